@@ -0,0 +1,146 @@
+package log
+
+import (
+	"errors"
+	"time"
+)
+
+// AdaptiveConfig bounds the adaptive bulk mode. The logger grows the flush
+// interval and buffer size to minimize the number of requests while keeping
+// the time from a message being logged to it being shipped under
+// TargetLatency.
+type AdaptiveConfig struct {
+	MinBufferSize    int
+	MaxBufferSize    int
+	MinFlushInterval time.Duration
+	MaxFlushInterval time.Duration
+	TargetLatency    time.Duration
+}
+
+type adaptive struct {
+	config AdaptiveConfig
+
+	// rate is a moving average of the event rate in events per second.
+	rate      float64
+	lastFlush time.Time
+}
+
+// rateSmoothing is the weight given to the newest rate observation.
+const rateSmoothing = 0.3
+
+// minAdaptiveInterval is the shortest flush interval, used when
+// MinFlushInterval is zero, as the flush loop needs a positive one.
+const minAdaptiveInterval = 10 * time.Millisecond
+
+// SetAdaptive enables adaptive tuning of the bulk buffer size and flush
+// interval within the given bounds. TargetLatency must be positive.
+func SetAdaptive(config AdaptiveConfig) error {
+	if config.TargetLatency <= 0 {
+		return errors.New("adaptive bulk mode requires a positive target latency")
+	}
+
+	if config.MinFlushInterval <= 0 {
+		config.MinFlushInterval = minAdaptiveInterval
+	}
+
+	loggerSingleton.Lock()
+	loggerSingleton.adaptive = &adaptive{config: config, lastFlush: loggerSingleton.clock.Now()}
+	loggerSingleton.Unlock()
+
+	return nil
+}
+
+// adapt retunes the buffer size and flush interval after a flush of messages
+// that started shipping at start.
+func (l *logger) adapt(messages []*logMessage, start time.Time) {
+	if len(messages) == 0 {
+		return
+	}
+
+	now := l.now()
+
+	l.Lock()
+
+	// A closed logger's last flush mustn't restart the flush loop.
+	a := l.adaptive
+	if a == nil || l.closed {
+		l.Unlock()
+		return
+	}
+
+	// Update the event rate from the time since the last flush.
+	if elapsed := now.Sub(a.lastFlush).Seconds(); elapsed > 0 {
+		rate := float64(len(messages)) / elapsed
+
+		if a.rate == 0 {
+			a.rate = rate
+		} else {
+			a.rate = rateSmoothing*rate + (1-rateSmoothing)*a.rate
+		}
+	}
+
+	a.lastFlush = now
+
+	latency := now.Sub(messages[0].queued)
+	shipping := now.Sub(start)
+	interval := l.flushInterval
+
+	switch {
+	case latency > a.config.TargetLatency:
+		// Messages are waiting too long, flush sooner.
+		interval /= 2
+	case latency < a.config.TargetLatency/2:
+		// Plenty of headroom, batch more per request.
+		interval += interval / 2
+	}
+
+	// Leave room for the request itself inside the target latency.
+	if budget := a.config.TargetLatency - shipping; interval > budget {
+		interval = budget
+	}
+
+	interval = clampDuration(interval, a.config.MinFlushInterval, a.config.MaxFlushInterval)
+
+	// Size the buffer so the interval, not the size limit, triggers flushes.
+	bufferSize := clampInt(int(a.rate*interval.Seconds())*2, a.config.MinBufferSize, a.config.MaxBufferSize)
+
+	changed := interval != l.flushInterval
+	l.flushInterval = interval
+	l.bufferSize = bufferSize
+
+	l.Unlock()
+
+	if changed && l.bulk {
+		l.startFlushLoop()
+	}
+}
+
+// clampDuration bounds a flush interval, which is never below
+// minAdaptiveInterval whatever min is.
+func clampDuration(d, min, max time.Duration) time.Duration {
+	if min < minAdaptiveInterval {
+		min = minAdaptiveInterval
+	}
+
+	if d < min {
+		return min
+	}
+
+	if max > 0 && d > max {
+		return max
+	}
+
+	return d
+}
+
+func clampInt(n, min, max int) int {
+	if n < min {
+		return min
+	}
+
+	if max > 0 && n > max {
+		return max
+	}
+
+	return n
+}
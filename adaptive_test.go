@@ -0,0 +1,100 @@
+package log
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newAdaptiveTestLogger(t *testing.T, config AdaptiveConfig) (*logger, *ManualClock, chan string) {
+	l, bodies := newTestLogger(t, true)
+
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.clock = clock
+	l.adaptive = &adaptive{config: config, lastFlush: clock.Now()}
+	t.Cleanup(func() {
+		if l.stop != nil {
+			close(l.stop)
+		}
+	})
+
+	return l, clock, bodies
+}
+
+func TestAdaptiveShrinksIntervalOverTarget(t *testing.T) {
+	l, clock, bodies := newAdaptiveTestLogger(t, AdaptiveConfig{
+		MinBufferSize:    10,
+		MaxBufferSize:    1000,
+		MinFlushInterval: time.Second,
+		MaxFlushInterval: time.Minute,
+		TargetLatency:    4 * time.Second,
+	})
+
+	l.buildAndShipMessage("This is an info statement.", LogLevelInfo, false, nil)
+	clock.Advance(10 * time.Second)
+	l.flush()
+	receive(t, bodies)
+
+	if l.flushInterval != 4*time.Second {
+		t.Errorf("expected the interval to fit the target latency, got %v", l.flushInterval)
+	}
+
+	if l.bufferSize != 10 {
+		t.Errorf("expected the minimum buffer size for a slow event rate, got %d", l.bufferSize)
+	}
+}
+
+func TestAdaptiveGrowsWithinBounds(t *testing.T) {
+	l, clock, bodies := newAdaptiveTestLogger(t, AdaptiveConfig{
+		MinBufferSize:    10,
+		MaxBufferSize:    500,
+		MinFlushInterval: time.Second,
+		MaxFlushInterval: 12 * time.Second,
+		TargetLatency:    time.Minute,
+	})
+
+	clock.Advance(time.Second)
+
+	for i := 0; i < 100; i++ {
+		l.buildAndShipMessage("This is an info statement.", LogLevelInfo, false, nil)
+	}
+
+	l.flush()
+	receive(t, bodies)
+
+	if l.flushInterval != 12*time.Second {
+		t.Errorf("expected the interval to grow to its maximum, got %v", l.flushInterval)
+	}
+
+	if l.bufferSize != 500 {
+		t.Errorf("expected the buffer size to grow to its maximum, got %d", l.bufferSize)
+	}
+}
+
+func TestAdaptiveIntervalStaysPositive(t *testing.T) {
+	l, clock, bodies := newAdaptiveTestLogger(t, AdaptiveConfig{TargetLatency: time.Second})
+
+	// Shipping takes longer than the whole target latency.
+	l.requestHook = func(*http.Request) error {
+		clock.Advance(2 * time.Second)
+		return nil
+	}
+
+	l.buildAndShipMessage("This is an info statement.", LogLevelInfo, false, nil)
+	l.flush()
+	receive(t, bodies)
+
+	if l.flushInterval != minAdaptiveInterval {
+		t.Errorf("expected the minimum interval, got %v", l.flushInterval)
+	}
+}
+
+func TestSetAdaptiveRequiresTargetLatency(t *testing.T) {
+	if err := SetAdaptive(AdaptiveConfig{MaxFlushInterval: time.Minute}); err == nil {
+		t.Error("expected an error without a target latency")
+	}
+
+	if loggerSingleton.adaptive != nil {
+		t.Error("expected adaptive mode left disabled")
+	}
+}
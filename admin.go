@@ -0,0 +1,84 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// AdminHandler returns an http.Handler for managing the logger at runtime.
+// Mount it with http.StripPrefix so it sees paths relative to its root:
+//
+//	GET    /filters       lists the filter and sampling rules
+//	POST   /filters       adds the FilterRule in the request body
+//	DELETE /filters/{id}  removes a rule
+//	GET    /top-talkers   returns the top talkers report
+//
+// The handler performs no authentication of its own. It manages the package
+// level logger of the moment, so it may be mounted before SetupLogger.
+func AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loggerSingleton.adminHandler().ServeHTTP(w, r)
+	})
+}
+
+func (l *logger) adminHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/filters", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, l.filterRules())
+		case http.MethodPost:
+			var rule FilterRule
+
+			if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			id, err := l.addFilter(rule)
+
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			rule.ID = id
+			writeJSON(w, http.StatusCreated, rule)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/filters/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !l.removeFilter(strings.TrimPrefix(r.URL.Path, "/filters/")) {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("/top-talkers", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, l.topTalkers())
+	})
+
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,74 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdminFilters(t *testing.T) {
+	l, _ := newTestLogger(t, true)
+
+	server := httptest.NewServer(l.adminHandler())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/filters", "application/json", strings.NewReader(`{"action":"drop","message":"^GET /health"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rule FilterRule
+	json.NewDecoder(resp.Body).Decode(&rule)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated || rule.ID == "" {
+		t.Fatalf("unexpected response %s %+v", resp.Status, rule)
+	}
+
+	if !l.filtered("GET /health 200", LogLevelInfo, nil, nil) {
+		t.Error("expected the rule added over the admin API to apply")
+	}
+
+	resp, err = http.Post(server.URL+"/filters", "application/json", strings.NewReader(`{"action":"drop","message":"("}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected an invalid rule to be rejected, got %s", resp.Status)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/filters/"+rule.ID, nil)
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent || len(l.filterRules()) != 0 {
+		t.Errorf("expected the rule to be removed, got %s", resp.Status)
+	}
+}
+
+func TestAdminHandlerFollowsSetup(t *testing.T) {
+	previous := loggerSingleton
+	t.Cleanup(func() { loggerSingleton = previous })
+
+	server := httptest.NewServer(AdminHandler())
+	defer server.Close()
+
+	l, _ := newTestLogger(t, true)
+	loggerSingleton = l
+
+	resp, err := http.Post(server.URL+"/filters", "application/json", strings.NewReader(`{"action":"drop","message":"^GET /health"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if !l.filtered("GET /health 200", LogLevelInfo, nil, nil) {
+		t.Error("expected the rule added to the logger set up after mounting")
+	}
+}
@@ -0,0 +1,170 @@
+package log
+
+import (
+	"fmt"
+	"time"
+)
+
+// AlertRule fires when Threshold events matching Match are logged within
+// Window, alerting on critical conditions faster than a Loggly alert can.
+// Once fired the count starts again, so a condition that persists fires
+// again each time the threshold is reached.
+type AlertRule struct {
+	Name string
+
+	// Match selects the events counted, its Action is ignored.
+	Match FilterRule
+
+	Threshold int
+	Window    time.Duration
+
+	// Callback, if set, is called with each alert on the logging goroutine
+	// and should return quickly.
+	Callback func(Alert)
+
+	// Sink, if set, receives each alert as a Warn level event, e.g. an
+	// HTTPSink posting to a webhook.
+	Sink Sink
+}
+
+// Alert describes a fired AlertRule.
+type Alert struct {
+	Rule   string
+	Count  int
+	Window time.Duration
+
+	// First and Last are when the first and last counted events were logged.
+	First time.Time
+	Last  time.Time
+
+	// Message is the message of the event that fired the alert.
+	Message string
+}
+
+type alertState struct {
+	rule  AlertRule
+	match *compiledRule
+	times []time.Time
+}
+
+// AddAlertRule adds a client side alert rule, evaluated against every event
+// that passes the logger's level.
+func AddAlertRule(rule AlertRule) error {
+	return loggerSingleton.addAlertRule(rule)
+}
+
+func (l *logger) addAlertRule(rule AlertRule) error {
+	if rule.Threshold < 1 || rule.Window <= 0 {
+		return fmt.Errorf("alert rule %s needs a positive threshold and window", rule.Name)
+	}
+
+	if rule.Callback == nil && rule.Sink == nil {
+		return fmt.Errorf("alert rule %s needs a callback or a sink", rule.Name)
+	}
+
+	match := rule.Match
+	match.Action = FilterKeep
+
+	compiled, err := compileRule(match)
+
+	if err != nil {
+		return err
+	}
+
+	l.Lock()
+	l.alerts = append(l.alerts, &alertState{rule: rule, match: compiled})
+	l.Unlock()
+
+	return nil
+}
+
+// evaluateAlerts counts the event against the alert rules, firing those that
+// reach their threshold.
+func (l *logger) evaluateAlerts(output string, level Level, d interface{}, extra map[string]interface{}) {
+	l.Lock()
+	empty := len(l.alerts) == 0
+	l.Unlock()
+
+	if empty {
+		return
+	}
+
+	fields := matchableFields(d, extra)
+	now := l.now()
+
+	var fired []AlertRule
+	var alerts []Alert
+
+	l.Lock()
+
+	for _, state := range l.alerts {
+		if !state.match.matches(output, level, fields) {
+			continue
+		}
+
+		// Forget the events that have left the window.
+		start := 0
+		for start < len(state.times) && now.Sub(state.times[start]) >= state.rule.Window {
+			start++
+		}
+
+		state.times = append(state.times[start:], now)
+
+		if len(state.times) < state.rule.Threshold {
+			continue
+		}
+
+		fired = append(fired, state.rule)
+		alerts = append(alerts, Alert{
+			Rule:    state.rule.Name,
+			Count:   len(state.times),
+			Window:  state.rule.Window,
+			First:   state.times[0],
+			Last:    now,
+			Message: output,
+		})
+
+		state.times = nil
+	}
+
+	l.Unlock()
+
+	for i, rule := range fired {
+		l.fireAlert(rule, alerts[i])
+	}
+}
+
+func (l *logger) fireAlert(rule AlertRule, alert Alert) {
+	if rule.Callback != nil {
+		rule.Callback(alert)
+	}
+
+	if rule.Sink == nil {
+		return
+	}
+
+	event := Event{
+		Time:    alert.Last,
+		Level:   LogLevelWarn,
+		Message: fmt.Sprintf("alert %s: %d events in %s", alert.Rule, alert.Count, alert.Window),
+		Fields: map[string]interface{}{
+			"alert":          alert.Rule,
+			"alert_count":    alert.Count,
+			"alert_window_s": alert.Window.Seconds(),
+			"alert_first":    alert.First.Format(time.RFC3339),
+			"alert_message":  alert.Message,
+		},
+	}
+
+	write := func() {
+		if err := rule.Sink.Write(event); err != nil && l.debugMode {
+			fmt.Printf("There was an error writing alert %s: %s", alert.Rule, err)
+		}
+	}
+
+	if l.isSynchronous() {
+		write()
+	} else {
+		go write()
+	}
+}
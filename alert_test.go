@@ -0,0 +1,71 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlertRule(t *testing.T) {
+	l, _ := newTestLogger(t, false)
+	l.shippingDisabled = true
+
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.clock = clock
+
+	var alerts []Alert
+	sink := &memorySink{}
+
+	err := l.addAlertRule(AlertRule{
+		Name:      "payments",
+		Match:     FilterRule{Message: "payment failed", Levels: []Level{LogLevelError}},
+		Threshold: 3,
+		Window:    time.Minute,
+		Callback:  func(a Alert) { alerts = append(alerts, a) },
+		Sink:      sink,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.log(record{output: "payment failed", level: LogLevelError})
+	clock.Advance(time.Minute)
+
+	// The first event has left the window by now.
+	l.log(record{output: "payment failed", level: LogLevelError})
+	l.log(record{output: "payment failed", level: LogLevelWarn})
+	l.log(record{output: "payment failed", level: LogLevelError})
+
+	if len(alerts) != 0 {
+		t.Fatalf("expected no alert yet, got %+v", alerts)
+	}
+
+	clock.Advance(time.Second)
+	l.log(record{output: "payment failed", level: LogLevelError})
+
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %+v", alerts)
+	}
+
+	if a := alerts[0]; a.Rule != "payments" || a.Count != 3 || a.Last.Sub(a.First) != time.Second {
+		t.Errorf("unexpected alert %+v", a)
+	}
+
+	events := sink.events
+
+	if len(events) != 1 || events[0].Message != "alert payments: 3 events in 1m0s" || events[0].Fields["alert_count"] != 3 {
+		t.Errorf("unexpected alert events %+v", events)
+	}
+}
+
+func TestAlertRuleValidation(t *testing.T) {
+	l, _ := newTestLogger(t, false)
+
+	if err := l.addAlertRule(AlertRule{Name: "empty", Threshold: 1, Window: time.Minute}); err == nil {
+		t.Error("expected a rule without a callback or sink to be rejected")
+	}
+
+	if err := l.addAlertRule(AlertRule{Name: "zero", Callback: func(Alert) {}}); err == nil {
+		t.Error("expected a rule without a threshold to be rejected")
+	}
+}
@@ -0,0 +1,156 @@
+package log
+
+import (
+	"math"
+	"time"
+)
+
+// anomalyScoreField carries how far an event's template rate deviates from
+// its usual rate, in standard deviations.
+const anomalyScoreField = "anomaly_score"
+
+// anomalyWarmup is how many intervals a template is measured over before
+// its events are scored.
+const anomalyWarmup = 3
+
+// maxSkippedIntervals bounds the idle intervals folded into a template's
+// averages when it is next logged.
+const maxSkippedIntervals = 100
+
+// AnomalyHints configures marking events whose template suddenly spikes.
+// Each message template's event count per Interval is tracked with
+// exponentially weighted moving averages, and events logged while their
+// template's count for the current interval is Threshold or more standard
+// deviations above its average carry an anomaly_score field.
+type AnomalyHints struct {
+	// Interval is the period rates are measured over, 1 minute if zero.
+	Interval time.Duration
+
+	// Alpha is the weight of the latest interval in the averages, 0.3 if
+	// zero.
+	Alpha float64
+
+	// Threshold is the score from which events are marked, 3 if zero.
+	Threshold float64
+}
+
+type templateRate struct {
+	start     time.Time
+	count     float64
+	mean      float64
+	variance  float64
+	intervals int
+}
+
+type anomalyHints struct {
+	config AnomalyHints
+	rates  map[string]*templateRate
+}
+
+// SetAnomalyHints enables anomaly scoring, replacing any previous rates.
+func SetAnomalyHints(hints AnomalyHints) {
+	if hints.Interval == 0 {
+		hints.Interval = time.Minute
+	}
+
+	if hints.Alpha == 0 {
+		hints.Alpha = 0.3
+	}
+
+	if hints.Threshold == 0 {
+		hints.Threshold = 3
+	}
+
+	loggerSingleton.Lock()
+	loggerSingleton.anomalies = &anomalyHints{config: hints, rates: map[string]*templateRate{}}
+	loggerSingleton.Unlock()
+}
+
+// withAnomalyScore counts the event against its template and adds an
+// anomaly_score field when its rate has spiked.
+func (l *logger) withAnomalyScore(output string, fields map[string]interface{}) map[string]interface{} {
+	l.Lock()
+	hints := l.anomalies
+	l.Unlock()
+
+	if hints == nil {
+		return fields
+	}
+
+	template := messageTemplate(output)
+	now := l.now()
+
+	l.Lock()
+	score, ok := hints.observe(template, now)
+	l.Unlock()
+
+	if !ok || score < hints.config.Threshold {
+		return fields
+	}
+
+	stamped := make(map[string]interface{}, len(fields)+1)
+
+	for key, value := range fields {
+		stamped[key] = value
+	}
+
+	stamped[anomalyScoreField] = math.Round(score*100) / 100
+
+	return stamped
+}
+
+// observe counts an event of template and returns its score, reporting false
+// while the template is warming up. It is called with the lock held.
+func (h *anomalyHints) observe(template string, now time.Time) (float64, bool) {
+	rate, ok := h.rates[template]
+
+	if !ok {
+		if len(h.rates) >= maxTemplates {
+			template = otherTemplate
+
+			if rate, ok = h.rates[template]; !ok {
+				rate = &templateRate{start: now}
+				h.rates[template] = rate
+			}
+		} else {
+			rate = &templateRate{start: now}
+			h.rates[template] = rate
+		}
+	}
+
+	// Fold finished intervals, idle ones included, into the averages.
+	elapsed := int(now.Sub(rate.start) / h.config.Interval)
+
+	for i := 0; i < elapsed && i < maxSkippedIntervals; i++ {
+		rate.fold(h.config.Alpha)
+	}
+
+	if elapsed > 0 {
+		rate.start = rate.start.Add(time.Duration(elapsed) * h.config.Interval)
+	}
+
+	rate.count++
+
+	if rate.intervals < anomalyWarmup {
+		return 0, false
+	}
+
+	// A steady rate still deserves some slack.
+	deviation := math.Max(math.Sqrt(rate.variance), 1)
+
+	return (rate.count - rate.mean) / deviation, true
+}
+
+// fold ends the current interval, updating the moving mean and variance.
+func (r *templateRate) fold(alpha float64) {
+	if r.intervals == 0 {
+		r.mean = r.count
+	} else {
+		diff := r.count - r.mean
+		r.mean += alpha * diff
+		r.variance = (1 - alpha) * (r.variance + alpha*diff*diff)
+	}
+
+	r.count = 0
+	r.intervals++
+}
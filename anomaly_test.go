@@ -0,0 +1,53 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnomalyScore(t *testing.T) {
+	hints := &anomalyHints{config: AnomalyHints{Interval: time.Minute, Alpha: 0.3, Threshold: 3}, rates: map[string]*templateRate{}}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Five quiet intervals of two events each.
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 2; j++ {
+			if _, ok := hints.observe("user # logged in", now); ok && i < anomalyWarmup {
+				t.Fatalf("expected no score while warming up in interval %d", i)
+			}
+		}
+
+		now = now.Add(time.Minute)
+	}
+
+	var score float64
+
+	for i := 0; i < 10; i++ {
+		score, _ = hints.observe("user # logged in", now)
+	}
+
+	if score < 3 {
+		t.Errorf("expected a spike to score at least 3, got %g", score)
+	}
+
+	if rate := hints.rates["user # logged in"]; rate.intervals != 5 || rate.mean != 2 {
+		t.Errorf("unexpected rate %+v", rate)
+	}
+}
+
+func TestAnomalyScoreField(t *testing.T) {
+	l, _ := newTestLogger(t, false)
+	l.clock = NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.anomalies = &anomalyHints{config: AnomalyHints{Interval: time.Minute, Alpha: 0.3, Threshold: 3}, rates: map[string]*templateRate{}}
+	l.anomalies.rates[messageTemplate("disk 42% full")] = &templateRate{start: l.now(), intervals: anomalyWarmup, mean: 1}
+
+	var fields map[string]interface{}
+
+	for i := 0; i < 5; i++ {
+		fields = l.withAnomalyScore("disk 97% full", nil)
+	}
+
+	if score, ok := fields[anomalyScoreField].(float64); !ok || score != 4 {
+		t.Errorf("expected an anomaly score of 4, got %+v", fields)
+	}
+}
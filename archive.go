@@ -0,0 +1,158 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// archivePrefix starts the name of every archive file.
+const archivePrefix = "loggly-"
+
+// archiveDateLayout dates the archive files.
+const archiveDateLayout = "2006-01-02"
+
+// Archive configures a local archive of everything shipped to Loggly, for
+// audits and reprocessing. Each batch Loggly accepts is appended to the file
+// for the UTC day, named like "loggly-2020-01-02.ndjson.gz", as newline
+// delimited JSON compressed on its own, so files are a concatenation of
+// compressed streams that gzip and zstd tools read as one.
+type Archive struct {
+	Dir string
+
+	// Codec compresses the batches, GzipCodec if nil.
+	Codec Codec
+
+	// Retention, if set, is how long archive files are kept. Older files are
+	// removed once a day.
+	Retention time.Duration
+}
+
+type archive struct {
+	config Archive
+	mu     sync.Mutex
+	day    string
+}
+
+// SetArchive archives every shipped batch under config. Pass a zero Archive
+// to stop archiving.
+func SetArchive(config Archive) error {
+	var a *archive
+
+	if config.Dir != "" {
+		if err := os.MkdirAll(config.Dir, 0755); err != nil {
+			return err
+		}
+
+		if config.Codec == nil {
+			config.Codec = GzipCodec
+		}
+
+		a = &archive{config: config}
+	}
+
+	loggerSingleton.Lock()
+	loggerSingleton.archive = a
+	loggerSingleton.Unlock()
+
+	return nil
+}
+
+// archiveBatch appends a shipped batch to the archive.
+func (l *logger) archiveBatch(body []byte) {
+	l.Lock()
+	a := l.archive
+	l.Unlock()
+
+	if a == nil {
+		return
+	}
+
+	if err := a.write(l.now(), body); err != nil && l.debugMode {
+		fmt.Printf("There was an error archiving a batch: %s", err)
+	}
+}
+
+func (a *archive) write(now time.Time, body []byte) error {
+	if !bytes.HasSuffix(body, []byte("\n")) {
+		body = append(append([]byte(nil), body...), '\n')
+	}
+
+	compressed, err := a.config.Codec.Compress(body)
+
+	if err != nil {
+		return err
+	}
+
+	day := now.UTC().Format(archiveDateLayout)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if day != a.day {
+		a.day = day
+
+		if a.config.Retention > 0 {
+			a.prune(now)
+		}
+	}
+
+	f, err := os.OpenFile(filepath.Join(a.config.Dir, a.fileName(day)), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(compressed)
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func (a *archive) fileName(day string) string {
+	return archivePrefix + day + a.extension()
+}
+
+func (a *archive) extension() string {
+	encoding := a.config.Codec.Encoding()
+
+	if encoding == "gzip" {
+		encoding = "gz"
+	}
+
+	return ".ndjson." + encoding
+}
+
+// prune removes archive files for days older than the retention.
+func (a *archive) prune(now time.Time) {
+	files, err := ioutil.ReadDir(a.config.Dir)
+
+	if err != nil {
+		return
+	}
+
+	cutoff := now.Add(-a.config.Retention)
+
+	for _, file := range files {
+		name := file.Name()
+
+		if !strings.HasPrefix(name, archivePrefix) || !strings.HasSuffix(name, a.extension()) {
+			continue
+		}
+
+		day, err := time.Parse(archiveDateLayout, strings.TrimSuffix(strings.TrimPrefix(name, archivePrefix), a.extension()))
+
+		// A day's file holds events up to its end.
+		if err == nil && day.AddDate(0, 0, 1).Before(cutoff) {
+			os.Remove(filepath.Join(a.config.Dir, name))
+		}
+	}
+}
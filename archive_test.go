@@ -0,0 +1,60 @@
+package log
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArchive(t *testing.T) {
+	dir := tempSpoolDir(t)
+	l, bodies := newTestLogger(t, true)
+	clock := NewManualClock(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+	l.clock = clock
+	l.archive = &archive{config: Archive{Dir: dir, Codec: GzipCodec, Retention: 24 * time.Hour}}
+
+	l.buildAndShipMessage("first", LogLevelInfo, false, nil)
+	l.flush()
+	l.buildAndShipMessage("second", LogLevelInfo, false, nil)
+	l.flush()
+	receive(t, bodies)
+	receive(t, bodies)
+
+	f, err := os.Open(filepath.Join(dir, "loggly-2020-01-01.ndjson.gz"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := ioutil.ReadAll(r)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+
+	if len(lines) != 2 || !strings.Contains(lines[0], "first") || !strings.Contains(lines[1], "second") {
+		t.Errorf("unexpected archive %q", b)
+	}
+
+	// Two days on, the first day's file is past the retention.
+	clock.Advance(48 * time.Hour)
+	l.buildAndShipMessage("third", LogLevelInfo, false, nil)
+	l.flush()
+	receive(t, bodies)
+
+	if _, err := os.Stat(filepath.Join(dir, "loggly-2020-01-01.ndjson.gz")); !os.IsNotExist(err) {
+		t.Errorf("expected the old archive pruned, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "loggly-2020-01-03.ndjson.gz")); err != nil {
+		t.Errorf("expected today's archive, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package log
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Assert logs msg at Error level with the caller's stack when cond is false
+// and returns cond, so it can guard the failing branch:
+//
+//	if !log.Assert(n >= 0, "negative count", "n", n) {
+//		return
+//	}
+//
+// keyvals are alternating keys and values shipped as top level fields.
+func Assert(cond bool, msg string, keyvals ...interface{}) bool {
+	return loggerSingleton.assert(cond, msg, keyvals)
+}
+
+// Check logs msg at Error level with the error and the caller's stack when err
+// is not nil, and returns err:
+//
+//	if err := log.Check(db.Ping(), "database unreachable"); err != nil {
+//		return err
+//	}
+func Check(err error, msg string, keyvals ...interface{}) error {
+	return loggerSingleton.check(err, msg, keyvals)
+}
+
+func (l *logger) assert(cond bool, msg string, keyvals []interface{}) bool {
+	if !cond {
+		l.logFailure(msg, nil, keyvals)
+	}
+
+	return cond
+}
+
+func (l *logger) check(err error, msg string, keyvals []interface{}) error {
+	if err != nil {
+		l.logFailure(msg, err, keyvals)
+	}
+
+	return err
+}
+
+// logFailure logs an Error level message with the stack of the code calling
+// Assert or Check.
+func (l *logger) logFailure(msg string, err error, keyvals []interface{}) {
+	fields := keyvalFields(keyvals)
+	fields["stack"] = callerStack(3)
+
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+
+	(&Entry{logger: l, fields: fields}).Errorln(msg)
+}
+
+// keyvalFields converts alternating keys and values into fields. Errors are
+// shipped as their text.
+func keyvalFields(keyvals []interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, len(keyvals)/2+2)
+
+	for i := 0; i < len(keyvals); i += 2 {
+		var value interface{} = "(MISSING)"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+
+		fields[fmt.Sprint(keyvals[i])] = value
+	}
+
+	return fields
+}
+
+// maxStackDepth bounds the frames captured by callerStack.
+const maxStackDepth = 32
+
+// callerStack renders the call stack above skip frames as "function
+// file:line" lines.
+func callerStack(skip int) string {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var b strings.Builder
+
+	for {
+		frame, more := frames.Next()
+
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+
+		if !more {
+			break
+		}
+	}
+
+	return b.String()
+}
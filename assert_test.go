@@ -0,0 +1,64 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAssert(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	// Mirror the exported wrapper so the stack starts at this test.
+	assert := func(cond bool, msg string, keyvals ...interface{}) bool {
+		return l.assert(cond, msg, keyvals)
+	}
+
+	if !assert(true, "negative count") {
+		t.Fatal("expected a passing assertion to return true")
+	}
+
+	if assert(false, "negative count", "n", -1) {
+		t.Fatal("expected a failing assertion to return false")
+	}
+
+	body := receive(t, bodies)
+
+	for _, want := range []string{`"level":"ERROR"`, `"message":"negative count"`, `"n":-1`, `"stack":"github.com/morlockaerospace/loggly.TestAssert`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in %q", want, body)
+		}
+	}
+
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected body for a passing assertion %q", body)
+	default:
+	}
+}
+
+func TestCheck(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	if err := l.check(nil, "unreachable", nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	err := errors.New("connection refused")
+
+	if got := l.check(err, "database unreachable", []interface{}{"host", "db1"}); got != err {
+		t.Fatalf("expected Check to return the error, got %v", got)
+	}
+
+	if body := receive(t, bodies); !strings.Contains(body, `"error":"connection refused"`) || !strings.Contains(body, `"host":"db1"`) {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestKeyvalFields(t *testing.T) {
+	fields := keyvalFields([]interface{}{"host", "db1", "err", errors.New("refused"), "dangling"})
+
+	if fields["host"] != "db1" || fields["err"] != "refused" || fields["dangling"] != "(MISSING)" {
+		t.Errorf("unexpected fields %+v", fields)
+	}
+}
@@ -0,0 +1,132 @@
+package log
+
+import (
+	"context"
+	"time"
+)
+
+// BatchInfo describes a single shipment to Loggly. Outside of bulk mode every
+// message is shipped as a batch of one.
+type BatchInfo struct {
+	// Events is the number of log messages in the batch.
+	Events int
+
+	// Bytes is the size of the request body.
+	Bytes int
+
+	// Latency is how long the request took.
+	Latency time.Duration
+
+	// Attempt is the delivery attempt the batch was on, starting at 1.
+	Attempt int
+}
+
+// OnBatchShipped registers a callback fired after every successful shipment.
+// Callbacks run on the shipping goroutine and should return quickly.
+func OnBatchShipped(callback func(BatchInfo)) {
+	loggerSingleton.Lock()
+	loggerSingleton.onShipped = append(loggerSingleton.onShipped, callback)
+	loggerSingleton.Unlock()
+}
+
+// OnBatchFailed registers a callback fired after every failed shipment.
+// Callbacks run on the shipping goroutine and should return quickly.
+func OnBatchFailed(callback func(BatchInfo, error)) {
+	loggerSingleton.Lock()
+	loggerSingleton.onFailed = append(loggerSingleton.onFailed, callback)
+	loggerSingleton.Unlock()
+}
+
+// SetPayloadInspector registers a callback invoked with the exact request body
+// and its event count just before every shipment, for archiving shipped
+// payloads or asserting on the wire format. The body must not be modified or
+// retained after the callback returns, copy it if needed. Pass nil to remove
+// the inspector.
+func SetPayloadInspector(inspector func(body []byte, events int)) {
+	loggerSingleton.Lock()
+	loggerSingleton.payloadInspector = inspector
+	loggerSingleton.Unlock()
+}
+
+// shipBatch posts body, which holds messages, and reports the result to the
+// messages' acks and the batch callbacks.
+func (l *logger) shipBatch(ctx context.Context, body []byte, messages []*logMessage) error {
+	if l.isSpoolOnly() {
+		return l.spoolOnlyBatch(messages)
+	}
+
+	l.Lock()
+	inspector := l.payloadInspector
+	l.Unlock()
+
+	if inspector != nil {
+		inspector(body, len(messages))
+	}
+
+	start := time.Now()
+
+	// Messages are batched per endpoint, so the first one's applies to all.
+	url := l.url
+	if len(messages) > 0 && messages[0].url != "" {
+		url = messages[0].url
+	}
+
+	attempt, err := l.postRetrying(ctx, url, body)
+
+	// A batch too large for the endpoint ships as two halves instead.
+	if bodies, halves, ok := splitBatch(err, body, messages); ok {
+		err = l.shipBatch(ctx, bodies[0], halves[0])
+
+		if halfErr := l.shipBatch(ctx, bodies[1], halves[1]); err == nil {
+			err = halfErr
+		}
+
+		return err
+	}
+
+	if oversizedEvent(err, messages) {
+		return l.shipOversized(ctx, body, messages[0])
+	}
+
+	info := BatchInfo{
+		Events:  len(messages),
+		Bytes:   len(body),
+		Latency: time.Since(start),
+		Attempt: attempt,
+	}
+
+	// Failed messages are handed to the spool when one is set, and the spool
+	// replays once shipping works again. Messages the endpoint rejected would
+	// only block the spool.
+	result := err
+	if err != nil && spoolable(err) && l.spoolMessages(messages) {
+		result = ErrSpooled
+	}
+
+	for _, m := range messages {
+		m.resolve(result)
+	}
+
+	l.stats.recordBatch(info, err)
+
+	l.Lock()
+	onShipped := l.onShipped
+	onFailed := l.onFailed
+	l.Unlock()
+
+	if err != nil {
+		for _, callback := range onFailed {
+			callback(info, err)
+		}
+	} else {
+		for _, callback := range onShipped {
+			callback(info)
+		}
+
+		l.recordVolume(messages)
+		l.archiveBatch(body)
+		l.replaySpool()
+	}
+
+	return err
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBatchShippedCallback(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	var shipped []BatchInfo
+	l.onShipped = append(l.onShipped, func(info BatchInfo) { shipped = append(shipped, info) })
+
+	l.buildAndShipMessage("This is an info statement 1.", LogLevelInfo, false, nil)
+	l.buildAndShipMessage("This is an info statement 2.", LogLevelInfo, false, nil)
+	l.flush()
+
+	body := receive(t, bodies)
+
+	if len(shipped) != 1 {
+		t.Fatalf("expected 1 shipped batch, got %d", len(shipped))
+	}
+
+	if info := shipped[0]; info.Events != 2 || info.Bytes != len(body) || info.Attempt != 1 {
+		t.Errorf("unexpected batch info %+v", info)
+	}
+}
+
+func TestBatchFailedCallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	l.url = server.URL
+	l.synchronous = true
+
+	var failures int
+	l.onFailed = append(l.onFailed, func(info BatchInfo, err error) {
+		failures++
+
+		if info.Events != 1 || err == nil {
+			t.Errorf("unexpected failure report %+v: %v", info, err)
+		}
+	})
+
+	l.buildAndShipMessage("This will fail.", LogLevelInfo, false, nil)
+
+	if failures != 1 {
+		t.Errorf("expected 1 failed batch, got %d", failures)
+	}
+}
+
+func TestPayloadInspector(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	var inspected string
+	var events int
+	l.payloadInspector = func(body []byte, n int) {
+		inspected = string(body)
+		events = n
+	}
+
+	l.buildAndShipMessage("This is an info statement 1.", LogLevelInfo, false, nil)
+	l.buildAndShipMessage("This is an info statement 2.", LogLevelInfo, false, nil)
+	l.flush()
+
+	if body := receive(t, bodies); inspected != body || events != 2 {
+		t.Errorf("inspector saw %d events in %q, shipped %q", events, inspected, body)
+	}
+}
@@ -0,0 +1,109 @@
+package log
+
+import (
+	"time"
+)
+
+// VerbosityBoost configures raising a component's verbosity after it logs an
+// error.
+type VerbosityBoost struct {
+	// Window is how long the boost lasts after the component's last error.
+	Window time.Duration
+
+	// Level is the component's effective level during the boost, Debug when
+	// zero.
+	Level Level
+}
+
+type verbosityBoost struct {
+	config VerbosityBoost
+
+	// until holds when each boosted component's window ends.
+	until map[string]time.Time
+}
+
+// SetVerbosityBoost lowers the level of a component, identified by the
+// component field of the metadata or the event's fields, for a window after
+// it logs an Error or Fatal message, so the lead up to a repeat failure is
+// captured in detail. A zero Window disables boosting.
+func SetVerbosityBoost(config VerbosityBoost) {
+	if config.Level == 0 {
+		config.Level = LogLevelDebug
+	}
+
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	if config.Window <= 0 {
+		loggerSingleton.boost = nil
+		return
+	}
+
+	loggerSingleton.boost = &verbosityBoost{config: config, until: map[string]time.Time{}}
+}
+
+// effectiveLevel returns the level events of the component logging d and
+// fields must reach, the logger's level unless the component is boosted.
+func (l *logger) effectiveLevel(d interface{}, fields map[string]interface{}) Level {
+	l.Lock()
+	level, boost := l.Level, l.boost
+	boosting := boost != nil && len(boost.until) > 0
+	l.Unlock()
+
+	if !boosting {
+		return level
+	}
+
+	component, ok := componentOf(d, fields)
+
+	if !ok {
+		return level
+	}
+
+	now := l.now()
+
+	l.Lock()
+	defer l.Unlock()
+
+	until, ok := boost.until[component]
+
+	if !ok {
+		return level
+	}
+
+	// The window ended, restore the component's level.
+	if !now.Before(until) {
+		delete(boost.until, component)
+		return level
+	}
+
+	if !boost.config.Level.AtLeast(level) {
+		return boost.config.Level
+	}
+
+	return level
+}
+
+// boostComponent starts or extends the boost window of the component of an
+// Error or Fatal event.
+func (l *logger) boostComponent(d interface{}, fields map[string]interface{}) {
+	l.Lock()
+	boost := l.boost
+	l.Unlock()
+
+	if boost == nil {
+		return
+	}
+
+	component, ok := componentOf(d, fields)
+
+	if !ok {
+		return
+	}
+
+	now := l.now()
+
+	l.Lock()
+	boost.until[component] = now.Add(boost.config.Window)
+	l.Unlock()
+}
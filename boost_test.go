@@ -0,0 +1,40 @@
+package log
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerbosityBoost(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.Level = LogLevelInfo
+
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.clock = clock
+	l.boost = &verbosityBoost{config: VerbosityBoost{Window: time.Minute, Level: LogLevelDebug}, until: map[string]time.Time{}}
+
+	radio := map[string]interface{}{"component": "radio"}
+
+	l.buildAndShipMessage("radio debug", LogLevelDebug, false, radio)
+	l.buildAndShipMessage("radio failed", LogLevelError, false, radio)
+	receive(t, bodies)
+
+	// Only the failing component is boosted, and only down to Debug.
+	l.buildAndShipMessage("gps debug", LogLevelDebug, false, map[string]interface{}{"component": "gps"})
+	l.buildAndShipMessage("radio trace", LogLevelTrace, false, radio)
+	l.buildAndShipMessage("radio debug", LogLevelDebug, false, radio)
+
+	if body := receive(t, bodies); !strings.Contains(body, `"message":"radio debug"`) {
+		t.Errorf("expected the boosted debug event, got %q", body)
+	}
+
+	clock.Advance(time.Minute)
+	l.buildAndShipMessage("radio debug", LogLevelDebug, false, radio)
+
+	select {
+	case body := <-bodies:
+		t.Errorf("expected the level to be restored after the window, got %q", body)
+	default:
+	}
+}
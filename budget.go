@@ -0,0 +1,120 @@
+package log
+
+import (
+	"errors"
+	"time"
+)
+
+// Budget limits how many events a component ships per period. Once the limit
+// is reached only SampleRate of the component's further events are shipped
+// until the period ends.
+type Budget struct {
+	Limit      int
+	Period     time.Duration
+	SampleRate float64
+}
+
+type budgetState struct {
+	budget  Budget
+	start   time.Time
+	count   int
+	dropped int
+}
+
+// SetBudget assigns an event budget to a component, identified by the
+// component field of the metadata or the event's fields. A Warn level summary
+// is shipped when the budget is first exceeded and once the period ends if
+// events were dropped. Period must be positive.
+func SetBudget(component string, budget Budget) error {
+	if budget.Period <= 0 {
+		return errors.New("log budget requires a positive period")
+	}
+
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	if loggerSingleton.budgets == nil {
+		loggerSingleton.budgets = map[string]*budgetState{}
+	}
+
+	loggerSingleton.budgets[component] = &budgetState{budget: budget, start: loggerSingleton.clock.Now()}
+
+	return nil
+}
+
+// overBudget counts the event against its component's budget and reports
+// whether it should be dropped.
+func (l *logger) overBudget(d interface{}, fields map[string]interface{}) bool {
+	l.Lock()
+	empty := len(l.budgets) == 0
+	l.Unlock()
+
+	if empty {
+		return false
+	}
+
+	component, ok := componentOf(d, fields)
+
+	if !ok {
+		return false
+	}
+
+	now := l.now()
+
+	l.Lock()
+
+	state, ok := l.budgets[component]
+
+	if !ok {
+		l.Unlock()
+		return false
+	}
+
+	var summary map[string]interface{}
+
+	// Start a new period, reporting what the last one dropped.
+	if now.Sub(state.start) >= state.budget.Period {
+		if state.dropped > 0 {
+			summary = state.summary(component)
+		}
+
+		state.start = now
+		state.count = 0
+		state.dropped = 0
+	}
+
+	state.count++
+
+	exceeded := state.count == state.budget.Limit+1
+	drop := state.count > state.budget.Limit && !sampled(state.budget.SampleRate, l.sampleKeys, d, fields)
+
+	if drop {
+		state.dropped++
+	}
+
+	if exceeded {
+		summary = state.summary(component)
+	}
+
+	l.Unlock()
+
+	if summary != nil {
+		l.buildAndShipMessage("log budget exceeded", LogLevelWarn, false, summary)
+	}
+
+	if drop {
+		l.stats.recordFiltered()
+	}
+
+	return drop
+}
+
+func (s *budgetState) summary(component string) map[string]interface{} {
+	return map[string]interface{}{
+		"budget_component": component,
+		"budget_limit":     s.budget.Limit,
+		"budget_period":    s.budget.Period.String(),
+		"budget_events":    s.count,
+		"budget_dropped":   s.dropped,
+	}
+}
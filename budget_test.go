@@ -0,0 +1,65 @@
+package log
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.clock = clock
+	l.budgets = map[string]*budgetState{
+		"radio": {budget: Budget{Limit: 2, Period: time.Hour}, start: clock.Now()},
+	}
+
+	radio := map[string]interface{}{"component": "radio"}
+
+	for i := 0; i < 5; i++ {
+		l.buildAndShipMessage("radio chatter", LogLevelInfo, false, radio)
+	}
+
+	l.buildAndShipMessage("gps fix", LogLevelInfo, false, map[string]interface{}{"component": "gps"})
+	l.flush()
+
+	body := receive(t, bodies)
+
+	if n := strings.Count(body, "radio chatter"); n != 2 {
+		t.Errorf("expected 2 radio events within budget, got %d", n)
+	}
+
+	if !strings.Contains(body, "gps fix") || strings.Count(body, "log budget exceeded") != 1 {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	// The next period reports how many events were dropped.
+	clock.Advance(time.Hour)
+	l.buildAndShipMessage("radio chatter", LogLevelInfo, false, radio)
+	l.flush()
+
+	body = receive(t, bodies)
+
+	if !strings.Contains(body, `"budget_dropped":3`) || !strings.Contains(body, "radio chatter") {
+		t.Errorf("expected a summary and the new period's event, got %q", body)
+	}
+}
+
+func TestSetBudgetRequiresPeriod(t *testing.T) {
+	previous := loggerSingleton
+	loggerSingleton = newDefaultLogger()
+	t.Cleanup(func() { loggerSingleton = previous })
+
+	if err := SetBudget("radio", Budget{Limit: 2}); err == nil {
+		t.Error("expected an error for a zero period")
+	}
+
+	if len(loggerSingleton.budgets) != 0 {
+		t.Error("expected the budget not to be set")
+	}
+
+	if err := SetBudget("radio", Budget{Limit: 2, Period: time.Minute}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package log
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// SetEventBuffer keeps the last n events, of any level, in memory for
+// WriteSupportBundle. Zero disables the buffer.
+func SetEventBuffer(n int) {
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	if n <= 0 {
+		loggerSingleton.recent = nil
+		return
+	}
+
+	loggerSingleton.recent = newEventRing(n)
+}
+
+// WriteSupportBundle writes a zip archive of diagnostics to w: the buffered
+// recent events (see SetEventBuffer), the shipping and spool statistics, the
+// top talkers and the redacted effective configuration.
+func WriteSupportBundle(w io.Writer) error {
+	return loggerSingleton.writeSupportBundle(w)
+}
+
+func (l *logger) writeSupportBundle(w io.Writer) error {
+	l.Lock()
+	recent := l.recent
+	s := l.spool
+	l.Unlock()
+
+	config := l.configSummary()
+
+	stats := map[string]interface{}{
+		"shipping": l.stats.snapshot(),
+	}
+
+	if s != nil {
+		stats["spool"] = s.stats()
+	}
+
+	stats["top_talkers"] = l.topTalkers()
+
+	archive := zip.NewWriter(w)
+	modified := l.now()
+
+	files := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{"events.ndjson", func(w io.Writer) error { return recent.write(w) }},
+		{"stats.json", func(w io.Writer) error { return writeIndented(w, stats) }},
+		{"config.json", func(w io.Writer) error {
+			return writeIndented(w, map[string]interface{}{
+				"config":      config,
+				"fingerprint": fingerprintConfig(config),
+			})
+		}},
+	}
+
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: modified})
+
+		if err != nil {
+			return err
+		}
+
+		if err := file.write(f); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func writeIndented(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+// eventRing holds the most recent events.
+type eventRing struct {
+	sync.Mutex
+	events []Event
+	next   int
+	full   bool
+}
+
+func newEventRing(n int) *eventRing {
+	return &eventRing{events: make([]Event, n)}
+}
+
+func (r *eventRing) add(e Event) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.events[r.next] = e
+	r.next = (r.next + 1) % len(r.events)
+
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// snapshot returns the buffered events, oldest first.
+func (r *eventRing) snapshot() []Event {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.ordered()
+}
+
+// drain returns the buffered events, oldest first, and empties the ring.
+func (r *eventRing) drain() []Event {
+	r.Lock()
+	defer r.Unlock()
+
+	events := r.ordered()
+	r.next, r.full = 0, false
+
+	return events
+}
+
+// ordered returns the buffered events, oldest first. It is called with the
+// lock held.
+func (r *eventRing) ordered() []Event {
+	if !r.full {
+		return append([]Event(nil), r.events[:r.next]...)
+	}
+
+	return append(append([]Event(nil), r.events[r.next:]...), r.events[:r.next]...)
+}
+
+// write writes the buffered events as newline delimited JSON. A nil ring
+// writes nothing.
+func (r *eventRing) write(w io.Writer) error {
+	if r == nil {
+		return nil
+	}
+
+	sink := NewWriterSink(w)
+
+	for _, e := range r.snapshot() {
+		if err := sink.Write(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// remember buffers an event before level filtering, so the bundle and the
+// tail show what happened leading up to a problem even at levels that aren't
+// shipped. Sensitive fields are protected before the event is kept.
+func (l *logger) remember(level Level, output string, d interface{}, fields map[string]interface{}) {
+	l.Lock()
+	recent := l.recent
+	l.Unlock()
+
+	if recent == nil {
+		return
+	}
+
+	d, fields = l.shippedValues(d, fields)
+
+	recent.add(Event{Time: l.now(), Level: level, Message: output, Metadata: d, Fields: fields})
+}
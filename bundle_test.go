@@ -0,0 +1,70 @@
+package log
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestEventRing(t *testing.T) {
+	r := newEventRing(2)
+
+	for _, message := range []string{"one", "two", "three"} {
+		r.add(Event{Message: message})
+	}
+
+	events := r.snapshot()
+
+	if len(events) != 2 || events[0].Message != "two" || events[1].Message != "three" {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
+
+func TestWriteSupportBundle(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.Level = LogLevelInfo
+	l.recent = newEventRing(10)
+
+	l.buildAndShipMessage("This is a trace statement.", LogLevelTrace, false, nil)
+	l.buildAndShipMessage("This is an info statement.", LogLevelInfo, false, map[string]interface{}{"order": 7})
+	receive(t, bodies)
+
+	var buf bytes.Buffer
+	if err := l.writeSupportBundle(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{}
+
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, _ := ioutil.ReadAll(r)
+		r.Close()
+
+		files[f.Name] = string(b)
+	}
+
+	// Events below the logger's level are kept too.
+	if events := files["events.ndjson"]; strings.Count(events, "\n") != 2 || !strings.Contains(events, "trace statement") || !strings.Contains(events, `"order":7`) {
+		t.Errorf("unexpected events %q", events)
+	}
+
+	if !strings.Contains(files["stats.json"], `"EventsShipped": 1`) {
+		t.Errorf("unexpected stats %q", files["stats.json"])
+	}
+
+	if config := files["config.json"]; !strings.Contains(config, `"fingerprint"`) || strings.Contains(config, "yourlogglytoken") {
+		t.Errorf("unexpected config %q", config)
+	}
+}
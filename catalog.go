@@ -0,0 +1,88 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrUnknownEventCode is returned by Emit for codes missing from the catalog.
+var ErrUnknownEventCode = errors.New("unknown event code")
+
+// CatalogEntry describes a catalogued event.
+type CatalogEntry struct {
+	Level Level
+
+	// Template is the message, with {name} placeholders replaced by the
+	// fields passed to Emit.
+	Template string
+}
+
+// eventCodeField is the top level field carrying an event's catalog code.
+const eventCodeField = "event_code"
+
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z0-9_.]+)\}`)
+
+// RegisterCatalog adds event codes to the catalog, replacing existing
+// entries with the same code.
+func RegisterCatalog(entries map[string]CatalogEntry) {
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	if loggerSingleton.catalog == nil {
+		loggerSingleton.catalog = map[string]CatalogEntry{}
+	}
+
+	for code, entry := range entries {
+		loggerSingleton.catalog[code] = entry
+	}
+}
+
+// Emit logs the catalogued event code, e.g. "NAV-042", at its level. The
+// message is rendered from the entry's template and fields, which are
+// shipped along with an "event_code" field so events can be identified
+// downstream even if the wording changes. An unknown code is logged at Warn
+// level and reported with ErrUnknownEventCode.
+func Emit(code string, fields map[string]interface{}) error {
+	return loggerSingleton.emit(code, fields)
+}
+
+func (l *logger) emit(code string, fields map[string]interface{}) error {
+	l.Lock()
+	entry, ok := l.catalog[code]
+	l.Unlock()
+
+	event := make(map[string]interface{}, len(fields)+1)
+
+	for key, value := range fields {
+		event[key] = value
+	}
+
+	event[eventCodeField] = code
+
+	e := &Entry{logger: l, fields: event}
+
+	if !ok {
+		e.Warnln(fmt.Sprintf("unregistered event code %s", code))
+
+		return fmt.Errorf("%w: %s", ErrUnknownEventCode, code)
+	}
+
+	e.Logln(entry.Level, renderTemplate(entry.Template, fields))
+
+	return nil
+}
+
+// renderTemplate replaces {name} placeholders with field values, leaving
+// placeholders without a field as they are.
+func renderTemplate(template string, fields map[string]interface{}) string {
+	return placeholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		value, ok := fields[placeholder[1:len(placeholder)-1]]
+
+		if !ok {
+			return placeholder
+		}
+
+		return fmt.Sprint(value)
+	})
+}
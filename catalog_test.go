@@ -0,0 +1,42 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	got := renderTemplate("waypoint {id} reached at {altitude}m, {missing}", map[string]interface{}{"id": "WP7", "altitude": 1200})
+
+	if got != "waypoint WP7 reached at 1200m, {missing}" {
+		t.Errorf("unexpected message %q", got)
+	}
+}
+
+func TestEmit(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.catalog = map[string]CatalogEntry{
+		"NAV-042": {Level: LogLevelWarn, Template: "waypoint {id} missed by {offset}m"},
+	}
+
+	if err := l.emit("NAV-042", map[string]interface{}{"id": "WP7", "offset": 35}); err != nil {
+		t.Fatal(err)
+	}
+
+	body := receive(t, bodies)
+
+	for _, want := range []string{`"level":"WARN"`, `"message":"waypoint WP7 missed by 35m"`, `"event_code":"NAV-042"`, `"offset":35`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in %q", want, body)
+		}
+	}
+
+	if err := l.emit("NAV-999", nil); !errors.Is(err, ErrUnknownEventCode) {
+		t.Errorf("expected ErrUnknownEventCode, got %v", err)
+	}
+
+	if body := receive(t, bodies); !strings.Contains(body, `"message":"unregistered event code NAV-999"`) {
+		t.Errorf("unexpected body %q", body)
+	}
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FormatGitHubActions renders Warn and Error events as GitHub Actions
+// workflow commands, so they surface as annotations on the run, and other
+// events as FormatConsole does. The annotation points at the "file" and
+// "line" fields when the event has them.
+//
+//	::error file=import.go,line=12::row 12 invalid
+func FormatGitHubActions(e Event) string {
+	var command string
+
+	switch {
+	case e.Level.AtLeast(LogLevelError):
+		command = "error"
+	case e.Level.AtLeast(LogLevelWarn):
+		command = "warning"
+	default:
+		return FormatConsole(e)
+	}
+
+	var properties []string
+
+	if file, ok := e.Fields["file"]; ok {
+		properties = append(properties, "file="+escapeProperty(fmt.Sprint(file)))
+	}
+
+	if line, ok := e.Fields["line"]; ok {
+		properties = append(properties, "line="+escapeProperty(fmt.Sprint(line)))
+	}
+
+	message := e.Message
+
+	if e.Metadata != nil {
+		message += fmt.Sprintf(" %+v", e.Metadata)
+	}
+
+	annotation := "::" + command
+
+	if len(properties) > 0 {
+		annotation += " " + strings.Join(properties, ",")
+	}
+
+	return annotation + "::" + escapeData(message)
+}
+
+// SetCIAnnotations makes the console emit GitHub Actions annotations when
+// running in a GitHub Actions workflow, reporting whether it did. GitLab CI
+// reads annotations from report artifacts rather than job logs, so it is
+// left alone.
+func SetCIAnnotations() bool {
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		return false
+	}
+
+	SetConsole(ConsoleWriter{Writer: os.Stdout, Level: LogLevelTrace, Format: FormatGitHubActions})
+
+	return true
+}
+
+var (
+	dataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	propertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func escapeData(s string) string {
+	return dataEscaper.Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return propertyEscaper.Replace(s)
+}
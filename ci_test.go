@@ -0,0 +1,26 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatGitHubActions(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		event    Event
+		expected string
+	}{
+		{Event{Time: now, Level: LogLevelInfo, Message: "import started"}, "2020-01-01T00:00:00Z [INFO] import started"},
+		{Event{Time: now, Level: LogLevelWarn, Message: "skipped row"}, "::warning::skipped row"},
+		{Event{Time: now, Level: LogLevelError, Message: "row 12 invalid\n100% broken", Fields: map[string]interface{}{"file": "data/a,b.csv", "line": 12}}, "::error file=data/a%2Cb.csv,line=12::row 12 invalid%0A100%25 broken"},
+		{Event{Time: now, Level: LogLevelFatal, Message: "out of disk", Metadata: map[string]int{"free": 0}}, "::error::out of disk map[free:0]"},
+	}
+
+	for _, test := range tests {
+		if line := FormatGitHubActions(test.event); line != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, line)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// classificationField holds the data classification categories of an event.
+const classificationField = "classification"
+
+// redactedField marks an event whose metadata was removed by the
+// classification policy.
+const redactedField = "redacted"
+
+// ErrNotPermitted is reported on a delivery channel when the classification
+// policy dropped the event.
+var ErrNotPermitted = errors.New("log event classification is not permitted")
+
+// ClassificationAction is what the classification policy does with events
+// of a category.
+type ClassificationAction int
+
+const (
+	// ClassificationAllow logs the event as is.
+	ClassificationAllow ClassificationAction = iota
+
+	// ClassificationRedact logs the event without its metadata, keeping the
+	// message and fields, for statements whose payload carries the
+	// classified data.
+	ClassificationRedact
+
+	// ClassificationDrop discards the event before it is printed, recorded,
+	// shipped or written to a sink.
+	ClassificationDrop
+)
+
+// ClassificationPolicy decides which data classification categories may be
+// logged by a deployment, such as no "pii" events in a region. An event with
+// several categories gets the strictest of their actions. Unclassified
+// events are always logged.
+type ClassificationPolicy struct {
+	// Categories maps categories to their action.
+	Categories map[string]ClassificationAction
+
+	// Default is the action for categories not listed, allowing them if
+	// zero. Set it to ClassificationDrop to permit listed categories only.
+	Default ClassificationAction
+}
+
+// WithClassification returns an Entry whose messages carry the data
+// classification categories, see SetClassificationPolicy.
+func WithClassification(categories ...string) *Entry {
+	return (&Entry{}).WithClassification(categories...)
+}
+
+// WithClassification returns a copy of the entry whose messages also carry
+// the data classification categories.
+func (e *Entry) WithClassification(categories ...string) *Entry {
+	merged := map[string]bool{}
+
+	for _, category := range append(classificationsOf(e.fields), categories...) {
+		merged[category] = true
+	}
+
+	all := make([]string, 0, len(merged))
+
+	for category := range merged {
+		all = append(all, category)
+	}
+
+	sort.Strings(all)
+
+	return e.With(classificationField, all)
+}
+
+// SetClassificationPolicy enforces policy on every event. Pass a zero
+// ClassificationPolicy to allow every category again.
+func SetClassificationPolicy(policy ClassificationPolicy) {
+	loggerSingleton.Lock()
+	loggerSingleton.classification = policy
+	loggerSingleton.Unlock()
+}
+
+// classify applies the classification policy to an event, returning its
+// metadata, possibly redacted, and false if it is dropped.
+func (l *logger) classify(d interface{}, fields map[string]interface{}) (interface{}, map[string]interface{}, bool) {
+	l.Lock()
+	policy := l.classification
+	l.Unlock()
+
+	if len(policy.Categories) == 0 && policy.Default == ClassificationAllow {
+		return d, fields, true
+	}
+
+	action := ClassificationAllow
+
+	for _, category := range classificationsOf(fields) {
+		categoryAction, ok := policy.Categories[category]
+
+		if !ok {
+			categoryAction = policy.Default
+		}
+
+		if categoryAction > action {
+			action = categoryAction
+		}
+	}
+
+	switch action {
+	case ClassificationDrop:
+		l.stats.recordFiltered()
+		return nil, fields, false
+	case ClassificationRedact:
+		if d == nil {
+			return d, fields, true
+		}
+
+		redacted := make(map[string]interface{}, len(fields)+1)
+
+		for key, value := range fields {
+			redacted[key] = value
+		}
+
+		redacted[redactedField] = true
+
+		return nil, redacted, true
+	}
+
+	return d, fields, true
+}
+
+// classificationsOf returns the categories in an event's fields.
+func classificationsOf(fields map[string]interface{}) []string {
+	switch categories := fields[classificationField].(type) {
+	case string:
+		return []string{categories}
+	case []string:
+		return categories
+	case []interface{}:
+		names := make([]string, len(categories))
+
+		for i, category := range categories {
+			names[i] = fmt.Sprint(category)
+		}
+
+		return names
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithClassification(t *testing.T) {
+	entry := WithClassification("pii").WithRetention("audit").WithClassification("financial", "pii")
+
+	if categories := entry.fields[classificationField]; !reflect.DeepEqual(categories, []string{"financial", "pii"}) {
+		t.Errorf("unexpected categories %v", categories)
+	}
+}
+
+func TestClassificationPolicy(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.classification = ClassificationPolicy{Categories: map[string]ClassificationAction{"pii": ClassificationDrop, "financial": ClassificationRedact}}
+
+	pii := WithClassification("pii", "financial")
+	pii.logger = l
+	financial := WithClassification("financial")
+	financial.logger = l
+
+	ack := make(chan error, 1)
+	l.log(record{output: "user signed up", level: LogLevelInfo, data: map[string]string{"email": "ada@example.com"}, fields: pii.fields, ack: ack})
+
+	if err := <-ack; err != ErrNotPermitted {
+		t.Errorf("expected ErrNotPermitted, got %v", err)
+	}
+
+	financial.Infod("payment captured", map[string]string{"iban": "DE89370400440532013000"})
+
+	if body := receive(t, bodies); strings.Contains(body, "DE89") || !strings.Contains(body, `"redacted":true`) || !strings.Contains(body, "payment captured") {
+		t.Errorf("expected the metadata redacted, got %q", body)
+	}
+
+	l.buildAndShipMessage("unclassified", LogLevelInfo, false, map[string]string{"id": "7"})
+
+	if body := receive(t, bodies); !strings.Contains(body, `"id":"7"`) {
+		t.Errorf("expected unclassified events logged, got %q", body)
+	}
+
+	l.classification = ClassificationPolicy{Categories: map[string]ClassificationAction{"public": ClassificationAllow}, Default: ClassificationDrop}
+	financial.Infoln("This is dropped as financial isn't listed.")
+
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected body %q", body)
+	default:
+	}
+}
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManualClockTicker(t *testing.T) {
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	ticker := clock.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	clock.Advance(5 * time.Second)
+
+	select {
+	case <-ticker.C():
+		t.Fatal("ticker fired early")
+	default:
+	}
+
+	clock.Advance(5 * time.Second)
+
+	select {
+	case tick := <-ticker.C():
+		if !tick.Equal(clock.Now()) {
+			t.Errorf("expected tick at %v, got %v", clock.Now(), tick)
+		}
+	default:
+		t.Fatal("ticker did not fire")
+	}
+}
+
+func TestManualClockDrivesFlush(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.clock = clock
+	l.startFlushLoop()
+	defer close(l.stop)
+
+	l.buildAndShipMessage("This is an info statement.", LogLevelInfo, false, nil)
+
+	clock.Advance(l.flushInterval)
+
+	body := receive(t, bodies)
+	if !strings.Contains(body, "2020-01-01T00:00:00Z") {
+		t.Errorf("expected the manual clock's timestamp, got %q", body)
+	}
+}
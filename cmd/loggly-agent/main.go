@@ -0,0 +1,83 @@
+// Command loggly-agent follows the log files of programs that can't log to
+// Loggly directly and ships their lines as they are written.
+//
+//	loggly-agent -token TOKEN -tags nginx,prod -format common /var/log/nginx/access.log
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	loggly "github.com/morlockaerospace/loggly"
+	"github.com/morlockaerospace/loggly/tailer"
+)
+
+// parsers are the line formats selectable with -format.
+var parsers = map[string]loggly.LineParser{
+	"plain":  loggly.ParsePlainLines,
+	"json":   loggly.ParseJSONLines,
+	"common": loggly.ParseCommonLog,
+}
+
+func main() {
+	token := flag.String("token", os.Getenv("LOGGLY_TOKEN"), "Loggly customer token, defaults to $LOGGLY_TOKEN")
+	tags := flag.String("tags", "", "comma separated Loggly tags")
+	url := flag.String("url", "", "endpoint overriding the Loggly bulk endpoint")
+	format := flag.String("format", "plain", "line format: plain, json or common")
+	fromStart := flag.Bool("from-start", false, "ship the lines the files already hold")
+	poll := flag.Duration("poll", time.Second, "how often to check the files besides notifications")
+	flag.Parse()
+
+	parser, ok := parsers[*format]
+
+	if flag.NArg() == 0 || !ok || (*token == "" && *url == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := []loggly.Option{loggly.WithBulk(0, 0)}
+
+	if *tags != "" {
+		opts = append(opts, loggly.WithTags(strings.Split(*tags, ",")...))
+	}
+
+	if *url != "" {
+		opts = append(opts, loggly.WithEndpoint(*url))
+	}
+
+	logger := loggly.New(*token, opts...)
+	defer logger.Close()
+
+	t, err := tailer.New(logger, tailer.Config{
+		Paths:        flag.Args(),
+		Parser:       parser,
+		FromStart:    *fromStart,
+		PollInterval: *poll,
+	})
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	if err := t.Run(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
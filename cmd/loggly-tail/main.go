@@ -0,0 +1,105 @@
+// Command loggly-tail is an interactive terminal tail for incident triage. It
+// follows a single instance's live events through the TailHandler stream, or
+// a Loggly search:
+//
+//	loggly-tail -stream https://host/debug/tail -token TOKEN
+//	loggly-tail -account ACCOUNT -api-token TOKEN -query 'tag:prod' -follow 5s
+//
+// While it runs, commands typed on standard input filter and search what is
+// shown, see the help command.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	loggly "github.com/morlockaerospace/loggly"
+)
+
+func main() {
+	stream := flag.String("stream", "", "URL of a TailHandler to follow")
+	token := flag.String("token", os.Getenv("LOGGLY_TAIL_TOKEN"), "TailHandler token, defaults to $LOGGLY_TAIL_TOKEN")
+	account := flag.String("account", "", "Loggly account (subdomain) to search")
+	apiToken := flag.String("api-token", os.Getenv("LOGGLY_API_TOKEN"), "Loggly API token, defaults to $LOGGLY_API_TOKEN")
+	query := flag.String("query", "*", "Loggly search query")
+	from := flag.String("from", "-10m", "start of the Loggly search")
+	follow := flag.Duration("follow", 0, "poll the Loggly search for new events this often, 0 to stop at the end")
+	level := flag.String("level", "trace", "least severe level shown")
+	flag.Parse()
+
+	var source source
+
+	switch {
+	case *stream != "":
+		source = &streamSource{url: *stream, token: *token}
+	case *account != "" && *apiToken != "":
+		source = &searchSource{account: *account, token: *apiToken, query: *query, from: *from, follow: *follow}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	minimum, err := loggly.ParseLevel(*level)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	v := newView(os.Stdout, minimum)
+	lines := make(chan line, 100)
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- source.run(ctx, lines)
+	}()
+
+	commands := make(chan string)
+
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+
+		for scanner.Scan() {
+			commands <- scanner.Text()
+		}
+	}()
+
+	fmt.Fprintln(os.Stderr, "Type h and enter for commands.")
+
+	for {
+		select {
+		case l := <-lines:
+			v.add(l)
+		case command := <-commands:
+			if !v.command(command) {
+				return
+			}
+		case err := <-errs:
+			if err != nil && ctx.Err() == nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			// A search without follow has shown everything, leave the
+			// commands to search it.
+			errs = nil
+		case <-ctx.Done():
+			return
+		}
+	}
+}
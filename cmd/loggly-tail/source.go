@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	loggly "github.com/morlockaerospace/loggly"
+)
+
+// line is an event as shown by the tail.
+type line struct {
+	time  time.Time
+	level loggly.Level
+	tags  []string
+	text  string
+}
+
+// source produces events until ctx is done or it runs out.
+type source interface {
+	run(ctx context.Context, lines chan<- line) error
+}
+
+// retryDelay is how long a dropped stream waits before reconnecting.
+const retryDelay = 2 * time.Second
+
+// streamSource follows the Server-Sent Events of a TailHandler, reconnecting
+// when the stream drops.
+type streamSource struct {
+	url   string
+	token string
+}
+
+func (s *streamSource) run(ctx context.Context, lines chan<- line) error {
+	for {
+		err := s.follow(ctx, lines)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "stream dropped: %s, reconnecting\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+func (s *streamSource) follow(ctx context.Context, lines chan<- line) error {
+	req, err := http.NewRequest(http.MethodGet, s.url, nil)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		data := scanner.Text()
+
+		if !strings.HasPrefix(data, "data: ") {
+			continue
+		}
+
+		var fields map[string]interface{}
+
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(data, "data: ")), &fields); err != nil {
+			continue
+		}
+
+		lines <- newLine(fields, nil, time.Time{})
+	}
+
+	return scanner.Err()
+}
+
+// searchSource pages through a Loggly search with the events iterate API,
+// polling for new events when following.
+type searchSource struct {
+	account string
+	token   string
+	query   string
+	from    string
+	follow  time.Duration
+	client  http.Client
+}
+
+type searchPage struct {
+	Events []struct {
+		Timestamp int64    `json:"timestamp"`
+		Tags      []string `json:"tags"`
+		Logmsg    string   `json:"logmsg"`
+		Event     struct {
+			JSON map[string]interface{} `json:"json"`
+		} `json:"event"`
+	} `json:"events"`
+	Next string `json:"next"`
+}
+
+func (s *searchSource) run(ctx context.Context, lines chan<- line) error {
+	from := s.from
+
+	for {
+		last, err := s.search(ctx, from, lines)
+
+		if err != nil || s.follow == 0 {
+			return err
+		}
+
+		// Carry on just after the newest event seen.
+		if !last.IsZero() {
+			from = last.Add(time.Millisecond).UTC().Format("2006-01-02T15:04:05.000Z")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.follow):
+		}
+	}
+}
+
+// search shows every event from from onwards and returns the newest one's
+// time.
+func (s *searchSource) search(ctx context.Context, from string, lines chan<- line) (time.Time, error) {
+	query := url.Values{"q": {s.query}, "from": {from}, "until": {"now"}, "size": {"1000"}}
+	next := fmt.Sprintf("https://%s.loggly.com/apiv2/events/iterate?%s", url.PathEscape(s.account), query.Encode())
+
+	var last time.Time
+
+	for next != "" {
+		page, err := s.page(ctx, next)
+
+		if err != nil {
+			return last, err
+		}
+
+		for _, e := range page.Events {
+			t := time.Unix(0, e.Timestamp*int64(time.Millisecond))
+
+			fields := e.Event.JSON
+			if fields == nil {
+				fields = map[string]interface{}{"message": e.Logmsg}
+			}
+
+			lines <- newLine(fields, e.Tags, t)
+
+			if t.After(last) {
+				last = t
+			}
+		}
+
+		next = page.Next
+	}
+
+	return last, nil
+}
+
+func (s *searchSource) page(ctx context.Context, target string) (*searchPage, error) {
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "bearer "+s.token)
+
+	resp, err := s.client.Do(req.WithContext(ctx))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	var page searchPage
+
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// newLine builds a line from an event's shipped JSON fields.
+func newLine(fields map[string]interface{}, tags []string, t time.Time) line {
+	l := line{time: t, level: loggly.LogLevelInfo, tags: tags}
+
+	if timestamp, ok := fields["timestamp"].(string); ok && t.IsZero() {
+		l.time, _ = time.Parse(time.RFC3339, timestamp)
+	}
+
+	if name, ok := fields["level"].(string); ok {
+		if level, err := loggly.ParseLevel(name); err == nil {
+			l.level = level
+		}
+	}
+
+	message, _ := fields["message"].(string)
+
+	var rest []string
+
+	for key, value := range fields {
+		switch key {
+		case "timestamp", "level", "severity", "message":
+			continue
+		}
+
+		b, _ := json.Marshal(value)
+		rest = append(rest, key+"="+string(b))
+	}
+
+	sort.Strings(rest)
+
+	l.text = strings.TrimSpace(message + " " + strings.Join(rest, " "))
+
+	return l
+}
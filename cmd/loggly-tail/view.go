@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	loggly "github.com/morlockaerospace/loggly"
+)
+
+// historySize bounds the lines kept for searching and for showing once
+// resumed.
+const historySize = 10000
+
+const help = `commands:
+  p            pause or resume the output
+  l LEVEL      show LEVEL and above, e.g. l warn
+  t TAG        show only events tagged TAG, t alone clears it
+  f REGEX      show only lines matching REGEX, f alone clears it
+  /REGEX       search the history for REGEX
+  h            show this help
+  q            quit`
+
+// Terminal colours per level.
+const (
+	colourReset  = "\x1b[0m"
+	colourGrey   = "\x1b[90m"
+	colourYellow = "\x1b[33m"
+	colourRed    = "\x1b[31m"
+	colourBold   = "\x1b[1;31m"
+)
+
+// view filters lines to the terminal and handles commands.
+type view struct {
+	w       io.Writer
+	level   loggly.Level
+	tag     string
+	filter  *regexp.Regexp
+	paused  bool
+	history []line
+
+	// pending counts the lines that arrived while paused.
+	pending int
+}
+
+func newView(w io.Writer, level loggly.Level) *view {
+	return &view{w: w, level: level}
+}
+
+func (v *view) add(l line) {
+	if len(v.history) == historySize {
+		v.history = v.history[1:]
+	}
+
+	v.history = append(v.history, l)
+
+	if v.paused {
+		if v.pending < historySize {
+			v.pending++
+		}
+
+		return
+	}
+
+	if v.matches(l) {
+		v.print(l)
+	}
+}
+
+// command runs a command and reports false when it is time to quit.
+func (v *view) command(command string) bool {
+	command = strings.TrimSpace(command)
+	name, arg := command, ""
+
+	if i := strings.IndexByte(command, ' '); i >= 0 {
+		name, arg = command[:i], strings.TrimSpace(command[i+1:])
+	}
+
+	switch {
+	case name == "q":
+		return false
+	case name == "p":
+		v.paused = !v.paused
+
+		if v.paused {
+			fmt.Fprintln(v.w, "-- paused, p resumes --")
+		} else {
+			v.resume()
+		}
+	case name == "l":
+		level, err := loggly.ParseLevel(arg)
+
+		if err != nil {
+			fmt.Fprintln(v.w, err)
+			break
+		}
+
+		v.level = level
+	case name == "t":
+		v.tag = arg
+	case name == "f":
+		if arg == "" {
+			v.filter = nil
+			break
+		}
+
+		filter, err := regexp.Compile(arg)
+
+		if err != nil {
+			fmt.Fprintln(v.w, err)
+			break
+		}
+
+		v.filter = filter
+	case strings.HasPrefix(command, "/"):
+		v.search(command[1:])
+	case name == "h", name == "":
+		fmt.Fprintln(v.w, help)
+	default:
+		fmt.Fprintf(v.w, "unknown command %q\n", command)
+	}
+
+	return true
+}
+
+// resume shows the lines that arrived while paused.
+func (v *view) resume() {
+	start := len(v.history) - v.pending
+	v.pending = 0
+
+	for _, l := range v.history[start:] {
+		if v.matches(l) {
+			v.print(l)
+		}
+	}
+}
+
+func (v *view) search(pattern string) {
+	re, err := regexp.Compile(pattern)
+
+	if err != nil {
+		fmt.Fprintln(v.w, err)
+		return
+	}
+
+	found := 0
+
+	for _, l := range v.history {
+		if re.MatchString(l.text) {
+			v.print(l)
+			found++
+		}
+	}
+
+	fmt.Fprintf(v.w, "-- %d of %d lines match %s --\n", found, len(v.history), pattern)
+}
+
+func (v *view) matches(l line) bool {
+	if !l.level.AtLeast(v.level) {
+		return false
+	}
+
+	if v.filter != nil && !v.filter.MatchString(l.text) {
+		return false
+	}
+
+	if v.tag == "" {
+		return true
+	}
+
+	for _, tag := range l.tags {
+		if tag == v.tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (v *view) print(l line) {
+	colour := ""
+
+	switch {
+	case l.level.AtLeast(loggly.LogLevelFatal):
+		colour = colourBold
+	case l.level.AtLeast(loggly.LogLevelError):
+		colour = colourRed
+	case l.level.AtLeast(loggly.LogLevelWarn):
+		colour = colourYellow
+	case !l.level.AtLeast(loggly.LogLevelInfo):
+		colour = colourGrey
+	}
+
+	text := fmt.Sprintf("%s [%s] %s", l.time.Local().Format(time.RFC3339), l.level, l.text)
+
+	if colour != "" {
+		text = colour + text + colourReset
+	}
+
+	fmt.Fprintln(v.w, text)
+}
@@ -0,0 +1,67 @@
+// Command loggly-uploader watches a spool directory written by applications in
+// spool only mode and uploads its messages to Loggly.
+//
+//	loggly-uploader -dir /var/spool/loggly -token TOKEN -tags app,prod -rate 65536
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	loggly "github.com/morlockaerospace/loggly"
+)
+
+func main() {
+	dir := flag.String("dir", "", "spool directory to upload from")
+	token := flag.String("token", os.Getenv("LOGGLY_TOKEN"), "Loggly customer token, defaults to $LOGGLY_TOKEN")
+	tags := flag.String("tags", "", "comma separated Loggly tags")
+	url := flag.String("url", "", "endpoint overriding the Loggly bulk endpoint")
+	rate := flag.Int64("rate", 0, "upload bandwidth limit in bytes per second, unlimited if 0")
+	poll := flag.Duration("poll", time.Second, "how often to check the spool for new messages")
+	flag.Parse()
+
+	if *dir == "" || (*token == "" && *url == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var tagList []string
+	if *tags != "" {
+		tagList = strings.Split(*tags, ",")
+	}
+
+	uploader, err := loggly.NewUploader(loggly.UploaderConfig{
+		Dir:            *dir,
+		Token:          *token,
+		Tags:           tagList,
+		URL:            *url,
+		PollInterval:   *poll,
+		BytesPerSecond: *rate,
+	})
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	if err := uploader.Run(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+)
+
+// Codec compresses request bodies for a sink, or batches written to the relay
+// set with SetRelay. Implementations for zstd, snappy or others can be plugged
+// in without this package depending on them.
+type Codec interface {
+	// Encoding is the Content-Encoding value announcing the codec.
+	Encoding() string
+
+	// Compress returns the compressed form of p.
+	Compress(p []byte) ([]byte, error)
+}
+
+// GzipCodec compresses with gzip at the default level.
+var GzipCodec Codec = gzipCodec{level: gzip.DefaultCompression}
+
+// NewGzipCodec creates a gzip codec with the given compression level, trading
+// CPU for ratio.
+func NewGzipCodec(level int) (Codec, error) {
+	if _, err := gzip.NewWriterLevel(nil, level); err != nil {
+		return nil, err
+	}
+
+	return gzipCodec{level: level}, nil
+}
+
+type gzipCodec struct {
+	level int
+}
+
+func (gzipCodec) Encoding() string {
+	return "gzip"
+}
+
+func (c gzipCodec) Compress(p []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := gzip.NewWriterLevel(&buf, c.level)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(p); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func init() {
+	RegisterCodec("gzip", func(options map[string]interface{}) (Codec, error) {
+		config := struct {
+			Level int `json:"level"`
+		}{Level: gzip.DefaultCompression}
+
+		if err := decodeOptions(options, &config); err != nil {
+			return nil, err
+		}
+
+		return NewGzipCodec(config.Level)
+	})
+}
@@ -0,0 +1,92 @@
+//go:build !loggly_minimal
+// +build !loggly_minimal
+
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// reverseCodec stands in for a third party codec such as zstd.
+type reverseCodec struct{}
+
+func (reverseCodec) Encoding() string { return "x-reverse" }
+
+func (reverseCodec) Compress(p []byte) ([]byte, error) {
+	out := make([]byte, len(p))
+	for i, b := range p {
+		out[len(p)-1-i] = b
+	}
+	return out, nil
+}
+
+func TestGzipCodec(t *testing.T) {
+	compressed, err := GzipCodec.Compress([]byte("This is compressed."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b, _ := ioutil.ReadAll(r); string(b) != "This is compressed." {
+		t.Errorf("unexpected round trip %q", b)
+	}
+
+	if _, err := NewGzipCodec(42); err == nil {
+		t.Error("expected an error for an invalid level")
+	}
+}
+
+func TestHTTPSinkCodec(t *testing.T) {
+	type request struct {
+		encoding string
+		body     []byte
+	}
+
+	requests := make(chan request, 2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		requests <- request{r.Header.Get("Content-Encoding"), b}
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL)
+	sink.Codec = GzipCodec
+
+	if err := sink.Write(Event{Message: "This is gzipped."}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := <-requests
+	r, err := gzip.NewReader(bytes.NewReader(req.body))
+	if err != nil {
+		t.Fatalf("expected a gzip body, got %q: %s", req.body, err)
+	}
+
+	if b, _ := ioutil.ReadAll(r); req.encoding != "gzip" || !strings.Contains(string(b), "This is gzipped.") {
+		t.Errorf("unexpected %s body %q", req.encoding, b)
+	}
+
+	sink.Codec = reverseCodec{}
+
+	if err := sink.Write(Event{Message: "This is reversed."}); err != nil {
+		t.Fatal(err)
+	}
+
+	req = <-requests
+	restored, _ := reverseCodec{}.Compress(req.body)
+
+	if req.encoding != "x-reverse" || !strings.Contains(string(restored), "This is reversed.") {
+		t.Errorf("unexpected %s body %q", req.encoding, req.body)
+	}
+}
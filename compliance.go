@@ -0,0 +1,140 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ComplianceAction is what happens to events missing required fields.
+type ComplianceAction int
+
+const (
+	// ComplianceReject drops non-compliant events.
+	ComplianceReject ComplianceAction = iota
+
+	// ComplianceQuarantine delivers non-compliant events to the quarantine
+	// sink instead of shipping them.
+	ComplianceQuarantine
+)
+
+// ErrNonCompliant is reported on a delivery channel when an event was missing
+// required fields and therefore never shipped.
+var ErrNonCompliant = errors.New("log event is missing required fields")
+
+// missingFieldsField lists the required fields a quarantined event lacked.
+const missingFieldsField = "missing_fields"
+
+// Compliance configures compliance mode for regulated logging policies.
+type Compliance struct {
+	// Fields are stamped on every event, such as the system and its
+	// classification. Entry and MDC fields can't override them.
+	Fields map[string]interface{}
+
+	// Required lists the fields every event must carry, in its metadata or
+	// its fields, e.g. "operator". Nested fields are joined by dots.
+	Required []string
+
+	Action ComplianceAction
+
+	// Quarantine receives non-compliant events when Action is
+	// ComplianceQuarantine.
+	Quarantine Sink
+}
+
+// SetCompliance enables compliance mode, replacing any previous
+// configuration.
+func SetCompliance(compliance Compliance) error {
+	if compliance.Action == ComplianceQuarantine && compliance.Quarantine == nil {
+		return fmt.Errorf("compliance quarantine requires a sink")
+	}
+
+	fields := make(map[string]interface{}, len(compliance.Fields))
+
+	for key, value := range compliance.Fields {
+		fields[key] = value
+	}
+
+	compliance.Fields = fields
+	compliance.Required = append([]string(nil), compliance.Required...)
+
+	loggerSingleton.Lock()
+	loggerSingleton.compliance = &compliance
+	loggerSingleton.Unlock()
+
+	return nil
+}
+
+// withComplianceFields stamps the immutable compliance fields over fields.
+func (l *logger) withComplianceFields(fields map[string]interface{}) map[string]interface{} {
+	l.Lock()
+	compliance := l.compliance
+	l.Unlock()
+
+	if compliance == nil || len(compliance.Fields) == 0 {
+		return fields
+	}
+
+	stamped := make(map[string]interface{}, len(fields)+len(compliance.Fields))
+
+	for key, value := range fields {
+		stamped[key] = value
+	}
+
+	for key, value := range compliance.Fields {
+		stamped[key] = value
+	}
+
+	return stamped
+}
+
+// nonCompliant reports whether an event is missing required fields,
+// quarantining it if configured to.
+func (l *logger) nonCompliant(output string, level Level, d interface{}, fields map[string]interface{}) bool {
+	l.Lock()
+	compliance := l.compliance
+	l.Unlock()
+
+	if compliance == nil || len(compliance.Required) == 0 {
+		return false
+	}
+
+	matchable := matchableFields(d, fields)
+
+	// Immutable fields take precedence over metadata.
+	for key, value := range compliance.Fields {
+		matchable[key] = value
+	}
+
+	var missing []string
+
+	for _, path := range compliance.Required {
+		if value, ok := getPath(matchable, path); !ok || value == nil || value == "" {
+			missing = append(missing, path)
+		}
+	}
+
+	if len(missing) == 0 {
+		return false
+	}
+
+	l.stats.recordFiltered()
+
+	if compliance.Action == ComplianceQuarantine {
+		quarantined := make(map[string]interface{}, len(fields)+1)
+
+		for key, value := range fields {
+			quarantined[key] = value
+		}
+
+		quarantined[missingFieldsField] = missing
+
+		options := l.encodeOptions()
+		event := Event{Time: l.now(), Level: level, Message: output, Metadata: encodeMetadata(d, options), Fields: encodeFields(quarantined, options)}
+
+		if err := compliance.Quarantine.Write(event); err != nil && l.debugMode {
+			fmt.Printf("There was an error quarantining a non-compliant event: %s", err)
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,70 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComplianceFields(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.compliance = &Compliance{Fields: map[string]interface{}{"classification": "CUI"}, Required: []string{"operator"}}
+
+	e := &Entry{logger: l, fields: map[string]interface{}{"classification": "public"}}
+	e.Infod("mission uploaded", map[string]interface{}{"operator": "jdoe"})
+
+	if body := receive(t, bodies); !strings.Contains(body, `"classification":"CUI"`) {
+		t.Errorf("expected the immutable field to win, got %q", body)
+	}
+}
+
+func TestComplianceReject(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.compliance = &Compliance{Required: []string{"operator", "system.id"}}
+
+	ack := make(chan error, 1)
+	l.log(record{output: "mission uploaded", level: LogLevelInfo, data: map[string]interface{}{"operator": "jdoe"}, ack: ack})
+
+	if err := <-ack; err != ErrNonCompliant {
+		t.Errorf("expected ErrNonCompliant, got %v", err)
+	}
+
+	l.buildAndShipMessage("mission uploaded", LogLevelInfo, false, map[string]interface{}{
+		"operator": "jdoe",
+		"system":   map[string]interface{}{"id": "GS-1"},
+	})
+
+	if body := receive(t, bodies); !strings.Contains(body, `"operator":"jdoe"`) {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	if filtered := l.stats.snapshot().EventsFiltered; filtered != 1 {
+		t.Errorf("expected 1 filtered event, got %d", filtered)
+	}
+}
+
+func TestComplianceQuarantine(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	quarantine := &memorySink{}
+	l.compliance = &Compliance{Required: []string{"operator"}, Action: ComplianceQuarantine, Quarantine: quarantine}
+
+	l.buildAndShipMessage("mission uploaded", LogLevelInfo, false, nil)
+
+	if len(quarantine.events) != 1 {
+		t.Fatalf("expected 1 quarantined event, got %d", len(quarantine.events))
+	}
+
+	if missing, _ := quarantine.events[0].Fields[missingFieldsField].([]interface{}); len(missing) != 1 || missing[0] != "operator" {
+		t.Errorf("unexpected quarantined fields %+v", quarantine.events[0].Fields)
+	}
+
+	select {
+	case body := <-bodies:
+		t.Errorf("expected the event not to ship, got %q", body)
+	default:
+	}
+
+	if err := SetCompliance(Compliance{Action: ComplianceQuarantine}); err == nil {
+		t.Error("expected quarantine without a sink to be refused")
+	}
+}
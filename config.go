@@ -0,0 +1,229 @@
+package log
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// LogConfig ships a "logger.config" event with a redacted dump of the
+// effective logger configuration and its fingerprint, so operators can verify
+// which settings an instance is running with. Call it once configuration is
+// complete.
+func LogConfig() {
+	loggerSingleton.logConfig()
+}
+
+// ConfigFingerprint returns a short hash of the effective logger
+// configuration. Instances with the same settings share a fingerprint.
+func ConfigFingerprint() string {
+	return fingerprintConfig(loggerSingleton.configSummary())
+}
+
+func (l *logger) logConfig() {
+	config := l.configSummary()
+
+	l.buildAndShipMessage("logger.config", LogLevelInfo, false, map[string]interface{}{
+		"event":       "logger.config",
+		"config":      config,
+		"fingerprint": fingerprintConfig(config),
+	})
+}
+
+// configSummary describes the effective logger configuration. Tokens are
+// redacted, including where they appear in the endpoint and routes, as is the
+// proxy password.
+func (l *logger) configSummary() map[string]interface{} {
+	l.Lock()
+	defer l.Unlock()
+
+	filters := make([]FilterRule, len(l.filters))
+	for i, rule := range l.filters {
+		filters[i] = rule.rule
+	}
+
+	routes := make(map[string]string, len(l.routes))
+	for class, r := range l.routes {
+		routes[class] = redactInputURL(r.url)
+	}
+
+	return map[string]interface{}{
+		"token":          redactToken(l.token),
+		"endpoint":       strings.Replace(l.url, l.token, redactToken(l.token), -1),
+		"debug_mode":     l.debugMode,
+		"panic_on_error": l.panicOnError,
+		"capture_std":    l.captureStdout,
+		"adaptive":       l.adaptive != nil,
+		"level":          l.Level.String(),
+		"tags":           l.tags,
+		"bulk":           l.bulk,
+		"buffer_size":    l.bufferSize,
+		"flush_interval": l.flushInterval.String(),
+		"synchronous":    l.synchronous,
+		"blocking":       l.blocking,
+		"sinks":          len(l.sinks),
+		"filters":        filters,
+		"budgets":        len(l.budgets),
+		"shipping":       !l.shippingDisabled,
+		"fields":         l.fields,
+		"retry": map[string]interface{}{
+			"attempts": l.retry.Attempts,
+			"min":      l.retry.Min.String(),
+			"max":      l.retry.Max.String(),
+			"jitter":   l.retry.Jitter,
+		},
+		"request_timeout": l.requestTimeout.String(),
+		"spool":           l.spoolSummary(),
+		"store":           l.store != nil,
+		"relay":           l.relaySummary(),
+		"transport":       l.transportSummary(),
+		"sensitive":       sensitiveSummary(l.sensitive),
+		"subjects":        sensitiveSummary(l.subjects),
+		"classification": map[string]interface{}{
+			"categories": l.classification.Categories,
+			"default":    l.classification.Default,
+		},
+		"routes":        routes,
+		"sample_keys":   l.sampleKeys,
+		"tail_sampling": tailSamplingSummary(l.tail),
+	}
+}
+
+// spoolSummary describes the spool, nil without one. It must be called with
+// the logger locked.
+func (l *logger) spoolSummary() map[string]interface{} {
+	if l.spool == nil {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"dir":  l.spool.dir,
+		"only": l.spoolOnly,
+		"sync": map[string]interface{}{
+			"every_batch": l.spoolSync.EveryBatch,
+			"bytes":       l.spoolSync.Bytes,
+			"interval":    l.spoolSync.Interval.String(),
+		},
+	}
+}
+
+// relaySummary describes the relay, nil without one. It must be called with
+// the logger locked.
+func (l *logger) relaySummary() map[string]interface{} {
+	if l.relay == nil {
+		return nil
+	}
+
+	codec := ""
+	if l.relay.codec != nil {
+		codec = l.relay.codec.Encoding()
+	}
+
+	return map[string]interface{}{
+		"network": l.relay.network,
+		"address": l.relay.address,
+		"codec":   codec,
+	}
+}
+
+// transportSummary describes the transport settings, with any proxy password
+// redacted. It must be called with the logger locked.
+func (l *logger) transportSummary() map[string]interface{} {
+	o := l.transport
+
+	proxy := ""
+	if o.proxy != nil {
+		u := *o.proxy
+		if u.User != nil {
+			u.User = url.User(u.User.Username())
+		}
+		proxy = u.String()
+	}
+
+	localAddr := ""
+	if o.localAddr != nil {
+		localAddr = o.localAddr.String()
+	}
+
+	clientCert := ""
+	if o.clientCert != nil {
+		clientCert = o.clientCert.certFile
+	}
+
+	return map[string]interface{}{
+		"proxy":         proxy,
+		"local_addr":    localAddr,
+		"client_cert":   clientCert,
+		"root_cas":      o.rootCAs != nil,
+		"dns_cache":     o.dns != nil,
+		"pool":          o.pool,
+		"custom":        o.custom != nil,
+		"custom_client": l.customClient != nil,
+	}
+}
+
+func sensitiveSummary(sensitive SensitiveFields) map[string]interface{} {
+	return map[string]interface{}{
+		"fields": sensitive.Fields,
+		"keys":   sensitive.Keys != nil,
+	}
+}
+
+func tailSamplingSummary(tail *TailSampling) map[string]interface{} {
+	if tail == nil {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"rate":       tail.Rate,
+		"latency":    tail.Latency.String(),
+		"max_events": tail.MaxEvents,
+	}
+}
+
+// redactInputURL redacts the token in the path of a Loggly input URL.
+func redactInputURL(input string) string {
+	for _, endpoint := range []string{"/inputs/", "/bulk/"} {
+		i := strings.Index(input, endpoint)
+
+		if i < 0 {
+			continue
+		}
+
+		start := i + len(endpoint)
+		end := strings.Index(input[start:], "/")
+
+		if end < 0 {
+			end = len(input) - start
+		}
+
+		return input[:start] + redactToken(input[start:start+end]) + input[start+end:]
+	}
+
+	return input
+}
+
+// fingerprintConfig hashes the config summary. encoding/json sorts map keys
+// so equal configurations always hash the same.
+func fingerprintConfig(config map[string]interface{}) string {
+	b, err := json.Marshal(config)
+
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// redactToken keeps only the last four characters of a token.
+func redactToken(token string) string {
+	if len(token) <= 4 {
+		return strings.Repeat("*", len(token))
+	}
+
+	return strings.Repeat("*", len(token)-4) + token[len(token)-4:]
+}
@@ -0,0 +1,98 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ConsoleFormatter renders an event as a console line, without a trailing
+// newline.
+type ConsoleFormatter func(e Event) string
+
+// ConsoleWriter is a destination the console mirror writes to.
+type ConsoleWriter struct {
+	Writer io.Writer
+
+	// Level is the least severe level written, events must pass the
+	// logger's level first.
+	Level Level
+
+	// Format renders each line, FormatConsole if nil.
+	Format ConsoleFormatter
+}
+
+// SetConsole replaces the destinations of the console mirror, standard output
+// by default, so it can tee to standard error, a local debug file and an
+// in-app viewer at once, each with its own format and level. Calling it with
+// no writers silences the console.
+func SetConsole(writers ...ConsoleWriter) {
+	loggerSingleton.Lock()
+	loggerSingleton.console = append([]ConsoleWriter{}, writers...)
+	loggerSingleton.Unlock()
+}
+
+// FormatConsole renders an event as "timestamp [LEVEL] message", followed by
+// its metadata and fields.
+func FormatConsole(e Event) string {
+	now := e.Time.Format(time.RFC3339)
+
+	var line string
+
+	if e.Metadata == nil {
+		line = fmt.Sprintf("%v [%s] %s", now, e.Level.String(), e.Message)
+	} else {
+		line = fmt.Sprintf("%v [%s] %s %+v", now, e.Level.String(), e.Message, e.Metadata)
+	}
+
+	if len(e.Fields) > 0 {
+		line += " " + formatFields(e.Fields)
+	}
+
+	return line
+}
+
+// FormatConsoleJSON renders an event as it is shipped to Loggly.
+func FormatConsoleJSON(e Event) string {
+	b, err := json.Marshal(e)
+
+	if err != nil {
+		return FormatConsole(e)
+	}
+
+	return string(b)
+}
+
+// defaultConsole writes every event to standard output.
+var defaultConsole = []ConsoleWriter{{Writer: os.Stdout, Level: LogLevelTrace}}
+
+// printConsole writes e to each console writer whose level it meets.
+func (l *logger) printConsole(e Event) {
+	l.Lock()
+	writers := l.console
+	l.Unlock()
+
+	if writers == nil {
+		writers = defaultConsole
+	}
+
+	l.consoleMu.Lock()
+	defer l.consoleMu.Unlock()
+
+	for _, w := range writers {
+		if !e.Level.AtLeast(w.Level) {
+			continue
+		}
+
+		format := w.Format
+		if format == nil {
+			format = FormatConsole
+		}
+
+		if _, err := io.WriteString(w.Writer, format(e)+"\n"); err != nil && l.debugMode {
+			fmt.Printf("There was an error writing to the console: %s", err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConsoleTee(t *testing.T) {
+	l, _ := newTestLogger(t, false)
+
+	var all, alerts bytes.Buffer
+	l.console = []ConsoleWriter{
+		{Writer: &all, Level: LogLevelTrace},
+		{Writer: &alerts, Level: LogLevelError, Format: FormatConsoleJSON},
+	}
+
+	l.log(record{output: "This is informational.", level: LogLevelInfo, fields: map[string]interface{}{"user": "ada"}})
+	l.log(record{output: "This is an error.", level: LogLevelError})
+
+	if lines := strings.Split(strings.TrimSpace(all.String()), "\n"); len(lines) != 2 || !strings.HasSuffix(lines[0], "[INFO] This is informational. user=ada") {
+		t.Errorf("unexpected console output %q", all.String())
+	}
+
+	if line := alerts.String(); strings.Count(line, "\n") != 1 || !strings.Contains(line, `"message":"This is an error."`) {
+		t.Errorf("unexpected error output %q", line)
+	}
+}
+
+func TestConsoleSilenced(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.console = []ConsoleWriter{}
+
+	l.log(record{output: "This only ships.", level: LogLevelInfo})
+
+	if body := receive(t, bodies); !strings.Contains(body, "This only ships.") {
+		t.Errorf("unexpected body %q", body)
+	}
+}
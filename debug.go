@@ -0,0 +1,107 @@
+//go:build !loggly_nodebug
+// +build !loggly_nodebug
+
+package log
+
+import (
+	"fmt"
+)
+
+// DebugCompiled reports whether Debug and Trace logging is compiled in. It is
+// false in builds with the loggly_nodebug tag, where those calls are no-ops,
+// and can guard the construction of expensive arguments:
+//
+//	if log.DebugCompiled {
+//		log.Debugd("state", dumpState())
+//	}
+const DebugCompiled = true
+
+// Traceln prints the output.
+func Traceln(output string) {
+	Traced(output, nil)
+}
+
+// Traced prints output string and data.
+func Traced(output string, d interface{}) {
+	loggerSingleton.buildAndShipMessage(output, LogLevelTrace, false, d)
+}
+
+// Tracef prints the formatted output.
+func Tracef(format string, a ...interface{}) {
+	Traceln(fmt.Sprintf(format, a...))
+}
+
+// Debugln prints the output.
+func Debugln(output string) {
+	Debugd(output, nil)
+}
+
+// Debugd prints output string and data.
+func Debugd(output string, d interface{}) {
+	loggerSingleton.buildAndShipMessage(output, LogLevelDebug, false, d)
+}
+
+// Debugf prints the formatted output.
+func Debugf(format string, a ...interface{}) {
+	Debugln(fmt.Sprintf(format, a...))
+}
+
+// Traceln prints the output.
+func (e *Entry) Traceln(output string) {
+	e.Traced(output, nil)
+}
+
+// Tracef prints the formatted output.
+func (e *Entry) Tracef(format string, a ...interface{}) {
+	e.Traceln(fmt.Sprintf(format, a...))
+}
+
+// Traced prints output string and data.
+func (e *Entry) Traced(output string, d interface{}) {
+	e.log(output, LogLevelTrace, false, d)
+}
+
+// Debugln prints the output.
+func (e *Entry) Debugln(output string) {
+	e.Debugd(output, nil)
+}
+
+// Debugf prints the formatted output.
+func (e *Entry) Debugf(format string, a ...interface{}) {
+	e.Debugln(fmt.Sprintf(format, a...))
+}
+
+// Debugd prints output string and data.
+func (e *Entry) Debugd(output string, d interface{}) {
+	e.log(output, LogLevelDebug, false, d)
+}
+
+// Traceln prints the output.
+func (lg *Logger) Traceln(output string) {
+	lg.Traced(output, nil)
+}
+
+// Tracef prints the formatted output.
+func (lg *Logger) Tracef(format string, a ...interface{}) {
+	lg.Traceln(fmt.Sprintf(format, a...))
+}
+
+// Traced prints output string and data.
+func (lg *Logger) Traced(output string, d interface{}) {
+	lg.logger().buildAndShipMessage(output, LogLevelTrace, false, d)
+}
+
+// Debugln prints the output.
+func (lg *Logger) Debugln(output string) {
+	lg.Debugd(output, nil)
+}
+
+// Debugf prints the formatted output.
+func (lg *Logger) Debugf(format string, a ...interface{}) {
+	lg.Debugln(fmt.Sprintf(format, a...))
+}
+
+// Debugd prints output string and data.
+func (lg *Logger) Debugd(output string, d interface{}) {
+	lg.logger().buildAndShipMessage(output, LogLevelDebug, false, d)
+}
@@ -0,0 +1,14 @@
+//go:build !js
+// +build !js
+
+package log
+
+import (
+	"net/http"
+)
+
+// configureDial dials through the transport options, applying the local
+// address and DNS cache.
+func (o transportOptions) configureDial(transport *http.Transport) {
+	transport.DialContext = o.dialContext
+}
@@ -0,0 +1,13 @@
+//go:build js
+// +build js
+
+package log
+
+import (
+	"net/http"
+)
+
+// configureDial leaves dialing to the browser. net/http only ships through
+// the Fetch API when no dialer is set, so the local address, DNS cache and
+// socks5 proxy settings have no effect under js/wasm.
+func (o transportOptions) configureDial(transport *http.Transport) {}
@@ -0,0 +1,182 @@
+package log
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Diff is the structural difference between two values, keyed by the dotted
+// paths of their JSON encodings, e.g. "limits.rate" or "hosts.2".
+type Diff struct {
+	Added   map[string]interface{} `json:"added,omitempty"`
+	Removed map[string]interface{} `json:"removed,omitempty"`
+	Changed map[string]Change      `json:"changed,omitempty"`
+}
+
+// Change is a value that differs between the old and new value.
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// rootPath names the whole value when it isn't an object or array.
+const rootPath = "."
+
+// Empty reports whether the values were equal.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Paths returns every differing path, sorted.
+func (d Diff) Paths() []string {
+	paths := make([]string, 0, len(d.Added)+len(d.Removed)+len(d.Changed))
+
+	for path := range d.Added {
+		paths = append(paths, path)
+	}
+
+	for path := range d.Removed {
+		paths = append(paths, path)
+	}
+
+	for path := range d.Changed {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// Diffd logs output at Info level with the structural difference between
+// old and new as its data, rather than both values, keeping change audit
+// events small and queryable. Nothing is logged when they are equal.
+func Diffd(output string, old, new interface{}) {
+	loggerSingleton.diffd(output, old, new)
+}
+
+// Diffd logs output with the difference between old and new, see the
+// package level Diffd.
+func (lg *Logger) Diffd(output string, old, new interface{}) {
+	lg.logger().diffd(output, old, new)
+}
+
+// Diffd logs output with the entry's fields and the difference between old
+// and new, see the package level Diffd.
+func (e *Entry) Diffd(output string, old, new interface{}) {
+	if d, ok := diffData(old, new); ok {
+		e.log(output, LogLevelInfo, false, d)
+	}
+}
+
+func (l *logger) diffd(output string, old, new interface{}) {
+	if d, ok := diffData(old, new); ok {
+		l.buildAndShipMessage(output, LogLevelInfo, false, d)
+	}
+}
+
+// diffData returns the data a Diffd event carries, and false if there is
+// nothing to log.
+func diffData(old, new interface{}) (interface{}, bool) {
+	diff, err := Compare(old, new)
+
+	if err != nil {
+		return map[string]interface{}{"diff_error": err.Error()}, true
+	}
+
+	return diff, !diff.Empty()
+}
+
+// Compare returns the structural difference between old and new, compared
+// by their JSON encodings.
+func Compare(old, new interface{}) (Diff, error) {
+	from, err := jsonValue(old)
+
+	if err != nil {
+		return Diff{}, err
+	}
+
+	to, err := jsonValue(new)
+
+	if err != nil {
+		return Diff{}, err
+	}
+
+	diff := Diff{Added: map[string]interface{}{}, Removed: map[string]interface{}{}, Changed: map[string]Change{}}
+	diff.compare("", from, to)
+
+	return diff, nil
+}
+
+func (d *Diff) compare(path string, from, to interface{}) {
+	switch f := from.(type) {
+	case map[string]interface{}:
+		if t, ok := to.(map[string]interface{}); ok {
+			for key, value := range f {
+				if next, ok := t[key]; ok {
+					d.compare(joinPath(path, key), value, next)
+				} else {
+					d.Removed[joinPath(path, key)] = value
+				}
+			}
+
+			for key, value := range t {
+				if _, ok := f[key]; !ok {
+					d.Added[joinPath(path, key)] = value
+				}
+			}
+
+			return
+		}
+	case []interface{}:
+		if t, ok := to.([]interface{}); ok {
+			for i := 0; i < len(f) || i < len(t); i++ {
+				key := joinPath(path, strconv.Itoa(i))
+
+				switch {
+				case i >= len(t):
+					d.Removed[key] = f[i]
+				case i >= len(f):
+					d.Added[key] = t[i]
+				default:
+					d.compare(key, f[i], t[i])
+				}
+			}
+
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		if path == "" {
+			path = rootPath
+		}
+
+		d.Changed[path] = Change{From: from, To: to}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// jsonValue converts v to its JSON form of maps, slices and scalars.
+func jsonValue(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+
+	err = json.Unmarshal(b, &value)
+
+	return value, err
+}
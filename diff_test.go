@@ -0,0 +1,61 @@
+package log
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	type limits struct {
+		Rate  int `json:"rate"`
+		Burst int `json:"burst,omitempty"`
+	}
+
+	type config struct {
+		Name   string   `json:"name"`
+		Hosts  []string `json:"hosts"`
+		Limits limits   `json:"limits"`
+		Debug  bool     `json:"debug,omitempty"`
+	}
+
+	old := config{Name: "api", Hosts: []string{"a", "b", "c"}, Limits: limits{Rate: 10, Burst: 5}}
+	new := config{Name: "api", Hosts: []string{"a", "x"}, Limits: limits{Rate: 20}, Debug: true}
+
+	diff, err := Compare(old, new)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Diff{
+		Added:   map[string]interface{}{"debug": true},
+		Removed: map[string]interface{}{"hosts.2": "c", "limits.burst": float64(5)},
+		Changed: map[string]Change{"hosts.1": {From: "b", To: "x"}, "limits.rate": {From: float64(10), To: float64(20)}},
+	}
+
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("expected %+v, got %+v", expected, diff)
+	}
+
+	if paths := strings.Join(diff.Paths(), " "); paths != "debug hosts.1 hosts.2 limits.burst limits.rate" {
+		t.Errorf("unexpected paths %q", paths)
+	}
+
+	if diff, _ := Compare("a", "b"); !reflect.DeepEqual(diff.Changed, map[string]Change{rootPath: {From: "a", To: "b"}}) {
+		t.Errorf("unexpected scalar diff %+v", diff)
+	}
+}
+
+func TestDiffd(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	l.diffd("config unchanged", map[string]int{"rate": 1}, map[string]int{"rate": 1})
+	l.diffd("config changed", map[string]int{"rate": 1}, map[string]int{"rate": 2})
+
+	body := receive(t, bodies)
+
+	if !strings.Contains(body, `"message":"config changed"`) || !strings.Contains(body, `"changed":{"rate":{"from":1,"to":2}}`) {
+		t.Errorf("unexpected body %q", body)
+	}
+}
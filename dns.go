@@ -0,0 +1,136 @@
+package log
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// SetDNSCache caches the endpoint's resolved addresses for ttl so shipping
+// doesn't wait on a slow resolver for every connection. Expired entries keep
+// being used while they refresh in the background, and if the refresh fails,
+// so an address change is picked up within about a ttl without ever stalling.
+// A ttl of 0 disables the cache.
+func SetDNSCache(ttl time.Duration) {
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	if ttl <= 0 {
+		loggerSingleton.transport.dns = nil
+	} else {
+		loggerSingleton.transport.dns = newDNSCache(ttl)
+	}
+
+	loggerSingleton.rebuildClient()
+}
+
+type dnsEntry struct {
+	addrs      []string
+	expires    time.Time
+	refreshing bool
+}
+
+type dnsCache struct {
+	sync.Mutex
+	ttl     time.Duration
+	entries map[string]*dnsEntry
+	lookup  func(ctx context.Context, host string) ([]string, error)
+}
+
+func newDNSCache(ttl time.Duration) *dnsCache {
+	return &dnsCache{
+		ttl:     ttl,
+		entries: map[string]*dnsEntry{},
+		lookup:  net.DefaultResolver.LookupHost,
+	}
+}
+
+// resolve returns the addresses for host, from the cache when possible.
+func (c *dnsCache) resolve(ctx context.Context, host string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+
+	c.Lock()
+	entry, ok := c.entries[host]
+
+	if ok {
+		if time.Now().After(entry.expires) && !entry.refreshing {
+			entry.refreshing = true
+			go c.refresh(host)
+		}
+
+		addrs := entry.addrs
+		c.Unlock()
+
+		return addrs, nil
+	}
+
+	c.Unlock()
+
+	addrs, err := c.lookup(ctx, host)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(host, addrs)
+
+	return addrs, nil
+}
+
+func (c *dnsCache) refresh(host string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	addrs, err := c.lookup(ctx, host)
+
+	if err != nil {
+		// Keep serving the stale addresses and try again on the next dial.
+		c.Lock()
+		if entry, ok := c.entries[host]; ok {
+			entry.refreshing = false
+		}
+		c.Unlock()
+
+		return
+	}
+
+	c.store(host, addrs)
+}
+
+func (c *dnsCache) store(host string, addrs []string) {
+	c.Lock()
+	c.entries[host] = &dnsEntry{addrs: addrs, expires: time.Now().Add(c.ttl)}
+	c.Unlock()
+}
+
+// dial connects to the first reachable cached address for addr.
+func (c *dnsCache) dial(ctx context.Context, dialer *net.Dialer, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := c.resolve(ctx, host)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+
+	for _, ip := range addrs {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+
+		if err == nil {
+			return conn, nil
+		}
+
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
@@ -0,0 +1,99 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDNSCache(t *testing.T) {
+	var mu sync.Mutex
+	lookups := 0
+	answer := []string{"10.0.0.1"}
+
+	c := newDNSCache(time.Minute)
+	c.lookup = func(ctx context.Context, host string) ([]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		lookups++
+		return answer, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if addrs, err := c.resolve(context.Background(), "logs-01.loggly.com"); err != nil || addrs[0] != "10.0.0.1" {
+			t.Fatalf("unexpected addresses %v: %v", addrs, err)
+		}
+	}
+
+	if lookups != 1 {
+		t.Errorf("expected a single lookup, got %d", lookups)
+	}
+
+	// Expire the entry, the stale address is served while it refreshes.
+	mu.Lock()
+	answer = []string{"10.0.0.2"}
+	mu.Unlock()
+
+	c.Lock()
+	c.entries["logs-01.loggly.com"].expires = time.Now().Add(-time.Second)
+	c.Unlock()
+
+	if addrs, _ := c.resolve(context.Background(), "logs-01.loggly.com"); addrs[0] != "10.0.0.1" {
+		t.Errorf("expected the stale address, got %v", addrs)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		addrs, _ := c.resolve(context.Background(), "logs-01.loggly.com")
+		if addrs[0] == "10.0.0.2" {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("expected the refreshed address to be picked up")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDNSCacheKeepsStaleOnFailure(t *testing.T) {
+	c := newDNSCache(time.Minute)
+	c.lookup = func(ctx context.Context, host string) ([]string, error) {
+		return nil, errors.New("resolver unavailable")
+	}
+
+	if _, err := c.resolve(context.Background(), "logs-01.loggly.com"); err == nil {
+		t.Error("expected an error without a cached entry")
+	}
+
+	c.entries["logs-01.loggly.com"] = &dnsEntry{addrs: []string{"10.0.0.1"}, expires: time.Now().Add(-time.Second)}
+
+	c.refresh("logs-01.loggly.com")
+
+	if addrs, err := c.resolve(context.Background(), "logs-01.loggly.com"); err != nil || addrs[0] != "10.0.0.1" {
+		t.Errorf("expected the stale address to survive a failed refresh, got %v: %v", addrs, err)
+	}
+}
+
+func TestDNSCacheDial(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	_, port, _ := net.SplitHostPort(l.url[len("http://"):])
+	l.url = "http://relay.internal:" + port
+
+	c := newDNSCache(time.Minute)
+	c.lookup = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"127.0.0.1"}, nil
+	}
+
+	l.transport.dns = c
+	l.rebuildClient()
+
+	l.buildAndShipMessage("This is resolved from the cache.", LogLevelInfo, false, nil)
+	receive(t, bodies)
+}
@@ -0,0 +1,194 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+)
+
+// defaultEarlyBuffer bounds the events held for replay, see SetEarlyBuffer.
+const defaultEarlyBuffer = 1000
+
+// ErrBufferFull is reported on a delivery channel when an event held before
+// the logger started didn't fit in the early buffer and was never shipped.
+var ErrBufferFull = errors.New("log buffer is full")
+
+// earlyBuffer holds the events logged before the logger starts shipping.
+type earlyBuffer struct {
+	records []record
+	limit   int
+	warned  bool
+
+	// held is set by Hold, keeping events held past SetupLogger until Start.
+	held bool
+
+	// untilSetup is set for the default logger, which holds until
+	// SetupLogger whatever Start says.
+	untilSetup bool
+}
+
+// Hold starts the early logging phase, for boot code running before the
+// network or configuration is ready. Events are printed to the console and
+// held, then shipped in order with their original timestamps once Start is
+// called. Events held beyond the early buffer are dropped, see
+// SetEarlyBuffer. Hold may be called before SetupLogger, in which case events
+// stay held through it until Start.
+func Hold() {
+	loggerSingleton.hold()
+}
+
+// Start ends the early logging phase begun by Hold, shipping the held
+// events.
+func Start() {
+	loggerSingleton.release()
+}
+
+// SetEarlyBuffer sets how many events are held during the early logging
+// phase, 1000 by default. Events logged before SetupLogger are held the same
+// way.
+func SetEarlyBuffer(size int) {
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	loggerSingleton.earlyLimit = size
+
+	if loggerSingleton.early != nil {
+		loggerSingleton.early.limit = size
+	}
+}
+
+// newDefaultLogger creates the logger used until SetupLogger is called. It
+// holds what it logs for the configured logger to ship.
+func newDefaultLogger() *logger {
+	l := newLogger("", LogLevelDebug, nil, false, false)
+	l.shippingDisabled = true
+	l.early = &earlyBuffer{limit: defaultEarlyBuffer, untilSetup: true}
+
+	return l
+}
+
+func (l *logger) hold() {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.early == nil {
+		limit := l.earlyLimit
+		if limit == 0 {
+			limit = defaultEarlyBuffer
+		}
+
+		l.early = &earlyBuffer{limit: limit}
+	}
+
+	// Holding was asked for, there's nothing to warn about.
+	l.early.held = true
+	l.early.warned = true
+}
+
+// release stops holding events and ships those held.
+func (l *logger) release() {
+	l.Lock()
+	early := l.early
+
+	if early == nil || early.untilSetup {
+		if early != nil {
+			early.held = false
+		}
+
+		l.Unlock()
+		return
+	}
+
+	l.early = nil
+	l.Unlock()
+
+	l.replayEarly(early.records)
+}
+
+// holdEarly holds r, printing it to the console, while the logger hasn't
+// started shipping. It reports whether r was held.
+func (l *logger) holdEarly(r record) bool {
+	// Fatal events can't wait.
+	if r.exit {
+		return false
+	}
+
+	l.Lock()
+	early := l.early
+
+	if early == nil {
+		l.Unlock()
+		return false
+	}
+
+	warn := !early.warned
+	early.warned = true
+
+	if r.time.IsZero() {
+		r.time = l.clock.Now()
+	}
+
+	full := len(early.records) >= early.limit
+
+	if !full {
+		held := r
+		held.printed = true
+		early.records = append(early.records, held)
+	}
+	l.Unlock()
+
+	if warn {
+		fmt.Println("loggly: logging before SetupLogger, events are printed and held until it is called")
+	}
+
+	if !r.printed {
+		d, _ := unwrapNoPanic(r.data)
+		l.printConsole(Event{Time: r.time, Level: r.level, Message: r.output, Metadata: d, Fields: r.fields})
+	}
+
+	if full && r.ack != nil {
+		r.ack <- ErrBufferFull
+		close(r.ack)
+	}
+
+	return true
+}
+
+// takeEarly returns the default logger's early buffer, after which nothing
+// more is held.
+func (l *logger) takeEarly() *earlyBuffer {
+	l.Lock()
+	defer l.Unlock()
+
+	early := l.early
+	l.early = nil
+
+	return early
+}
+
+// adoptEarly takes over the events held by the default logger, holding on to
+// them until Start if Hold was called and shipping them otherwise.
+func (l *logger) adoptEarly(early *earlyBuffer) {
+	if early == nil {
+		return
+	}
+
+	if !early.held {
+		l.replayEarly(early.records)
+		return
+	}
+
+	early.untilSetup = false
+
+	l.Lock()
+	l.early = early
+	l.earlyLimit = early.limit
+	l.Unlock()
+}
+
+// replayEarly logs events held before the logger started, keeping their
+// timestamps.
+func (l *logger) replayEarly(records []record) {
+	for _, r := range records {
+		l.log(r)
+	}
+}
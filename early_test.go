@@ -0,0 +1,114 @@
+package log
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEarlyEventsReplay(t *testing.T) {
+	early := newDefaultLogger()
+	early.clock = NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	ack := make(chan error, 1)
+	early.log(record{output: "This is logged during boot.", level: LogLevelInfo, ack: ack})
+
+	select {
+	case err := <-ack:
+		t.Fatalf("expected the ack to wait for setup, got %v", err)
+	default:
+	}
+
+	l, bodies := newTestLogger(t, false)
+	l.adoptEarly(early.takeEarly())
+
+	body := receive(t, bodies)
+
+	if !strings.Contains(body, "This is logged during boot.") || !strings.Contains(body, "2020-01-01T00:00:00Z") {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	if err := <-ack; err != nil {
+		t.Errorf("expected successful delivery, got %s", err)
+	}
+
+	early.log(record{output: "This is logged after setup.", level: LogLevelInfo})
+
+	if early.early != nil {
+		t.Error("expected nothing held after setup")
+	}
+}
+
+func TestEarlyBufferFull(t *testing.T) {
+	early := newDefaultLogger()
+	early.early.records = make([]record, defaultEarlyBuffer)
+
+	ack := make(chan error, 1)
+	early.log(record{output: "This doesn't fit.", level: LogLevelInfo, ack: ack})
+
+	if err := <-ack; err != ErrBufferFull {
+		t.Errorf("expected ErrBufferFull, got %v", err)
+	}
+}
+
+func TestHoldUntilStart(t *testing.T) {
+	early := newDefaultLogger()
+	early.hold()
+	early.log(record{output: "This is logged before setup.", level: LogLevelInfo})
+
+	l, bodies := newTestLogger(t, false)
+	l.adoptEarly(early.takeEarly())
+	l.log(record{output: "This is logged before the network is up.", level: LogLevelInfo})
+
+	select {
+	case body := <-bodies:
+		t.Fatalf("expected events to be held until Start, got %q", body)
+	default:
+	}
+
+	l.release()
+
+	if body := receive(t, bodies); !strings.Contains(body, "This is logged before setup.") {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	if body := receive(t, bodies); !strings.Contains(body, "This is logged before the network is up.") {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	l.log(record{output: "This ships straight away.", level: LogLevelInfo})
+
+	if body := receive(t, bodies); !strings.Contains(body, "This ships straight away.") {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestStartWaitsForSetup(t *testing.T) {
+	early := newDefaultLogger()
+	early.hold()
+	early.release()
+	early.log(record{output: "This is still held.", level: LogLevelInfo})
+
+	l, bodies := newTestLogger(t, false)
+	l.adoptEarly(early.takeEarly())
+
+	if body := receive(t, bodies); !strings.Contains(body, "This is still held.") {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestHoldAfterSetup(t *testing.T) {
+	previous := loggerSingleton
+	loggerSingleton = newDefaultLogger()
+	t.Cleanup(func() { loggerSingleton = previous })
+
+	Setup("tok-one", WithTags("a"))
+	configured := loggerSingleton
+
+	Hold()
+	Setup("tok-two", WithTags("b"))
+
+	if loggerSingleton != configured || !strings.Contains(configured.url, "tok-one") {
+		t.Errorf("expected a second setup to keep the configured logger, got %q", loggerSingleton.url)
+	}
+}
@@ -0,0 +1,470 @@
+package log
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxEncodeDepth stops the metadata encoder on cyclic or absurdly deep values.
+const maxEncodeDepth = 32
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[reflect.Type]reflect.Value{}
+
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	durationType      = reflect.TypeOf(time.Duration(0))
+	timeType          = reflect.TypeOf(time.Time{})
+)
+
+// RegisterEncoder registers a function serializing a domain type logged as
+// metadata, e.g. func(MyTelemetryFrame) map[string]interface{}. The function
+// must take a single argument of the type it encodes and return a single
+// value, which is encoded in turn. Encoders apply wherever the type appears in
+// the metadata, including inside maps, slices and structs.
+func RegisterEncoder(fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+
+	if t.Kind() != reflect.Func || t.NumIn() != 1 || t.NumOut() != 1 || t.IsVariadic() {
+		return fmt.Errorf("encoder must be a func with one argument and one result, got %s", t)
+	}
+
+	encodersMu.Lock()
+	encoders[t.In(0)] = v
+	encodersMu.Unlock()
+
+	return nil
+}
+
+func encoderFor(t reflect.Type) (reflect.Value, bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+
+	fn, ok := encoders[t]
+
+	return fn, ok
+}
+
+// BytesEncoding selects how byte slices and arrays in metadata are shipped.
+type BytesEncoding int
+
+const (
+	// BytesBase64 ships bytes as a base64 string, like encoding/json.
+	BytesBase64 BytesEncoding = iota
+
+	// BytesHex ships bytes as a hex string.
+	BytesHex
+
+	// BytesPreview ships the length and a hex preview of the first bytes.
+	BytesPreview
+
+	// BytesLength ships only the length.
+	BytesLength
+)
+
+// DurationFormat selects how time.Duration values in metadata are shipped.
+type DurationFormat int
+
+const (
+	// DurationString ships durations as strings such as "1.5s".
+	DurationString DurationFormat = iota
+
+	// DurationMilliseconds ships durations as a number of milliseconds.
+	DurationMilliseconds
+
+	// DurationSeconds ships durations as a number of seconds.
+	DurationSeconds
+
+	// DurationNanoseconds ships durations as a number of nanoseconds, like
+	// encoding/json.
+	DurationNanoseconds
+)
+
+// bytesPreviewSize is how many bytes a preview shows.
+const bytesPreviewSize = 16
+
+// encodeOptions controls how metadata is encoded for shipping.
+type encodeOptions struct {
+	bytes BytesEncoding
+
+	// bytesLimit is the largest byte slice encoded in full, larger ones are
+	// previewed. Zero means no limit.
+	bytesLimit int
+
+	durations DurationFormat
+
+	// timeLayout formats time.Time values, RFC3339 with nanoseconds when
+	// empty.
+	timeLayout string
+
+	// flattenDepth is how many levels of nested objects are flattened into
+	// dotted keys. Zero disables flattening.
+	flattenDepth int
+}
+
+// SetBytesEncoding sets how byte slices in metadata are shipped. Binary data
+// longer than limit bytes is shipped as a preview regardless of encoding, a
+// limit of zero disables that.
+func SetBytesEncoding(encoding BytesEncoding, limit int) {
+	loggerSingleton.Lock()
+	loggerSingleton.encoding.bytes = encoding
+	loggerSingleton.encoding.bytesLimit = limit
+	loggerSingleton.Unlock()
+}
+
+// SetDurationFormat sets how time.Duration values in metadata are shipped.
+func SetDurationFormat(format DurationFormat) {
+	loggerSingleton.Lock()
+	loggerSingleton.encoding.durations = format
+	loggerSingleton.Unlock()
+}
+
+// SetTimeFormat sets the layout, as for time.Format, of time.Time values in
+// metadata. Times are shipped as RFC3339 with nanoseconds by default.
+func SetTimeFormat(layout string) {
+	loggerSingleton.Lock()
+	loggerSingleton.encoding.timeLayout = layout
+	loggerSingleton.Unlock()
+}
+
+// SetFlatten flattens nested metadata objects into dotted keys, such as
+// user.address.city, up to depth levels deep. Objects nested deeper are
+// shipped as they are. A depth of zero disables flattening.
+func SetFlatten(depth int) {
+	loggerSingleton.Lock()
+	loggerSingleton.encoding.flattenDepth = depth
+	loggerSingleton.Unlock()
+}
+
+func (l *logger) encodeOptions() encodeOptions {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.encoding
+}
+
+// encodeMetadata converts metadata into plain maps, slices and values ready to
+// be marshalled, applying registered encoders along the way. Values that know
+// how to represent themselves are asked to, so arbitrary third party types
+// can be logged without failing the whole message.
+func encodeMetadata(d interface{}, options encodeOptions) interface{} {
+	if d == nil {
+		return nil
+	}
+
+	encoded := options.encodeValue(reflect.ValueOf(d), 0)
+
+	if fields, ok := encoded.(map[string]interface{}); ok && options.flattenDepth > 0 {
+		flat := map[string]interface{}{}
+		flatten(flat, "", fields, options.flattenDepth)
+
+		return flat
+	}
+
+	return encoded
+}
+
+// flatten copies fields into flat, joining the keys of nested objects up to
+// depth levels with dots.
+func flatten(flat map[string]interface{}, prefix string, fields map[string]interface{}, depth int) {
+	for key, value := range fields {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok && depth > 0 && len(nested) > 0 {
+			flatten(flat, key, nested, depth-1)
+			continue
+		}
+
+		flat[key] = value
+	}
+}
+
+func (o encodeOptions) encodeValue(v reflect.Value, depth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if depth > maxEncodeDepth {
+		return fmt.Sprintf("%v", v.Interface())
+	}
+
+	if fn, ok := encoderFor(v.Type()); ok {
+		return o.encodeValue(fn.Call([]reflect.Value{v})[0], depth+1)
+	}
+
+	switch v.Type() {
+	case durationType:
+		return o.encodeDuration(time.Duration(v.Int()))
+	case timeType:
+		return o.encodeTime(v.Interface().(time.Time))
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+
+		// Types encoding themselves through a pointer receiver.
+		if v.Kind() == reflect.Ptr && v.Type().Elem() != timeType {
+			if encoded, ok := encodeSelf(v); ok {
+				return encoded
+			}
+		}
+
+		return o.encodeValue(v.Elem(), depth+1)
+	}
+
+	if encoded, ok := encodeSelf(v); ok {
+		return encoded
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := map[string]interface{}{}
+		o.encodeStruct(v, fields, depth)
+
+		return fields
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+
+		fields := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+
+		for iter.Next() {
+			fields[fmt.Sprint(iter.Key().Interface())] = o.encodeValue(iter.Value(), depth+1)
+		}
+
+		return fields
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return o.encodeBytes(v)
+		}
+
+		values := make([]interface{}, v.Len())
+
+		for i := range values {
+			values[i] = o.encodeValue(v.Index(i), depth+1)
+		}
+
+		return values
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		// Not representable in JSON, ship a description rather than failing
+		// the whole message.
+		return fmt.Sprintf("%v", v.Interface())
+	}
+
+	return v.Interface()
+}
+
+func (o encodeOptions) encodeDuration(d time.Duration) interface{} {
+	switch o.durations {
+	case DurationMilliseconds:
+		return float64(d) / float64(time.Millisecond)
+	case DurationSeconds:
+		return d.Seconds()
+	case DurationNanoseconds:
+		return int64(d)
+	}
+
+	return d.String()
+}
+
+func (o encodeOptions) encodeTime(t time.Time) interface{} {
+	layout := o.timeLayout
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+
+	return t.Format(layout)
+}
+
+// byteType is the element type of byte slices reflect can read directly.
+var byteType = reflect.TypeOf(byte(0))
+
+// encodeBytes encodes a byte slice or array according to the bytes policy.
+func (o encodeOptions) encodeBytes(v reflect.Value) interface{} {
+	var b []byte
+
+	if v.Kind() == reflect.Slice && v.Type().Elem() == byteType {
+		b = v.Bytes()
+	} else {
+		// Arrays, and elements of a named byte type, are copied one by one.
+		b = make([]byte, v.Len())
+
+		for i := range b {
+			b[i] = uint8(v.Index(i).Uint())
+		}
+	}
+
+	encoding := o.bytes
+	if o.bytesLimit > 0 && len(b) > o.bytesLimit && encoding != BytesLength {
+		encoding = BytesPreview
+	}
+
+	switch encoding {
+	case BytesHex:
+		return hex.EncodeToString(b)
+	case BytesPreview:
+		preview := b
+		if len(preview) > bytesPreviewSize {
+			preview = preview[:bytesPreviewSize]
+		}
+
+		return map[string]interface{}{"bytes": len(b), "preview": hex.EncodeToString(preview)}
+	case BytesLength:
+		return map[string]interface{}{"bytes": len(b)}
+	}
+
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// encodeStruct adds the exported fields of v to fields following the
+// encoding/json naming rules.
+func (o encodeOptions) encodeStruct(v reflect.Value, fields map[string]interface{}, depth int) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+
+		if tag == "-" {
+			continue
+		}
+
+		name, options := tag, ""
+		if comma := strings.Index(tag, ","); comma >= 0 {
+			name, options = tag[:comma], tag[comma:]
+		}
+
+		value := v.Field(i)
+
+		// Promote the fields of untagged embedded structs.
+		if field.Anonymous && name == "" {
+			embedded := value
+
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				o.encodeStruct(embedded, fields, depth)
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if strings.Contains(options, ",omitempty") && isEmptyValue(value) {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fields[name] = o.encodeValue(value, depth+1)
+	}
+}
+
+// encodeSelf encodes values implementing json.Marshaler,
+// encoding.TextMarshaler, error or fmt.Stringer, in that order of preference.
+// Methods that fail or panic leave a fallback description instead.
+func encodeSelf(v reflect.Value) (encoded interface{}, ok bool) {
+	t := v.Type()
+
+	if !t.Implements(jsonMarshalerType) && !t.Implements(textMarshalerType) && !t.Implements(errorType) && !t.Implements(stringerType) {
+		return nil, false
+	}
+
+	if !v.CanInterface() {
+		return nil, false
+	}
+
+	value := v.Interface()
+
+	defer func() {
+		if r := recover(); r != nil {
+			encoded, ok = fmt.Sprintf("!PANIC(%T: %v)", value, r), true
+		}
+	}()
+
+	switch m := value.(type) {
+	case json.Marshaler:
+		b, err := m.MarshalJSON()
+
+		if err != nil || !json.Valid(b) {
+			return fallbackValue(value, err), true
+		}
+
+		return json.RawMessage(b), true
+	case encoding.TextMarshaler:
+		b, err := m.MarshalText()
+
+		if err != nil {
+			return fallbackValue(value, err), true
+		}
+
+		return string(b), true
+	case error:
+		return m.Error(), true
+	case fmt.Stringer:
+		return m.String(), true
+	}
+
+	return nil, false
+}
+
+// fallbackValue describes a value whose own marshalling failed.
+func fallbackValue(value interface{}, err error) string {
+	if err == nil {
+		err = errors.New("invalid JSON")
+	}
+
+	return fmt.Sprintf("!ERROR(%T: %s)", value, err)
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	return false
+}
@@ -0,0 +1,231 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type telemetryFrame struct {
+	raw []float64
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	if err := RegisterEncoder(func(f telemetryFrame) map[string]interface{} {
+		return map[string]interface{}{"samples": len(f.raw), "first": f.raw[0]}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := encodeMetadata(map[string]interface{}{
+		"frame":  telemetryFrame{raw: []float64{1.5, 2}},
+		"frames": []telemetryFrame{{raw: []float64{3}}},
+	}, encodeOptions{})
+
+	want := map[string]interface{}{
+		"frame":  map[string]interface{}{"samples": 2, "first": 1.5},
+		"frames": []interface{}{map[string]interface{}{"samples": 1, "first": float64(3)}},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if err := RegisterEncoder(func(a, b int) int { return a + b }); err == nil {
+		t.Error("expected an error for an encoder with two arguments")
+	}
+}
+
+func TestEncodeStructMatchesJSON(t *testing.T) {
+	type base struct {
+		ID int `json:"id"`
+	}
+
+	type sample struct {
+		base
+		Name     string `json:"name"`
+		Optional string `json:"optional,omitempty"`
+		Skipped  string `json:"-"`
+		Untagged bool
+		When     time.Time         `json:"when"`
+		Labels   map[string]string `json:"labels"`
+		hidden   string
+	}
+
+	value := &sample{
+		base:   base{ID: 7},
+		Name:   "logan",
+		When:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Labels: map[string]string{"a": "b"},
+		hidden: "x",
+	}
+
+	encoded, err := json.Marshal(encodeMetadata(value, encodeOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	direct, _ := json.Marshal(value)
+
+	var got, want map[string]interface{}
+	json.Unmarshal(encoded, &got)
+	json.Unmarshal(direct, &want)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %s, want %s", encoded, direct)
+	}
+}
+
+func TestEncodeUnsupportedValues(t *testing.T) {
+	encoded := encodeMetadata(map[string]interface{}{"callback": func() {}, "ch": make(chan int)}, encodeOptions{})
+
+	if _, err := json.Marshal(encoded); err != nil {
+		t.Errorf("expected unsupported values to be described instead of failing: %s", err)
+	}
+}
+
+type stringerID int
+
+func (id stringerID) String() string {
+	return fmt.Sprintf("ID-%03d", int(id))
+}
+
+type brokenMarshaler struct{}
+
+func (brokenMarshaler) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("broken")
+}
+
+type panickingStringer struct {
+	name *string
+}
+
+func (p *panickingStringer) String() string {
+	return *p.name
+}
+
+type ipAddress [4]byte
+
+func (ip ipAddress) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d.%d.%d.%d", ip[0], ip[1], ip[2], ip[3])), nil
+}
+
+func TestEncodeSelfDescribingValues(t *testing.T) {
+	encoded, err := json.Marshal(encodeMetadata(map[string]interface{}{
+		"id":     stringerID(7),
+		"addr":   ipAddress{10, 0, 0, 1},
+		"err":    errors.New("no fix"),
+		"broken": brokenMarshaler{},
+		"panics": &panickingStringer{},
+		"raw":    json.RawMessage(`{"ok":true}`),
+	}, encodeOptions{}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	json.Unmarshal(encoded, &got)
+
+	want := map[string]interface{}{
+		"id":     "ID-007",
+		"addr":   "10.0.0.1",
+		"err":    "no fix",
+		"broken": "!ERROR(log.brokenMarshaler: broken)",
+		"raw":    map[string]interface{}{"ok": true},
+	}
+
+	for key, value := range want {
+		if !reflect.DeepEqual(got[key], value) {
+			t.Errorf("%s: got %#v, want %#v", key, got[key], value)
+		}
+	}
+
+	if panics, _ := got["panics"].(string); !strings.HasPrefix(panics, "!PANIC(") {
+		t.Errorf("expected a panic fallback, got %#v", got["panics"])
+	}
+}
+
+type octet uint8
+
+func TestEncodeBytes(t *testing.T) {
+	payload := []byte{0xde, 0xad, 0xbe, 0xef}
+	large := make([]byte, 1024)
+
+	cases := []struct {
+		options encodeOptions
+		value   interface{}
+		want    interface{}
+	}{
+		{encodeOptions{}, payload, "3q2+7w=="},
+		{encodeOptions{bytes: BytesHex}, [4]byte{0xde, 0xad, 0xbe, 0xef}, "deadbeef"},
+		{encodeOptions{bytes: BytesHex}, []octet{1, 2, 3}, "010203"},
+		{encodeOptions{bytes: BytesHex}, [2]octet{0xbe, 0xef}, "beef"},
+		{encodeOptions{bytes: BytesLength}, payload, map[string]interface{}{"bytes": 4}},
+		{encodeOptions{bytes: BytesPreview}, payload, map[string]interface{}{"bytes": 4, "preview": "deadbeef"}},
+		{encodeOptions{bytes: BytesHex, bytesLimit: 512}, large, map[string]interface{}{"bytes": 1024, "preview": strings.Repeat("00", bytesPreviewSize)}},
+	}
+
+	for _, c := range cases {
+		got := encodeMetadata(map[string]interface{}{"data": c.value}, c.options).(map[string]interface{})["data"]
+
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%+v: got %#v, want %#v", c.options, got, c.want)
+		}
+	}
+}
+
+func TestEncodeDurationsAndTimes(t *testing.T) {
+	when := time.Date(2020, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	took := 1500 * time.Millisecond
+
+	cases := []struct {
+		options encodeOptions
+		took    interface{}
+		when    interface{}
+	}{
+		{encodeOptions{}, "1.5s", "2020-01-02T03:04:05.6Z"},
+		{encodeOptions{durations: DurationMilliseconds, timeLayout: time.RFC3339}, float64(1500), "2020-01-02T03:04:05Z"},
+		{encodeOptions{durations: DurationSeconds}, 1.5, "2020-01-02T03:04:05.6Z"},
+		{encodeOptions{durations: DurationNanoseconds}, int64(1500000000), "2020-01-02T03:04:05.6Z"},
+	}
+
+	for _, c := range cases {
+		got := encodeMetadata(map[string]interface{}{"took": took, "when": &when}, c.options).(map[string]interface{})
+
+		if got["took"] != c.took || got["when"] != c.when {
+			t.Errorf("%+v: got took %#v and when %#v", c.options, got["took"], got["when"])
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	metadata := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name":    "logan",
+			"address": map[string]interface{}{"city": "Seattle", "geo": map[string]interface{}{"lat": 47.6}},
+		},
+		"tags": []string{"a"},
+	}
+
+	got := encodeMetadata(metadata, encodeOptions{flattenDepth: 2})
+	want := map[string]interface{}{
+		"user.name":         "logan",
+		"user.address.city": "Seattle",
+		"user.address.geo":  map[string]interface{}{"lat": 47.6},
+		"tags":              []interface{}{"a"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	filtered := FieldFilter{Deny: []string{"user.address.city"}}.apply(got)
+	if _, ok := filtered.(map[string]interface{})["user.address.city"]; ok {
+		t.Error("expected field filters to match flattened keys")
+	}
+}
@@ -0,0 +1,162 @@
+package log
+
+import (
+	"fmt"
+)
+
+// Entry logs messages carrying a set of fields, which are shipped as top level
+// keys of every message so Loggly can index and facet on them.
+type Entry struct {
+	logger *logger
+	fields map[string]interface{}
+	mdc    *MappedContext
+
+	// session, if set, counts the entry's events.
+	session *Session
+
+	// request, if set, holds the entry's events under tail sampling.
+	request *RequestLog
+}
+
+// With returns an Entry whose messages carry the field key, chainable to add
+// more:
+//
+//	log.With("user_id", id).With("region", region).Infoln("login")
+func With(key string, value interface{}) *Entry {
+	return (&Entry{}).With(key, value)
+}
+
+// WithFields returns an Entry whose messages carry fields.
+func WithFields(fields map[string]interface{}) *Entry {
+	return (&Entry{}).WithFields(fields)
+}
+
+// With returns a copy of the entry whose messages also carry the field key.
+func (e *Entry) With(key string, value interface{}) *Entry {
+	return e.WithFields(map[string]interface{}{key: value})
+}
+
+// WithFields returns a copy of the entry whose messages also carry fields,
+// replacing any of the entry's fields with the same keys.
+func (e *Entry) WithFields(fields map[string]interface{}) *Entry {
+	merged := make(map[string]interface{}, len(e.fields)+len(fields))
+
+	for key, value := range e.fields {
+		merged[key] = value
+	}
+
+	for key, value := range fields {
+		merged[key] = value
+	}
+
+	return &Entry{logger: e.logger, fields: merged, mdc: e.mdc, session: e.session, request: e.request}
+}
+
+// Logln prints the output at level, which may be a custom level.
+func (e *Entry) Logln(level Level, output string) {
+	e.Logd(level, output, nil)
+}
+
+// Logf prints the formatted output at level, which may be a custom level.
+func (e *Entry) Logf(level Level, format string, a ...interface{}) {
+	e.Logln(level, fmt.Sprintf(format, a...))
+}
+
+// Logd prints output string and data at level, which may be a custom level.
+func (e *Entry) Logd(level Level, output string, d interface{}) {
+	e.log(output, level, false, d)
+}
+
+// Infoln prints the output.
+func (e *Entry) Infoln(output string) {
+	e.Infod(output, nil)
+}
+
+// Infof prints the formatted output.
+func (e *Entry) Infof(format string, a ...interface{}) {
+	e.Infoln(fmt.Sprintf(format, a...))
+}
+
+// Infod prints output string and data.
+func (e *Entry) Infod(output string, d interface{}) {
+	e.log(output, LogLevelInfo, false, d)
+}
+
+// Warnln prints the output.
+func (e *Entry) Warnln(output string) {
+	e.Warnd(output, nil)
+}
+
+// Warnf prints the formatted output.
+func (e *Entry) Warnf(format string, a ...interface{}) {
+	e.Warnln(fmt.Sprintf(format, a...))
+}
+
+// Warnd prints output string and data.
+func (e *Entry) Warnd(output string, d interface{}) {
+	e.log(output, LogLevelWarn, false, d)
+}
+
+// Errorln prints the output.
+func (e *Entry) Errorln(output string) {
+	e.Errord(output, nil)
+}
+
+// Errorf prints the formatted output.
+func (e *Entry) Errorf(format string, a ...interface{}) {
+	e.Errorln(fmt.Sprintf(format, a...))
+}
+
+// Errord prints output string and data.
+func (e *Entry) Errord(output string, d interface{}) {
+	e.log(output, LogLevelError, false, d)
+}
+
+// Fatalln prints the output.
+func (e *Entry) Fatalln(output string) {
+	e.Fatald(output, nil)
+}
+
+// Fatalf prints the formatted output.
+func (e *Entry) Fatalf(format string, a ...interface{}) {
+	e.Fatalln(fmt.Sprintf(format, a...))
+}
+
+// Fatald prints output string and data.
+func (e *Entry) Fatald(output string, d interface{}) {
+	e.log(output, LogLevelFatal, true, d)
+}
+
+func (e *Entry) log(output string, level Level, exit bool, d interface{}) {
+	l := e.logger
+	if l == nil {
+		l = loggerSingleton
+	}
+
+	if e.session != nil {
+		e.session.count(level, output)
+	}
+
+	r := record{output: output, level: level, exit: exit, data: d, fields: e.allFields()}
+
+	if e.request != nil && e.request.hold(l, r) {
+		return
+	}
+
+	l.log(r)
+}
+
+// allFields merges the entry's fields over its MDC values.
+func (e *Entry) allFields() map[string]interface{} {
+	values := e.mdc.snapshot()
+
+	if len(values) == 0 {
+		return e.fields
+	}
+
+	for key, value := range e.fields {
+		values[key] = value
+	}
+
+	return values
+}
@@ -0,0 +1,29 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWith(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	base := (&Logger{l: l}).With("user_id", 42)
+	login := base.With("region", "eu").WithFields(map[string]interface{}{"method": "sso", "message": "reserved"})
+
+	login.Infoln("login")
+
+	body := receive(t, bodies)
+
+	for _, expected := range []string{`"user_id":42`, `"region":"eu"`, `"method":"sso"`, `"field.message":"reserved"`, `"message":"login"`} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %s in %q", expected, body)
+		}
+	}
+
+	base.Infoln("logout")
+
+	if body := receive(t, bodies); strings.Contains(body, "region") {
+		t.Errorf("expected the parent entry unchanged, got %q", body)
+	}
+}
@@ -0,0 +1,108 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrShippingDisabled is reported on a delivery channel when shipping to
+// Loggly is disabled, as it is in the dev environment.
+var ErrShippingDisabled = errors.New("shipping to loggly is disabled")
+
+// SetEnvironment stamps every message with an environment field, such as
+// "prod", "staging" or "dev", and applies that environment's defaults: dev
+// disables shipping to Loggly so logs only reach the console and sinks.
+//
+// It refuses dangerous combinations, like shipping prod at Debug level or
+// below without a sampling rule, unless force is set.
+func SetEnvironment(environment string, force bool) error {
+	return loggerSingleton.setEnvironment(environment, force)
+}
+
+// SetShipping enables or disables shipping to Loggly. Sinks and the console
+// are unaffected.
+func SetShipping(enabled bool) {
+	loggerSingleton.Lock()
+	loggerSingleton.shippingDisabled = !enabled
+	loggerSingleton.Unlock()
+}
+
+func (l *logger) setEnvironment(environment string, force bool) error {
+	environment = strings.ToLower(strings.TrimSpace(environment))
+
+	if environment == "" {
+		return errors.New("environment must not be empty")
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	switch environment {
+	case "prod", "production":
+		if !force && !l.shippingDisabled && l.Level.rank() <= LogLevelDebug.rank() && !l.sampling() {
+			return fmt.Errorf("refusing to ship %s at %s level without sampling, pass force to override", environment, l.Level)
+		}
+	case "dev", "development", "local":
+		l.shippingDisabled = true
+	}
+
+	l.setField("environment", environment)
+
+	return nil
+}
+
+// sampling reports whether a sampling rule limits volume. It must be called
+// with the lock held.
+func (l *logger) sampling() bool {
+	for _, rule := range l.filters {
+		if rule.rule.Action == FilterSample {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setField adds a field to every message. It must be called with the lock
+// held.
+func (l *logger) setField(key string, value interface{}) {
+	fields := make(map[string]interface{}, len(l.fields)+1)
+
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+
+	fields[key] = value
+	l.fields = fields
+}
+
+// withLoggerFields merges the logger's fields under a record's fields.
+func (l *logger) withLoggerFields(fields map[string]interface{}) map[string]interface{} {
+	l.Lock()
+	loggerFields := l.fields
+	l.Unlock()
+
+	if len(loggerFields) == 0 {
+		return fields
+	}
+
+	merged := make(map[string]interface{}, len(loggerFields)+len(fields))
+
+	for key, value := range loggerFields {
+		merged[key] = value
+	}
+
+	for key, value := range fields {
+		merged[key] = value
+	}
+
+	return merged
+}
+
+func (l *logger) isShippingDisabled() bool {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.shippingDisabled
+}
@@ -0,0 +1,67 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnvironmentField(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+	l.Level = LogLevelInfo
+
+	if err := l.setEnvironment("Staging", false); err != nil {
+		t.Fatal(err)
+	}
+
+	l.buildAndShipMessage("This is an info statement.", LogLevelInfo, false, nil)
+	l.flush()
+
+	if body := receive(t, bodies); !strings.Contains(body, `"environment":"staging"`) {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestDevEnvironmentDisablesShipping(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	if err := l.setEnvironment("dev", false); err != nil {
+		t.Fatal(err)
+	}
+
+	ack := make(chan error, 1)
+	l.log(record{output: "This stays local.", level: LogLevelInfo, ack: ack})
+
+	if err := <-ack; err != ErrShippingDisabled {
+		t.Errorf("expected ErrShippingDisabled, got %v", err)
+	}
+
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected request %q", body)
+	default:
+	}
+}
+
+func TestProdGuardrails(t *testing.T) {
+	l, _ := newTestLogger(t, true)
+	l.Level = LogLevelDebug
+
+	if err := l.setEnvironment("prod", false); err == nil {
+		t.Error("expected prod at debug level without sampling to be refused")
+	}
+
+	if err := l.setEnvironment("prod", true); err != nil {
+		t.Errorf("expected force to override the guardrail, got %s", err)
+	}
+
+	l.Level = LogLevelDebug
+	l.fields = nil
+
+	if _, err := l.addFilter(FilterRule{Action: FilterSample, Rate: 0.1, Levels: []Level{LogLevelDebug}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.setEnvironment("production", false); err != nil {
+		t.Errorf("expected sampled debug shipping to be allowed, got %s", err)
+	}
+}
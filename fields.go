@@ -0,0 +1,226 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldFilter restricts the metadata fields delivered to a sink. Fields are
+// named by their JSON key, with nested fields joined by dots, e.g.
+// "request.body".
+type FieldFilter struct {
+	// Allow, when not empty, lists the only fields delivered.
+	Allow []string
+
+	// Deny lists fields that are never delivered.
+	Deny []string
+}
+
+// apply returns a filtered copy of metadata. Metadata that doesn't encode to
+// a JSON object is returned unchanged.
+func (f FieldFilter) apply(metadata interface{}) interface{} {
+	if metadata == nil || (len(f.Allow) == 0 && len(f.Deny) == 0) {
+		return metadata
+	}
+
+	fields, ok := toFieldMap(metadata)
+
+	if !ok {
+		return metadata
+	}
+
+	if len(f.Allow) > 0 {
+		allowed := map[string]interface{}{}
+
+		for _, path := range f.Allow {
+			if value, ok := getPath(fields, path); ok {
+				setPath(allowed, path, value)
+			}
+		}
+
+		fields = allowed
+	}
+
+	for _, path := range f.Deny {
+		deletePath(fields, path)
+	}
+
+	return fields
+}
+
+// applyFields returns a filtered copy of top level message fields.
+func (f FieldFilter) applyFields(fields map[string]interface{}) map[string]interface{} {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	filtered, _ := f.apply(fields).(map[string]interface{})
+
+	return filtered
+}
+
+// encodeFields encodes the values of top level message fields.
+func encodeFields(fields map[string]interface{}, options encodeOptions) map[string]interface{} {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	encoded, _ := encodeMetadata(fields, options).(map[string]interface{})
+
+	return encoded
+}
+
+// formatFields renders fields as sorted key=value pairs for the console.
+func formatFields(fields map[string]interface{}) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = fmt.Sprintf("%s=%+v", key, fields[key])
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// matchableFields merges metadata and top level fields for rule matching,
+// metadata taking precedence.
+func matchableFields(metadata interface{}, fields map[string]interface{}) map[string]interface{} {
+	matchable, _ := toFieldMap(metadata)
+
+	if matchable == nil {
+		matchable = map[string]interface{}{}
+	}
+
+	for key, value := range fields {
+		if _, ok := matchable[key]; !ok {
+			matchable[key] = value
+		}
+	}
+
+	return matchable
+}
+
+// toFieldMap converts metadata into a fresh map of its JSON fields.
+func toFieldMap(metadata interface{}) (map[string]interface{}, bool) {
+	b, err := json.Marshal(metadata)
+
+	if err != nil {
+		return nil, false
+	}
+
+	var fields map[string]interface{}
+
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, false
+	}
+
+	return fields, true
+}
+
+// componentOf returns the component field of the metadata or, failing that,
+// of the event's fields.
+func componentOf(d interface{}, fields map[string]interface{}) (string, bool) {
+	if component, ok := fieldValue(d, "component"); ok {
+		return component, true
+	}
+
+	component, ok := fields["component"].(string)
+
+	return component, ok
+}
+
+// fieldValue returns the string value of a top level metadata field.
+func fieldValue(metadata interface{}, key string) (string, bool) {
+	var value interface{}
+
+	switch m := metadata.(type) {
+	case nil:
+		return "", false
+	case map[string]string:
+		v, ok := m[key]
+		return v, ok
+	case map[string]interface{}:
+		value = m[key]
+	default:
+		fields, ok := toFieldMap(metadata)
+
+		if !ok {
+			return "", false
+		}
+
+		value = fields[key]
+	}
+
+	s, ok := value.(string)
+
+	return s, ok
+}
+
+func getPath(fields map[string]interface{}, path string) (interface{}, bool) {
+	// Flattened metadata holds dotted keys directly.
+	if value, ok := fields[path]; ok {
+		return value, true
+	}
+
+	keys := strings.Split(path, ".")
+
+	for i, key := range keys {
+		value, ok := fields[key]
+
+		if !ok {
+			return nil, false
+		}
+
+		if i == len(keys)-1 {
+			return value, true
+		}
+
+		if fields, ok = value.(map[string]interface{}); !ok {
+			return nil, false
+		}
+	}
+
+	return nil, false
+}
+
+func setPath(fields map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := fields[key].(map[string]interface{})
+
+		if !ok {
+			next = map[string]interface{}{}
+			fields[key] = next
+		}
+
+		fields = next
+	}
+
+	fields[keys[len(keys)-1]] = value
+}
+
+func deletePath(fields map[string]interface{}, path string) {
+	delete(fields, path)
+
+	keys := strings.Split(path, ".")
+
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := fields[key].(map[string]interface{})
+
+		if !ok {
+			return
+		}
+
+		fields = next
+	}
+
+	delete(fields, keys[len(keys)-1])
+}
@@ -0,0 +1,67 @@
+package log
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldFilterDeny(t *testing.T) {
+	metadata := map[string]interface{}{
+		"user":    "logan",
+		"payload": map[string]interface{}{"body": "secret", "size": 6},
+	}
+
+	got := FieldFilter{Deny: []string{"payload.body"}}.apply(metadata)
+	want := map[string]interface{}{
+		"user":    "logan",
+		"payload": map[string]interface{}{"size": float64(6)},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// The caller's metadata is left alone.
+	if _, ok := metadata["payload"].(map[string]interface{})["body"]; !ok {
+		t.Error("filter modified the original metadata")
+	}
+}
+
+func TestFieldFilterAllow(t *testing.T) {
+	type request struct {
+		Method string `json:"method"`
+		Body   string `json:"body"`
+	}
+
+	metadata := struct {
+		Request request `json:"request"`
+		Debug   string  `json:"debug"`
+	}{Request: request{Method: "POST", Body: "secret"}, Debug: "verbose"}
+
+	got := FieldFilter{Allow: []string{"request.method"}}.apply(metadata)
+	want := map[string]interface{}{"request": map[string]interface{}{"method": "POST"}}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestFieldFilterNonObject(t *testing.T) {
+	if got := (FieldFilter{Deny: []string{"x"}}).apply("plain"); got != "plain" {
+		t.Errorf("expected non-object metadata to pass through, got %+v", got)
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	type tagged struct {
+		Component string `json:"component"`
+	}
+
+	if v, ok := fieldValue(tagged{Component: "gps"}, "component"); !ok || v != "gps" {
+		t.Errorf("unexpected value %q", v)
+	}
+
+	if _, ok := fieldValue("plain", "component"); ok {
+		t.Error("expected no component for plain metadata")
+	}
+}
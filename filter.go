@@ -0,0 +1,246 @@
+package log
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// FilterAction is what a matching filter rule does with an event.
+type FilterAction string
+
+const (
+	// FilterDrop discards matching events before they are printed, written to
+	// sinks or buffered.
+	FilterDrop FilterAction = "drop"
+
+	// FilterKeep ships matching events, overriding later drop rules.
+	FilterKeep FilterAction = "keep"
+
+	// FilterSample ships a random fraction, Rate, of matching events, the
+	// same fraction of requests when sampling is keyed, see SetSampleKeys.
+	FilterSample FilterAction = "sample"
+)
+
+// FilterRule matches events by message, level, component and field values.
+// Every condition that is set must match. Rules are evaluated in the order
+// they were added and the first matching rule decides, events matching no
+// rule are kept.
+type FilterRule struct {
+	// ID identifies the rule for removal. One is generated if it is empty.
+	ID string `json:"id"`
+
+	Action FilterAction `json:"action"`
+
+	// Rate is the fraction of matching events kept by a sample rule.
+	Rate float64 `json:"rate,omitempty"`
+
+	// Message is a regular expression matched against the message.
+	Message string `json:"message,omitempty"`
+
+	// Levels restricts the rule to events at these levels.
+	Levels []Level `json:"levels,omitempty"`
+
+	// Component is a regular expression matched against the metadata's
+	// component field.
+	Component string `json:"component,omitempty"`
+
+	// Fields maps metadata fields, dotted for nested fields, to regular
+	// expressions matched against their values.
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+type compiledRule struct {
+	rule      FilterRule
+	message   *regexp.Regexp
+	component *regexp.Regexp
+	fields    map[string]*regexp.Regexp
+}
+
+// AddFilter appends a filter rule and returns its ID. Rules can be added and
+// removed at runtime, e.g. to mute a noisy message during an incident.
+func AddFilter(rule FilterRule) (string, error) {
+	return loggerSingleton.addFilter(rule)
+}
+
+// RemoveFilter removes the filter rule with id, reporting whether it existed.
+func RemoveFilter(id string) bool {
+	return loggerSingleton.removeFilter(id)
+}
+
+// Filters returns the current filter rules in evaluation order.
+func Filters() []FilterRule {
+	return loggerSingleton.filterRules()
+}
+
+func (l *logger) addFilter(rule FilterRule) (string, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	if rule.ID == "" {
+		l.filterSequence++
+		rule.ID = fmt.Sprintf("rule-%d", l.filterSequence)
+	}
+
+	for _, existing := range l.filters {
+		if existing.rule.ID == rule.ID {
+			return "", fmt.Errorf("filter rule %s already exists", rule.ID)
+		}
+	}
+
+	compiled, err := compileRule(rule)
+
+	if err != nil {
+		return "", err
+	}
+
+	// Copy on write so filtered can evaluate the rules without the lock.
+	filters := make([]*compiledRule, len(l.filters), len(l.filters)+1)
+	copy(filters, l.filters)
+	l.filters = append(filters, compiled)
+
+	return rule.ID, nil
+}
+
+func (l *logger) removeFilter(id string) bool {
+	l.Lock()
+	defer l.Unlock()
+
+	for i, rule := range l.filters {
+		if rule.rule.ID == id {
+			filters := make([]*compiledRule, 0, len(l.filters)-1)
+			filters = append(filters, l.filters[:i]...)
+			l.filters = append(filters, l.filters[i+1:]...)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *logger) filterRules() []FilterRule {
+	l.Lock()
+	defer l.Unlock()
+
+	rules := make([]FilterRule, len(l.filters))
+
+	for i, rule := range l.filters {
+		rules[i] = rule.rule
+	}
+
+	return rules
+}
+
+func compileRule(rule FilterRule) (*compiledRule, error) {
+	switch rule.Action {
+	case FilterDrop, FilterKeep:
+	case FilterSample:
+		if rule.Rate < 0 || rule.Rate > 1 {
+			return nil, fmt.Errorf("sample rate %g is outside 0 to 1", rule.Rate)
+		}
+	default:
+		return nil, fmt.Errorf("unknown filter action %q", rule.Action)
+	}
+
+	compiled := &compiledRule{rule: rule, fields: map[string]*regexp.Regexp{}}
+
+	var err error
+
+	if rule.Message != "" {
+		if compiled.message, err = regexp.Compile(rule.Message); err != nil {
+			return nil, fmt.Errorf("invalid message pattern: %s", err)
+		}
+	}
+
+	if rule.Component != "" {
+		if compiled.component, err = regexp.Compile(rule.Component); err != nil {
+			return nil, fmt.Errorf("invalid component pattern: %s", err)
+		}
+	}
+
+	for field, pattern := range rule.Fields {
+		if compiled.fields[field], err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid pattern for field %s: %s", field, err)
+		}
+	}
+
+	return compiled, nil
+}
+
+// filtered reports whether the filter rules drop the event.
+func (l *logger) filtered(output string, level Level, d interface{}, extra map[string]interface{}) bool {
+	l.Lock()
+	rules := l.filters
+	sampleKeys := l.sampleKeys
+	l.Unlock()
+
+	if len(rules) == 0 {
+		return false
+	}
+
+	var fields map[string]interface{}
+
+	for _, rule := range rules {
+		if (rule.component != nil || len(rule.fields) > 0) && fields == nil {
+			fields = matchableFields(d, extra)
+		}
+
+		if !rule.matches(output, level, fields) {
+			continue
+		}
+
+		switch rule.rule.Action {
+		case FilterDrop:
+			l.stats.recordFiltered()
+			return true
+		case FilterSample:
+			if !sampled(rule.rule.Rate, sampleKeys, d, extra) {
+				l.stats.recordFiltered()
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return false
+}
+
+func (r *compiledRule) matches(output string, level Level, fields map[string]interface{}) bool {
+	if r.message != nil && !r.message.MatchString(output) {
+		return false
+	}
+
+	if len(r.rule.Levels) > 0 {
+		found := false
+
+		for _, l := range r.rule.Levels {
+			if l == level {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if r.component != nil && !matchField(fields, "component", r.component) {
+		return false
+	}
+
+	for field, pattern := range r.fields {
+		if !matchField(fields, field, pattern) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchField(fields map[string]interface{}, path string, pattern *regexp.Regexp) bool {
+	value, ok := getPath(fields, path)
+
+	return ok && pattern.MatchString(fmt.Sprint(value))
+}
@@ -0,0 +1,99 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilterRules(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	for _, rule := range []FilterRule{
+		{Action: FilterKeep, Message: "^GET /health", Fields: map[string]string{"status": "^5"}},
+		{Action: FilterDrop, Message: "^GET /health"},
+		{Action: FilterDrop, Levels: []Level{LogLevelDebug}, Component: "^radio$"},
+	} {
+		if _, err := l.addFilter(rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l.buildAndShipMessage("GET /health 200", LogLevelInfo, false, map[string]interface{}{"status": 200})
+	l.buildAndShipMessage("GET /health 503", LogLevelInfo, false, map[string]interface{}{"status": 503})
+	l.buildAndShipMessage("radio chatter", LogLevelDebug, false, map[string]interface{}{"component": "radio"})
+	l.buildAndShipMessage("radio fault", LogLevelError, false, map[string]interface{}{"component": "radio"})
+	l.flush()
+
+	body := receive(t, bodies)
+
+	for _, dropped := range []string{"GET /health 200", "radio chatter"} {
+		if strings.Contains(body, dropped) {
+			t.Errorf("expected %q to be dropped", dropped)
+		}
+	}
+
+	for _, kept := range []string{"GET /health 503", "radio fault"} {
+		if !strings.Contains(body, kept) {
+			t.Errorf("expected %q to be kept", kept)
+		}
+	}
+
+	if filtered := l.stats.snapshot().EventsFiltered; filtered != 2 {
+		t.Errorf("expected 2 filtered events, got %d", filtered)
+	}
+}
+
+func TestCompileRuleErrors(t *testing.T) {
+	if _, err := compileRule(FilterRule{Action: "mute"}); err == nil {
+		t.Error("expected an error for an unknown action")
+	}
+
+	if _, err := compileRule(FilterRule{Action: FilterDrop, Message: "("}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestAddRemoveFilter(t *testing.T) {
+	l, _ := newTestLogger(t, true)
+
+	id, err := l.addFilter(FilterRule{Action: FilterDrop, Message: "noisy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := l.addFilter(FilterRule{ID: id, Action: FilterDrop}); err == nil {
+		t.Error("expected an error for a duplicate rule ID")
+	}
+
+	if !l.filtered("noisy message", LogLevelInfo, nil, nil) {
+		t.Error("expected the rule to drop the message")
+	}
+
+	if !l.removeFilter(id) || l.removeFilter(id) {
+		t.Error("expected the rule to be removed exactly once")
+	}
+
+	if l.filtered("noisy message", LogLevelInfo, nil, nil) {
+		t.Error("expected the message to ship once the rule was removed")
+	}
+}
+
+func TestSampleFilter(t *testing.T) {
+	l, _ := newTestLogger(t, true)
+
+	if _, err := l.addFilter(FilterRule{Action: FilterSample, Rate: 0, Message: "sampled"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := l.addFilter(FilterRule{Action: FilterSample, Rate: 1, Message: "kept"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !l.filtered("sampled message", LogLevelInfo, nil, nil) || l.filtered("kept message", LogLevelInfo, nil, nil) {
+		t.Error("unexpected sampling decisions")
+	}
+
+	if _, err := compileRule(FilterRule{Action: FilterSample, Rate: 2}); err == nil {
+		t.Error("expected an error for a rate above 1")
+	}
+}
@@ -0,0 +1,84 @@
+package log
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// fingerprintField is the top level field carrying an error's fingerprint.
+const fingerprintField = "fingerprint"
+
+// fingerprintFrames is how many of the caller's stack frames go into a
+// fingerprint.
+const fingerprintFrames = 3
+
+// packagePrefix prefixes the names of this package's functions, whose frames
+// are left out of fingerprints.
+var packagePrefix = strings.TrimSuffix(reflect.TypeOf(logger{}).PkgPath(), "/") + "."
+
+// SetErrorFingerprints adds a "fingerprint" field to Error and Fatal events:
+// a hash of the message template and the top frames of the logging call's
+// stack. It is stable across deployments and differently parameterised
+// messages, so Loggly can group and deduplicate alerts on it.
+func SetErrorFingerprints(enabled bool) {
+	loggerSingleton.Lock()
+	loggerSingleton.fingerprints = enabled
+	loggerSingleton.Unlock()
+}
+
+// withFingerprint returns fields with the fingerprint of an error event.
+func (l *logger) withFingerprint(output string, fields map[string]interface{}) map[string]interface{} {
+	l.Lock()
+	enabled := l.fingerprints
+	l.Unlock()
+
+	if !enabled {
+		return fields
+	}
+
+	fingerprinted := make(map[string]interface{}, len(fields)+1)
+
+	for key, value := range fields {
+		fingerprinted[key] = value
+	}
+
+	fingerprinted[fingerprintField] = errorFingerprint(output, callerFunctions(fingerprintFrames))
+
+	return fingerprinted
+}
+
+// errorFingerprint hashes the message template and stack functions. Line
+// numbers are left out so unrelated edits don't change it.
+func errorFingerprint(output string, functions []string) string {
+	sum := sha256.Sum256([]byte(messageTemplate(output) + "\n" + strings.Join(functions, "\n")))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// callerFunctions returns the names of up to n functions on the stack above
+// this package's own frames.
+func callerFunctions(n int) []string {
+	pcs := make([]uintptr, maxStackDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	var functions []string
+
+	for len(functions) < n {
+		frame, more := frames.Next()
+
+		internal := strings.HasPrefix(frame.Function, packagePrefix) && !strings.HasSuffix(frame.File, "_test.go")
+
+		if !internal && frame.Function != "" {
+			functions = append(functions, frame.Function)
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return functions
+}
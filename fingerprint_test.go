@@ -0,0 +1,60 @@
+package log
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestErrorFingerprint(t *testing.T) {
+	functions := []string{"main.handle", "main.main"}
+
+	if errorFingerprint("order 42 failed", functions) != errorFingerprint("order 7 failed", functions) {
+		t.Error("expected messages from the same template to share a fingerprint")
+	}
+
+	if errorFingerprint("order 42 failed", functions) == errorFingerprint("order 42 failed", functions[1:]) {
+		t.Error("expected different call sites to have different fingerprints")
+	}
+}
+
+func TestErrorFingerprints(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.fingerprints = true
+
+	fingerprint := func(body string) string {
+		var message map[string]interface{}
+		json.Unmarshal([]byte(body), &message)
+
+		s, _ := message[fingerprintField].(string)
+
+		return s
+	}
+
+	var prints []string
+
+	for _, id := range []string{"42", "7"} {
+		l.buildAndShipMessage("order "+id+" failed", LogLevelError, false, nil)
+		prints = append(prints, fingerprint(receive(t, bodies)))
+	}
+
+	retry := func() {
+		l.buildAndShipMessage("order 42 failed", LogLevelError, false, nil)
+	}
+
+	retry()
+	prints = append(prints, fingerprint(receive(t, bodies)))
+
+	if prints[0] == "" || prints[0] != prints[1] {
+		t.Errorf("expected a shared fingerprint from one function, got %q", prints)
+	}
+
+	if prints[2] == prints[0] {
+		t.Errorf("expected another function to have its own fingerprint, got %q", prints)
+	}
+
+	l.buildAndShipMessage("This is a warning.", LogLevelWarn, false, nil)
+
+	if print := fingerprint(receive(t, bodies)); print != "" {
+		t.Errorf("unexpected fingerprint %q on a warning", print)
+	}
+}
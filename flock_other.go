@@ -0,0 +1,18 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package log
+
+import (
+	"os"
+)
+
+// lockFile always succeeds, there is no advisory locking on this platform so
+// a spool directory must not be shared between processes.
+func lockFile(f *os.File, block bool) (bool, error) {
+	return true, nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,37 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package log
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive advisory lock on f. Unless block is set it
+// reports false instead of waiting when another process holds the lock.
+func lockFile(f *os.File, block bool) (bool, error) {
+	how := syscall.LOCK_EX
+
+	if !block {
+		how |= syscall.LOCK_NB
+	}
+
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+
+		if err == syscall.EINTR {
+			continue
+		}
+
+		if err == syscall.EWOULDBLOCK {
+			return false, nil
+		}
+
+		return err == nil, err
+	}
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
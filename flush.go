@@ -0,0 +1,164 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrClosed is reported on a delivery channel when the message was logged
+// after Close.
+var ErrClosed = errors.New("logger is closed")
+
+// closeTimeout bounds how long Close waits for buffered events to ship.
+const closeTimeout = 30 * time.Second
+
+// Flush ships the bulk buffer and waits for every request already in flight,
+// returning the first shipping error, or the context's error if it is done
+// first.
+func Flush(ctx context.Context) error {
+	return loggerSingleton.flushAll(ctx)
+}
+
+// Close stops the bulk flush interval, then flushes, waiting up to 30 seconds
+// for the buffer and in flight requests to ship, and releases the spool, the
+// relay connection, the archive and the subscriptions. Events logged
+// afterwards are printed and written to sinks but not shipped.
+func Close() error {
+	return loggerSingleton.close()
+}
+
+// Flush ships the logger's bulk buffer and waits for requests in flight, see
+// the package level Flush.
+func (lg *Logger) Flush(ctx context.Context) error {
+	return lg.logger().flushAll(ctx)
+}
+
+// Close stops the logger once its events have shipped, see the package level
+// Close.
+func (lg *Logger) Close() error {
+	return lg.logger().close()
+}
+
+func (l *logger) flushAll(ctx context.Context) error {
+	var err error
+
+	if l.bulk {
+		err = l.flushContext(ctx)
+	}
+
+	if waitErr := l.inflight.wait(ctx); err == nil {
+		err = waitErr
+	}
+
+	return err
+}
+
+func (l *logger) close() error {
+	l.Lock()
+
+	if l.closed {
+		l.Unlock()
+		return nil
+	}
+
+	l.closed = true
+
+	if l.stop != nil {
+		close(l.stop)
+		l.stop = nil
+	}
+	l.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+
+	err := l.flushAll(ctx)
+
+	// Nothing ships once closed, so nothing spools, relays or archives.
+	l.Lock()
+	s, r := l.spool, l.relay
+	l.spool, l.relay, l.archive = nil, nil, nil
+	l.Unlock()
+
+	if s != nil {
+		if closeErr := s.close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	if r != nil {
+		r.close()
+	}
+
+	l.closeSubscribers()
+
+	return err
+}
+
+func (l *logger) isClosed() bool {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.closed
+}
+
+// goShip runs f on a new goroutine tracked as in flight until it returns.
+func (l *logger) goShip(f func()) {
+	l.inflight.add()
+
+	go func() {
+		defer l.inflight.done()
+		f()
+	}()
+}
+
+// inflight counts shipping goroutines so they can be waited for.
+type inflight struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{}
+}
+
+func (f *inflight) add() {
+	f.mu.Lock()
+	f.n++
+	f.mu.Unlock()
+}
+
+func (f *inflight) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.n--
+
+	if f.n == 0 && f.idle != nil {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+// wait returns once nothing is in flight or ctx is done.
+func (f *inflight) wait(ctx context.Context) error {
+	f.mu.Lock()
+
+	if f.n == 0 {
+		f.mu.Unlock()
+		return nil
+	}
+
+	if f.idle == nil {
+		f.idle = make(chan struct{})
+	}
+
+	idle := f.idle
+	f.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,220 @@
+package log
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlushWaitsForInFlightRequests(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	l := newLogger("yourlogglytoken", 0, nil, false, false)
+	l.url = server.URL
+
+	ack := make(chan error, 1)
+	l.log(record{output: "in flight", level: LogLevelInfo, ack: ack})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := l.flushAll(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected the flush to wait for the request, got %v", err)
+	}
+
+	close(release)
+
+	if err := l.flushAll(context.Background()); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	select {
+	case err := <-ack:
+		if err != nil {
+			t.Errorf("expected successful delivery, got %s", err)
+		}
+	default:
+		t.Error("expected the request to have completed")
+	}
+}
+
+func TestFlushWaitsForReplay(t *testing.T) {
+	release := make(chan struct{})
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(b)
+	}))
+	defer server.Close()
+
+	l := newLogger("yourlogglytoken", 0, nil, false, false)
+	l.url = server.URL
+
+	var err error
+	if l.spool, err = openSpool(tempSpoolDir(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.spool.append([]spoolRecord{{time: time.Now(), data: []byte(`{"message":"replayed"}`)}}); err != nil {
+		t.Fatal(err)
+	}
+
+	l.replaySpool()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := l.flushAll(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected the flush to wait for the replay, got %v", err)
+	}
+
+	close(release)
+
+	if err := l.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case body := <-bodies:
+		if !strings.Contains(body, "replayed") {
+			t.Errorf("unexpected body %q", body)
+		}
+	default:
+		t.Error("expected the replay to have completed")
+	}
+}
+
+func TestClose(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+	l.startFlushLoop()
+
+	l.buildAndShipMessage("buffered", LogLevelInfo, false, nil)
+
+	if err := l.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if body := receive(t, bodies); !strings.Contains(body, "buffered") {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	if l.stop != nil {
+		t.Error("expected the flush loop stopped")
+	}
+
+	ack := make(chan error, 1)
+	l.log(record{output: "too late", level: LogLevelInfo, ack: ack})
+
+	if err := <-ack; err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestCloseKeepsFlushLoopStopped(t *testing.T) {
+	l, clock, bodies := newAdaptiveTestLogger(t, AdaptiveConfig{
+		MinFlushInterval: time.Second,
+		MaxFlushInterval: time.Minute,
+		TargetLatency:    4 * time.Second,
+	})
+	l.flushInterval = 15 * time.Second
+	l.startFlushLoop()
+
+	// The last flush retunes the interval, which would restart the loop.
+	l.buildAndShipMessage("buffered", LogLevelInfo, false, nil)
+	clock.Advance(10 * time.Second)
+
+	if err := l.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	receive(t, bodies)
+
+	if l.stop != nil {
+		t.Error("expected the flush loop to stay stopped")
+	}
+
+	l.startFlushLoop()
+
+	if l.stop != nil {
+		t.Error("expected a closed logger not to start a flush loop")
+	}
+}
+
+func TestCloseReleasesResources(t *testing.T) {
+	l, _ := newTestLogger(t, false)
+	dir := tempSpoolDir(t)
+
+	s, err := openSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Lock()
+	s.acquireUpload()
+	s.Unlock()
+	l.spool = s
+
+	listener, lines := listenRelay(t, "tcp", "127.0.0.1:0")
+
+	r, err := newRelay("tcp://" + listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.relay = r
+	l.archive = &archive{config: Archive{Dir: tempSpoolDir(t), Codec: GzipCodec}}
+
+	events := l.subscribe(LogLevelTrace)
+
+	l.buildAndShipMessage("relayed", LogLevelInfo, false, nil)
+	<-lines
+
+	if err := l.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for closed := false; !closed; {
+		select {
+		case _, ok := <-events:
+			closed = !ok
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the subscription closed")
+		}
+	}
+
+	if l.spool != nil || l.relay != nil || l.archive != nil {
+		t.Error("expected the spool, relay and archive released")
+	}
+
+	if r.conn != nil {
+		t.Error("expected the relay connection closed")
+	}
+
+	// The upload lock is free for another spool on the same directory.
+	reopened, err := openSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.close()
+
+	reopened.Lock()
+	acquired := reopened.acquireUpload()
+	reopened.Unlock()
+
+	if !acquired {
+		t.Error("expected the upload lock released")
+	}
+
+	if err := l.close(); err != nil {
+		t.Errorf("expected closing twice to succeed, got %v", err)
+	}
+}
@@ -1,12 +1,14 @@
 module github.com/morlockaerospace/loggly
 
-go 1.13
+go 1.14
 
 require (
 	github.com/fatih/color v1.7.0
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
 	github.com/mattn/go-colorable v0.1.4 // indirect
 	github.com/mattn/go-isatty v0.0.11 // indirect
-	github.com/sirupsen/logrus v1.4.2 // indirect
+	github.com/sirupsen/logrus v1.4.2
+	go.uber.org/zap v1.27.0
 	golang.org/x/sys v0.0.0-20191210023423-ac6580df4449 // indirect
 )
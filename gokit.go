@@ -0,0 +1,64 @@
+//go:build !loggly_minimal
+// +build !loggly_minimal
+
+package log
+
+import (
+	"fmt"
+)
+
+// GokitLogger adapts the loggly logger to go-kit's log.Logger interface so it
+// can be dropped into go-kit middleware stacks.
+type GokitLogger struct{}
+
+// NewGokitLogger creates a new go-kit compatible logger.
+func NewGokitLogger() *GokitLogger {
+	return &GokitLogger{}
+}
+
+// Log ships the keyvals as a single log message through the package level
+// logger. The "level" key selects the log level and the "msg" or "message"
+// key the message, the remaining pairs are shipped as metadata. Fatal events
+// are shipped without exiting, that is left to the caller.
+func (g *GokitLogger) Log(keyvals ...interface{}) error {
+	level, output, metadata := gokitMessage(keyvals)
+
+	loggerSingleton.buildAndShipMessage(output, level, false, metadata)
+
+	return nil
+}
+
+func gokitMessage(keyvals []interface{}) (Level, string, map[string]interface{}) {
+	level := LogLevelInfo
+	output := ""
+	metadata := map[string]interface{}{}
+
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+
+		var value interface{} = "(MISSING)"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+
+		// Errors don't marshal to anything useful so ship their text instead.
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+
+		switch key {
+		case "level":
+			level = MapLevel(fmt.Sprint(value))
+		case "msg", "message":
+			output = fmt.Sprint(value)
+		default:
+			metadata[key] = value
+		}
+	}
+
+	if len(metadata) == 0 {
+		return level, output, nil
+	}
+
+	return level, output, metadata
+}
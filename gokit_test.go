@@ -0,0 +1,76 @@
+//go:build !loggly_minimal
+// +build !loggly_minimal
+
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGokitMessage(t *testing.T) {
+	level, output, metadata := gokitMessage([]interface{}{"level", "warn", "msg", "disk almost full", "disk", "/dev/sda1", "err", errors.New("no space")})
+
+	if level != LogLevelWarn {
+		t.Errorf("expected level %s, got %s", LogLevelWarn, level)
+	}
+
+	if output != "disk almost full" {
+		t.Errorf("unexpected message %q", output)
+	}
+
+	if metadata["disk"] != "/dev/sda1" || metadata["err"] != "no space" {
+		t.Errorf("unexpected metadata %+v", metadata)
+	}
+}
+
+func TestGokitMessageMissingValue(t *testing.T) {
+	level, _, metadata := gokitMessage([]interface{}{"orphan"})
+
+	if level != LogLevelInfo {
+		t.Errorf("expected default level %s, got %s", LogLevelInfo, level)
+	}
+
+	if metadata["orphan"] != "(MISSING)" {
+		t.Errorf("unexpected metadata %+v", metadata)
+	}
+}
+
+func TestGokitLog(t *testing.T) {
+	SetupLogger("yourlogglytoken", 0, []string{"test"}, false, true)
+
+	NewGokitLogger().Log("level", "info", "msg", "This is a go-kit statement.", "component", "gokit")
+}
+
+func TestGokitLevelMapping(t *testing.T) {
+	defer ResetLevelMapping()
+
+	SetLevelMapping(map[string]Level{"verbose": LogLevelDebug})
+
+	if level, _, _ := gokitMessage([]interface{}{"level", "verbose"}); level != LogLevelDebug {
+		t.Errorf("expected the go-kit adapter to use the mapping, got %s", level)
+	}
+}
+
+func TestGokitFatalDoesNotExit(t *testing.T) {
+	code := stubExit(t)
+	adapter := NewGokitLogger()
+
+	previous := loggerSingleton
+	t.Cleanup(func() { loggerSingleton = previous })
+
+	// The adapter logs through the logger set up after it was created.
+	l, bodies := newTestLogger(t, false)
+	loggerSingleton = l
+
+	adapter.Log("level", "crit", "msg", "This is critical.")
+
+	if body := receive(t, bodies); !strings.Contains(body, `"level":"FATAL"`) {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	if *code != 0 {
+		t.Errorf("expected the adapter not to exit, got code %d", *code)
+	}
+}
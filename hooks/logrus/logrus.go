@@ -0,0 +1,96 @@
+// Package logrus ships logrus entries to Loggly through a logrus.Hook, so
+// existing logrus call sites need no changes. Import it under another name
+// alongside logrus itself:
+//
+//	import logglyhook "github.com/morlockaerospace/loggly/hooks/logrus"
+//
+//	logrus.AddHook(logglyhook.New(loggly.New(token, loggly.WithBulk(0, 0))))
+package logrus
+
+import (
+	"context"
+	"time"
+
+	loggly "github.com/morlockaerospace/loggly"
+	"github.com/sirupsen/logrus"
+)
+
+// fatalFlushTimeout bounds the flush before logrus exits on Fatal and Panic
+// entries.
+const fatalFlushTimeout = 5 * time.Second
+
+// Hook logs logrus entries through a Logger, with the entry's fields as
+// top level fields. In bulk mode entries join the Logger's bulk buffer,
+// which is flushed before logrus exits or panics.
+type Hook struct {
+	logger *loggly.Logger
+	levels []logrus.Level
+}
+
+// New creates a hook logging through logger, or the default Logger if it is
+// nil, for entries at levels, every level if none are given.
+func New(logger *loggly.Logger, levels ...logrus.Level) *Hook {
+	if logger == nil {
+		logger = loggly.Default()
+	}
+
+	if len(levels) == 0 {
+		levels = logrus.AllLevels
+	}
+
+	return &Hook{logger: logger, levels: levels}
+}
+
+// Levels returns the levels the hook fires for.
+func (h *Hook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire logs entry.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	fields := make(map[string]interface{}, len(entry.Data))
+
+	for key, value := range entry.Data {
+		// Errors marshal to empty objects.
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+
+		fields[key] = value
+	}
+
+	h.logger.Entry(fields).Logln(Level(entry.Level), entry.Message)
+
+	if entry.Level <= logrus.FatalLevel {
+		ctx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout)
+		defer cancel()
+
+		return h.logger.Flush(ctx)
+	}
+
+	return nil
+}
+
+// Level converts a logrus level to the equivalent level, following the level
+// names mapped with loggly.SetLevelMapping. By default Panic and Fatal both
+// map to Fatal, logrus exits or panics itself.
+func Level(level logrus.Level) loggly.Level {
+	if mapped, err := loggly.ParseLevel(level.String()); err == nil {
+		return mapped
+	}
+
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return loggly.LogLevelFatal
+	case logrus.ErrorLevel:
+		return loggly.LogLevelError
+	case logrus.WarnLevel:
+		return loggly.LogLevelWarn
+	case logrus.InfoLevel:
+		return loggly.LogLevelInfo
+	case logrus.DebugLevel:
+		return loggly.LogLevelDebug
+	}
+
+	return loggly.LogLevelTrace
+}
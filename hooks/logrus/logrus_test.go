@@ -0,0 +1,85 @@
+package logrus
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	loggly "github.com/morlockaerospace/loggly"
+	"github.com/sirupsen/logrus"
+)
+
+func TestHook(t *testing.T) {
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	lg := loggly.New("", loggly.WithEndpoint(server.URL), loggly.WithBulk(0, time.Hour), loggly.WithSynchronous())
+	defer lg.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(New(lg, logrus.WarnLevel, logrus.ErrorLevel))
+
+	logger.Info("This is below the hook's levels.")
+	logger.WithField("order", 7).Warn("slow checkout")
+	logger.WithError(errors.New("card declined")).Error("payment failed")
+
+	select {
+	case body := <-bodies:
+		t.Fatalf("expected entries buffered, got %q", body)
+	default:
+	}
+
+	if err := lg.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	body := <-bodies
+
+	for _, expected := range []string{`"level":"WARN"`, `"order":7`, `"message":"payment failed"`, `"error":"card declined"`} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %s in %q", expected, body)
+		}
+	}
+
+	if strings.Contains(body, "below the hook") {
+		t.Errorf("unexpected entry in %q", body)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	cases := map[logrus.Level]loggly.Level{
+		logrus.PanicLevel: loggly.LogLevelFatal,
+		logrus.FatalLevel: loggly.LogLevelFatal,
+		logrus.ErrorLevel: loggly.LogLevelError,
+		logrus.WarnLevel:  loggly.LogLevelWarn,
+		logrus.InfoLevel:  loggly.LogLevelInfo,
+		logrus.DebugLevel: loggly.LogLevelDebug,
+		logrus.TraceLevel: loggly.LogLevelTrace,
+	}
+
+	for level, expected := range cases {
+		if got := Level(level); got != expected {
+			t.Errorf("Level(%s): got %s, want %s", level, got, expected)
+		}
+	}
+}
+
+func TestLevelMapping(t *testing.T) {
+	defer loggly.ResetLevelMapping()
+
+	loggly.SetLevelMapping(map[string]loggly.Level{"warning": loggly.LogLevelError})
+
+	if got := Level(logrus.WarnLevel); got != loggly.LogLevelError {
+		t.Errorf("expected the mapping to apply, got %s", got)
+	}
+}
@@ -0,0 +1,129 @@
+// Package zap ships zap entries to Loggly through a zapcore.Core, which can
+// be teed with the cores already in use:
+//
+//	import logglyzap "github.com/morlockaerospace/loggly/hooks/zap"
+//
+//	core := zapcore.NewTee(existing, logglyzap.New(loggly.New(token), zapcore.InfoLevel))
+//	logger := zap.New(core)
+package zap
+
+import (
+	"context"
+	"time"
+
+	loggly "github.com/morlockaerospace/loggly"
+	"go.uber.org/zap/zapcore"
+)
+
+// syncTimeout bounds Sync, which zap also runs before exiting on Fatal.
+const syncTimeout = 5 * time.Second
+
+// Core logs zap entries through a Logger, with their fields as the event's
+// metadata and the logger name, caller and stack as top level fields. In
+// bulk mode entries join the Logger's bulk buffer, flushed by Sync.
+type Core struct {
+	zapcore.LevelEnabler
+
+	logger *loggly.Logger
+	fields map[string]interface{}
+}
+
+// New creates a core logging through logger, or the default Logger if it is
+// nil, for the levels enabler enables.
+func New(logger *loggly.Logger, enabler zapcore.LevelEnabler) *Core {
+	if logger == nil {
+		logger = loggly.Default()
+	}
+
+	return &Core{LevelEnabler: enabler, logger: logger}
+}
+
+// With returns a core adding fields to every entry.
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	return &Core{LevelEnabler: c.LevelEnabler, logger: c.logger, fields: c.encode(fields)}
+}
+
+// Check adds the core to ce if the entry's level is enabled.
+func (c *Core) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+
+	return ce
+}
+
+// Write logs entry with fields.
+func (c *Core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	extra := map[string]interface{}{}
+
+	if entry.LoggerName != "" {
+		extra["logger"] = entry.LoggerName
+	}
+
+	if entry.Caller.Defined {
+		extra["caller"] = entry.Caller.TrimmedPath()
+	}
+
+	if entry.Stack != "" {
+		extra["stack"] = entry.Stack
+	}
+
+	var d interface{}
+	if metadata := c.encode(fields); len(metadata) > 0 {
+		d = metadata
+	}
+
+	c.logger.Entry(extra).Logd(Level(entry.Level), entry.Message, d)
+
+	// Entries above Error end in a panic or exit.
+	if entry.Level > zapcore.ErrorLevel {
+		return c.Sync()
+	}
+
+	return nil
+}
+
+// Sync flushes the Logger's bulk buffer and waits for requests in flight.
+func (c *Core) Sync() error {
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	return c.logger.Flush(ctx)
+}
+
+// encode returns the core's fields with fields added.
+func (c *Core) encode(fields []zapcore.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+
+	for key, value := range c.fields {
+		enc.Fields[key] = value
+	}
+
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	return enc.Fields
+}
+
+// Level converts a zap level to the equivalent level, following the level
+// names mapped with loggly.SetLevelMapping. By default DPanic maps to Error,
+// Panic and Fatal to Fatal, zap panics or exits itself.
+func Level(level zapcore.Level) loggly.Level {
+	if mapped, err := loggly.ParseLevel(level.String()); err == nil {
+		return mapped
+	}
+
+	switch {
+	case level <= zapcore.DebugLevel:
+		return loggly.LogLevelDebug
+	case level == zapcore.InfoLevel:
+		return loggly.LogLevelInfo
+	case level == zapcore.WarnLevel:
+		return loggly.LogLevelWarn
+	case level <= zapcore.DPanicLevel:
+		return loggly.LogLevelError
+	}
+
+	return loggly.LogLevelFatal
+}
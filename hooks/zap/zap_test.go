@@ -0,0 +1,87 @@
+package zap
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	loggly "github.com/morlockaerospace/loggly"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestCore(t *testing.T) {
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	lg := loggly.New("", loggly.WithEndpoint(server.URL), loggly.WithBulk(0, time.Hour), loggly.WithSynchronous())
+	defer lg.Close()
+
+	logger := zap.New(New(lg, zapcore.InfoLevel)).Named("billing").With(zap.String("service", "api"))
+
+	logger.Debug("This is below the core's level.")
+	logger.Warn("slow checkout", zap.Int("order", 7), zap.Duration("took", time.Second))
+	logger.Error("payment failed", zap.Error(errors.New("card declined")))
+
+	select {
+	case body := <-bodies:
+		t.Fatalf("expected entries buffered, got %q", body)
+	default:
+	}
+
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	body := <-bodies
+
+	for _, expected := range []string{`"level":"WARN"`, `"order":7`, `"service":"api"`, `"logger":"billing"`, `"error":"card declined"`} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %s in %q", expected, body)
+		}
+	}
+
+	if strings.Contains(body, "below the core") {
+		t.Errorf("unexpected entry in %q", body)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	cases := map[zapcore.Level]loggly.Level{
+		zapcore.DebugLevel:  loggly.LogLevelDebug,
+		zapcore.InfoLevel:   loggly.LogLevelInfo,
+		zapcore.WarnLevel:   loggly.LogLevelWarn,
+		zapcore.ErrorLevel:  loggly.LogLevelError,
+		zapcore.DPanicLevel: loggly.LogLevelError,
+		zapcore.PanicLevel:  loggly.LogLevelFatal,
+		zapcore.FatalLevel:  loggly.LogLevelFatal,
+	}
+
+	for level, expected := range cases {
+		if got := Level(level); got != expected {
+			t.Errorf("Level(%s): got %s, want %s", level, got, expected)
+		}
+	}
+}
+
+func TestLevelMapping(t *testing.T) {
+	defer loggly.ResetLevelMapping()
+
+	loggly.SetLevelMapping(map[string]loggly.Level{"dpanic": loggly.LogLevelFatal, "warn": loggly.LogLevelError})
+
+	if got := Level(zapcore.DPanicLevel); got != loggly.LogLevelFatal {
+		t.Errorf("expected the mapping to apply to dpanic, got %s", got)
+	}
+
+	if got := Level(zapcore.WarnLevel); got != loggly.LogLevelError {
+		t.Errorf("expected the mapping to apply to warn, got %s", got)
+	}
+}
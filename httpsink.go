@@ -0,0 +1,159 @@
+//go:build !loggly_minimal
+// +build !loggly_minimal
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPSink posts each event as a line of JSON to a generic HTTP endpoint, such
+// as an internal ingestion gateway.
+type HTTPSink struct {
+	// URL is the endpoint events are posted to.
+	URL string
+
+	// Client sends the requests, http.DefaultClient if nil.
+	Client *http.Client
+
+	// Header is added to every request.
+	Header http.Header
+
+	// RequestHook, if set, runs on every request after Header is applied.
+	RequestHook RequestHook
+
+	// Timeout bounds each request, 10 seconds if zero.
+	Timeout time.Duration
+
+	// Codec, if set, compresses request bodies.
+	Codec Codec
+}
+
+// NewHTTPSink creates a sink posting to url.
+func NewHTTPSink(url string) *HTTPSink {
+	return &HTTPSink{URL: url, Header: http.Header{}}
+}
+
+// Write posts a single event.
+func (s *HTTPSink) Write(e Event) error {
+	b, err := json.Marshal(e)
+
+	if err != nil {
+		return err
+	}
+
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	body := append(b, '\n')
+
+	if s.Codec != nil {
+		if body, err = s.Codec.Compress(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	if s.Codec != nil {
+		req.Header.Set("Content-Encoding", s.Codec.Encoding())
+	}
+
+	for key, values := range s.Header {
+		req.Header[key] = values
+	}
+
+	if s.RequestHook != nil {
+		if err := s.RequestHook(req); err != nil {
+			return err
+		}
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newResponseError(resp, nil)
+	}
+
+	return nil
+}
+
+func init() {
+	RegisterSink("http", newConfiguredHTTPSink)
+}
+
+// newConfiguredHTTPSink builds an HTTPSink from the "url", "header",
+// "timeout" and "codec" options.
+func newConfiguredHTTPSink(options map[string]interface{}) (Sink, error) {
+	var config struct {
+		URL     string                 `json:"url"`
+		Header  map[string]string      `json:"header"`
+		Timeout string                 `json:"timeout"`
+		Codec   string                 `json:"codec"`
+		Options map[string]interface{} `json:"codec_options"`
+	}
+
+	if err := decodeOptions(options, &config); err != nil {
+		return nil, err
+	}
+
+	if config.URL == "" {
+		return nil, fmt.Errorf("http sink requires a url")
+	}
+
+	sink := NewHTTPSink(config.URL)
+
+	for key, value := range config.Header {
+		sink.Header.Set(key, value)
+	}
+
+	if config.Timeout != "" {
+		timeout, err := time.ParseDuration(config.Timeout)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %s", err)
+		}
+
+		sink.Timeout = timeout
+	}
+
+	if config.Codec != "" {
+		codec, err := NewCodec(config.Codec, config.Options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sink.Codec = codec
+	}
+
+	return sink, nil
+}
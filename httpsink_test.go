@@ -0,0 +1,108 @@
+//go:build !loggly_minimal
+// +build !loggly_minimal
+
+package log
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signRequest(secret []byte) RequestHook {
+	return func(req *http.Request) error {
+		body, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+
+		b, _ := ioutil.ReadAll(body)
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(b)
+		req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+		return nil
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	secret := []byte("gateway secret")
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan string, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		requests <- r
+		bodies <- string(b)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL)
+	sink.Header.Set("X-Tenant", "flight-test")
+	sink.RequestHook = signRequest(secret)
+
+	if err := sink.Write(Event{Time: time.Now(), Level: LogLevelWarn, Message: "This is a warning."}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, body := <-requests, <-bodies
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(body))
+
+	if r.Header.Get("X-Signature") != hex.EncodeToString(mac.Sum(nil)) {
+		t.Error("expected the request to be signed")
+	}
+
+	if r.Header.Get("X-Tenant") != "flight-test" || !strings.Contains(body, `"message":"This is a warning."`) {
+		t.Errorf("unexpected request %v: %q", r.Header, body)
+	}
+}
+
+func TestHTTPSinkErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL)
+
+	if err := sink.Write(Event{Message: "This is rejected."}); err == nil {
+		t.Error("expected an error for an unauthorized response")
+	}
+
+	sink.RequestHook = func(*http.Request) error { return errors.New("no credentials") }
+
+	if err := sink.Write(Event{Message: "This is never sent."}); err == nil || err.Error() != "no credentials" {
+		t.Errorf("expected the hook error, got %v", err)
+	}
+}
+
+func TestConfiguredHTTPSink(t *testing.T) {
+	sink, err := NewSink("http", map[string]interface{}{
+		"url":     "http://gateway.example.com/events",
+		"header":  map[string]interface{}{"X-Source": "nav"},
+		"timeout": "2s",
+		"codec":   "gzip",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := sink.(*HTTPSink)
+
+	if s.Header.Get("X-Source") != "nav" || s.Timeout != 2*time.Second || s.Codec == nil {
+		t.Errorf("unexpected sink %+v", s)
+	}
+
+	if _, err := NewSink("http", map[string]interface{}{"url": "http://gateway.example.com", "timeout": "soon"}); err == nil {
+		t.Error("expected an invalid timeout to be refused")
+	}
+}
@@ -0,0 +1,244 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LineParser parses a line of a log file into an event for IngestFile. An
+// event with a zero Time is stamped with the time it is ingested. Returning
+// ErrSkipLine leaves the line out.
+type LineParser func(line string) (Event, error)
+
+// ErrSkipLine is returned by a LineParser for lines that hold no event.
+var ErrSkipLine = errors.New("skip line")
+
+// maxIngestLine bounds the length of a line IngestFile reads.
+const maxIngestLine = 1024 * 1024
+
+// IngestFile ships the events of an existing log file, one per line parsed
+// by parser, with their original timestamps, to backfill history. Events
+// take the same path as logged ones, joining the bulk buffer in bulk mode,
+// without being printed. It returns once they have shipped with the number
+// of events read, stopping at the first line parser fails on.
+func IngestFile(path string, parser LineParser) (int, error) {
+	return loggerSingleton.ingestFile(path, parser)
+}
+
+func (l *logger) ingestFile(path string, parser LineParser) (int, error) {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxIngestLine)
+
+	n := 0
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimRight(scanner.Text(), "\r")
+
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		event, err := parser(text)
+
+		if err == ErrSkipLine {
+			continue
+		}
+
+		if err != nil {
+			l.flushAll(context.Background())
+			return n, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+
+		l.ingest(event)
+		n++
+	}
+
+	if err := scanner.Err(); err != nil {
+		l.flushAll(context.Background())
+		return n, fmt.Errorf("%s:%d: %v", path, line+1, err)
+	}
+
+	return n, l.flushAll(context.Background())
+}
+
+// Ingest logs an event read from elsewhere, such as a line parsed from
+// another program's log file, with its time and fields, without printing it.
+func Ingest(event Event) {
+	loggerSingleton.ingest(event)
+}
+
+// Ingest logs an event read from elsewhere, see the package level Ingest.
+func (lg *Logger) Ingest(event Event) {
+	lg.logger().ingest(event)
+}
+
+func (l *logger) ingest(event Event) {
+	// Errors from the past mustn't trip SetPanicOnError now.
+	l.log(record{output: event.Message, level: event.Level, data: NoPanic(event.Metadata), fields: event.Fields, time: event.Time, printed: true})
+}
+
+// ParsePlainLines parses each line as the message of an Info event.
+func ParsePlainLines(line string) (Event, error) {
+	return Event{Level: LogLevelInfo, Message: line}, nil
+}
+
+// JSON keys ParseJSONLines takes the time, level and message from, in order
+// of preference.
+var (
+	jsonTimeKeys    = []string{"timestamp", "time", "ts", "@timestamp"}
+	jsonLevelKeys   = []string{"level", "severity", "lvl"}
+	jsonMessageKeys = []string{"message", "msg"}
+)
+
+// ParseJSONLines parses lines holding a JSON object each. The time, level
+// and message are taken from their usual keys, such as "timestamp", "level"
+// and "message" or "ts", "lvl" and "msg", and the other keys become the
+// metadata. Times are RFC 3339 strings or Unix seconds.
+func ParseJSONLines(line string) (Event, error) {
+	var fields map[string]interface{}
+
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&fields); err != nil {
+		return Event{}, err
+	}
+
+	event := Event{Level: LogLevelInfo}
+
+	if value, ok := takeKey(fields, jsonTimeKeys); ok {
+		t, err := parseJSONTime(value)
+
+		if err != nil {
+			return Event{}, err
+		}
+
+		event.Time = t
+	}
+
+	if value, ok := takeKey(fields, jsonLevelKeys); ok {
+		event.Level = MapLevel(fmt.Sprint(value))
+	}
+
+	if value, ok := takeKey(fields, jsonMessageKeys); ok {
+		event.Message = fmt.Sprint(value)
+	}
+
+	// Events shipped by this package nest their data under metadata already.
+	if metadata, ok := fields["metadata"]; ok && len(fields) == 1 {
+		event.Metadata = metadata
+	} else if len(fields) > 0 {
+		event.Metadata = fields
+	}
+
+	return event, nil
+}
+
+// takeKey removes and returns the first of keys present in fields.
+func takeKey(fields map[string]interface{}, keys []string) (interface{}, bool) {
+	for _, key := range keys {
+		if value, ok := fields[key]; ok {
+			delete(fields, key)
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+func parseJSONTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case string:
+		return time.Parse(time.RFC3339Nano, v)
+	case json.Number:
+		seconds, err := v.Float64()
+
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return time.Unix(0, int64(seconds*float64(time.Second))), nil
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported time %v", value)
+}
+
+// commonLogPattern matches the Common Log Format, and the Combined Log
+// Format's referer and user agent when present.
+var commonLogPattern = regexp.MustCompile(`^(\S+) (\S+) (\S+) \[([^\]]+)\] "([^"]*)" (\d{3}) (\d+|-)(?: "([^"]*)" "([^"]*)")?`)
+
+// commonLogTime is the layout of Common Log Format timestamps.
+const commonLogTime = "02/Jan/2006:15:04:05 -0700"
+
+// ParseCommonLog parses web server access logs in the Common or Combined
+// Log Format. Responses are logged at Error level for 5xx statuses, Warn for
+// 4xx and Info otherwise, with the request line as the message.
+func ParseCommonLog(line string) (Event, error) {
+	match := commonLogPattern.FindStringSubmatch(line)
+
+	if match == nil {
+		return Event{}, fmt.Errorf("not in common log format")
+	}
+
+	t, err := time.Parse(commonLogTime, match[4])
+
+	if err != nil {
+		return Event{}, err
+	}
+
+	status, _ := strconv.Atoi(match[6])
+
+	metadata := map[string]interface{}{
+		"remote_addr": match[1],
+		"status":      status,
+	}
+
+	if match[3] != "-" {
+		metadata["user"] = match[3]
+	}
+
+	if bytes, err := strconv.Atoi(match[7]); err == nil {
+		metadata["bytes"] = bytes
+	}
+
+	if parts := strings.SplitN(match[5], " ", 3); len(parts) == 3 {
+		metadata["method"], metadata["path"], metadata["protocol"] = parts[0], parts[1], parts[2]
+	}
+
+	if match[8] != "" && match[8] != "-" {
+		metadata["referer"] = match[8]
+	}
+
+	if match[9] != "" && match[9] != "-" {
+		metadata["user_agent"] = match[9]
+	}
+
+	level := LogLevelInfo
+
+	switch {
+	case status >= 500:
+		level = LogLevelError
+	case status >= 400:
+		level = LogLevelWarn
+	}
+
+	return Event{Time: t, Level: level, Message: match[5], Metadata: metadata}, nil
+}
@@ -0,0 +1,86 @@
+package log
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseJSONLines(t *testing.T) {
+	event, err := ParseJSONLines(`{"ts": 1577836800.5, "lvl": "warn", "msg": "disk low", "free": 12}`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !event.Time.Equal(time.Unix(1577836800, 5e8)) || event.Level != LogLevelWarn || event.Message != "disk low" {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	if metadata := event.Metadata.(map[string]interface{}); len(metadata) != 1 || metadata["free"].(interface{ String() string }).String() != "12" {
+		t.Errorf("unexpected metadata %+v", event.Metadata)
+	}
+
+	if _, err := ParseJSONLines("not json"); err == nil {
+		t.Error("expected an error for a line that isn't JSON")
+	}
+}
+
+func TestParseCommonLog(t *testing.T) {
+	event, err := ParseCommonLog(`10.0.0.1 - ada [10/Oct/2020:13:55:36 -0700] "GET /checkout HTTP/1.1" 503 2326 "https://example.com/" "curl/7.68"`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"remote_addr": "10.0.0.1",
+		"user":        "ada",
+		"status":      503,
+		"bytes":       2326,
+		"method":      "GET",
+		"path":        "/checkout",
+		"protocol":    "HTTP/1.1",
+		"referer":     "https://example.com/",
+		"user_agent":  "curl/7.68",
+	}
+
+	if event.Level != LogLevelError || event.Message != "GET /checkout HTTP/1.1" || !reflect.DeepEqual(event.Metadata, expected) {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	if event.Time.UTC() != time.Date(2020, 10, 10, 20, 55, 36, 0, time.UTC) {
+		t.Errorf("unexpected time %s", event.Time)
+	}
+}
+
+func TestIngestFile(t *testing.T) {
+	path := filepath.Join(tempSpoolDir(t), "app.log")
+	lines := "{\"time\":\"2019-06-01T10:00:00Z\",\"level\":\"info\",\"message\":\"started\"}\n\n{\"time\":\"2019-06-01T10:00:05Z\",\"level\":\"error\",\"message\":\"crashed\"}\n"
+
+	if err := ioutil.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, bodies := newTestLogger(t, true)
+	l.panicOnError = true
+
+	n, err := l.ingestFile(path, ParseJSONLines)
+
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 events ingested, got %d, %v", n, err)
+	}
+
+	body := receive(t, bodies)
+
+	if !strings.Contains(body, `"timestamp":"2019-06-01T10:00:00Z"`) || !strings.Contains(body, `"timestamp":"2019-06-01T10:00:05Z","level":"ERROR"`) {
+		t.Errorf("expected the original timestamps, got %q", body)
+	}
+
+	if _, err := l.ingestFile(path, ParseCommonLog); err == nil || !strings.Contains(err.Error(), "app.log:1:") {
+		t.Errorf("expected the failing line reported, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// firstCustomLevel is the value of the first level added with RegisterLevel,
+// clear of the built-in levels so their values keep their meaning.
+const firstCustomLevel Level = 100
+
+// customLevel is a level added with RegisterLevel.
+type customLevel struct {
+	name string
+	rank float64
+}
+
+var (
+	levelMappingMu sync.RWMutex
+
+	// customLevels holds the levels added with RegisterLevel.
+	customLevels = map[Level]customLevel{}
+
+	// levelMapping maps lower-cased level names used by bridged loggers
+	// (go-kit, slog, logrus, syslog) onto this package's levels.
+	levelMapping = defaultLevelMapping()
+)
+
+func defaultLevelMapping() map[string]Level {
+	return map[string]Level{
+		"trace":       LogLevelTrace,
+		"debug":       LogLevelDebug,
+		"info":        LogLevelInfo,
+		"information": LogLevelInfo,
+		"notice":      LogLevelInfo,
+		"warn":        LogLevelWarn,
+		"warning":     LogLevelWarn,
+		"error":       LogLevelError,
+		"err":         LogLevelError,
+		"fatal":       LogLevelFatal,
+		"panic":       LogLevelFatal,
+		"crit":        LogLevelFatal,
+		"critical":    LogLevelFatal,
+		"alert":       LogLevelFatal,
+		"emerg":       LogLevelFatal,
+		"emergency":   LogLevelFatal,
+	}
+}
+
+// rank returns the level's place in the order levels are filtered by: the
+// value of built-in and unregistered levels, and the rank custom levels were
+// registered with.
+func (l Level) rank() float64 {
+	if l >= firstCustomLevel {
+		levelMappingMu.RLock()
+		custom, ok := customLevels[l]
+		levelMappingMu.RUnlock()
+
+		if ok {
+			return custom.rank
+		}
+	}
+
+	return float64(l)
+}
+
+// AtLeast reports whether the level is at least as severe as min, ordering
+// custom levels by their rank.
+func (l Level) AtLeast(min Level) bool {
+	return l.rank() >= min.rank()
+}
+
+// Severity returns the syslog severity matching the level, from 2 (critical)
+// for Fatal to 7 (debug) for Debug and Trace. Custom levels take the severity
+// of the built-in level ranked below them, except that levels between Info
+// and Warn are 5 (notice).
+func (l Level) Severity() int {
+	rank := l.rank()
+
+	switch {
+	case rank >= LogLevelFatal.rank():
+		return 2
+	case rank >= LogLevelError.rank():
+		return 3
+	case rank >= LogLevelWarn.rank():
+		return 4
+	case rank > LogLevelInfo.rank():
+		return 5
+	case rank >= LogLevelInfo.rank():
+		return 6
+	}
+
+	return 7
+}
+
+// SetLevelMapping overrides how level names from bridged loggers map onto
+// this package's levels, e.g. {"notice": LogLevelWarn}. Names are matched
+// case-insensitively and entries not overridden keep their default mapping.
+// The table is shared by every adapter.
+func SetLevelMapping(mapping map[string]Level) {
+	levelMappingMu.Lock()
+	defer levelMappingMu.Unlock()
+
+	for name, level := range mapping {
+		levelMapping[strings.ToLower(name)] = level
+	}
+}
+
+// RegisterLevel adds a custom named level and returns it. The level is
+// ordered by rank among the built-in levels, which rank as their values, e.g.
+// NOTICE between Info and Warn with RegisterLevel("NOTICE", 1.5). It is
+// filtered by its rank, printed and shipped under its name, and can be parsed
+// by ParseLevel. Registering a name again with the same rank returns the same
+// level. Log at custom levels with Logln, Logf and Logd.
+func RegisterLevel(name string, rank float64) (Level, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+
+	if name == "" {
+		return 0, fmt.Errorf("custom level of rank %g needs a name", rank)
+	}
+
+	for _, level := range []Level{LogLevelTrace, LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError, LogLevelFatal} {
+		if name == level.String() || rank == level.rank() {
+			return 0, fmt.Errorf("custom level %s of rank %g clashes with %s", name, rank, level)
+		}
+	}
+
+	levelMappingMu.Lock()
+	defer levelMappingMu.Unlock()
+
+	for level, custom := range customLevels {
+		switch {
+		case custom.name == name && custom.rank == rank:
+			return level, nil
+		case custom.name == name:
+			return 0, fmt.Errorf("level %s is already registered with rank %g", name, custom.rank)
+		case custom.rank == rank:
+			return 0, fmt.Errorf("rank %g is already registered as %s", rank, custom.name)
+		}
+	}
+
+	level := firstCustomLevel + Level(len(customLevels))
+
+	customLevels[level] = customLevel{name: name, rank: rank}
+	levelMapping[strings.ToLower(name)] = level
+
+	return level, nil
+}
+
+func customLevelName(level Level) (string, bool) {
+	levelMappingMu.RLock()
+	defer levelMappingMu.RUnlock()
+
+	custom, ok := customLevels[level]
+
+	return custom.name, ok
+}
+
+// customLevelRanked returns the custom level registered with rank.
+func customLevelRanked(rank float64) (Level, bool) {
+	levelMappingMu.RLock()
+	defer levelMappingMu.RUnlock()
+
+	for level, custom := range customLevels {
+		if custom.rank == rank {
+			return level, true
+		}
+	}
+
+	return 0, false
+}
+
+// ResetLevelMapping restores the default level name mapping, keeping custom
+// levels registered with RegisterLevel.
+func ResetLevelMapping() {
+	levelMappingMu.Lock()
+	defer levelMappingMu.Unlock()
+
+	levelMapping = defaultLevelMapping()
+
+	for level, custom := range customLevels {
+		levelMapping[strings.ToLower(custom.name)] = level
+	}
+}
+
+// ParseLevel returns the level mapped to name.
+func ParseLevel(name string) (Level, error) {
+	levelMappingMu.RLock()
+	defer levelMappingMu.RUnlock()
+
+	if level, ok := levelMapping[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return level, nil
+	}
+
+	return LogLevelInfo, fmt.Errorf("unknown log level %q", name)
+}
+
+// MapLevel returns the level mapped to name, falling back to Info for names
+// that aren't mapped.
+func MapLevel(name string) Level {
+	level, _ := ParseLevel(name)
+
+	return level
+}
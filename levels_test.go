@@ -0,0 +1,122 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]Level{
+		"debug":   LogLevelDebug,
+		"TRACE":   LogLevelTrace,
+		"Warning": LogLevelWarn,
+		"panic":   LogLevelFatal,
+		" info ":  LogLevelInfo,
+	}
+
+	for name, want := range cases {
+		if got, err := ParseLevel(name); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %s, %v, want %s", name, got, err, want)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
+
+func TestSetLevelMapping(t *testing.T) {
+	defer ResetLevelMapping()
+
+	SetLevelMapping(map[string]Level{"Notice": LogLevelWarn, "verbose": LogLevelDebug})
+
+	if level := MapLevel("notice"); level != LogLevelWarn {
+		t.Errorf("expected notice to map to %s, got %s", LogLevelWarn, level)
+	}
+}
+
+func TestRegisterLevel(t *testing.T) {
+	notice, err := RegisterLevel("notice", 1.5)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audit, err := RegisterLevel("AUDIT", 3.5)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if notice.String() != "NOTICE" || audit.String() != "AUDIT" {
+		t.Errorf("unexpected names %s and %s", notice, audit)
+	}
+
+	if level, err := ParseLevel("Audit"); err != nil || level != audit {
+		t.Errorf("expected AUDIT to parse to %d, got %d, %v", audit, level, err)
+	}
+
+	if level, err := RegisterLevel("Notice", 1.5); err != nil || level != notice {
+		t.Errorf("expected registering NOTICE again to return %d, got %d, %v", notice, level, err)
+	}
+
+	if _, err := RegisterLevel("LOUD", 2); err == nil {
+		t.Error("expected an error when registering at the rank of a built-in level")
+	}
+
+	if _, err := RegisterLevel("WARN", 2.5); err == nil {
+		t.Error("expected an error when registering under the name of a built-in level")
+	}
+
+	if _, err := RegisterLevel("OTHER", 3.5); err == nil {
+		t.Error("expected an error when registering an existing rank under another name")
+	}
+
+	if _, err := RegisterLevel("AUDIT", 3.75); err == nil {
+		t.Error("expected an error when registering an existing name at another rank")
+	}
+
+	// The built-in levels keep their baseline values.
+	if LogLevelDebug != 0 || LogLevelInfo != 1 || LogLevelWarn != 2 || LogLevelError != 3 || LogLevelFatal != 4 {
+		t.Error("expected the built-in levels to keep their values")
+	}
+
+	if !audit.AtLeast(LogLevelError) || audit.AtLeast(LogLevelFatal) || notice.AtLeast(LogLevelWarn) {
+		t.Error("expected custom levels to be ordered by rank")
+	}
+
+	l, bodies := newTestLogger(t, true)
+	l.Level = notice
+
+	l.buildAndShipMessage("This is filtered.", LogLevelInfo, false, nil)
+	l.buildAndShipMessage("This is an audit record.", audit, false, nil)
+	l.buildAndShipMessage("This is a warning.", LogLevelWarn, false, nil)
+	l.flush()
+
+	body := receive(t, bodies)
+	if strings.Contains(body, "filtered") || !strings.Contains(body, `"level":"AUDIT"`) || !strings.Contains(body, "This is a warning.") {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	notice, _ := RegisterLevel("NOTICE", 1.5)
+	audit, _ := RegisterLevel("AUDIT", 3.5)
+
+	cases := map[Level]int{
+		LogLevelTrace: 7,
+		LogLevelDebug: 7,
+		LogLevelInfo:  6,
+		notice:        5,
+		LogLevelWarn:  4,
+		LogLevelError: 3,
+		audit:         3,
+		LogLevelFatal: 2,
+	}
+
+	for level, want := range cases {
+		if got := level.Severity(); got != want {
+			t.Errorf("%s: expected severity %d, got %d", level, want, got)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package log
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"time"
+)
+
+// EnableLifecycleEvents ships a "service.start" event now, carrying version,
+// host and a summary of the logger configuration, and arranges for a
+// "service.stop" event with the uptime and exit reason on Shutdown or Fatal.
+func EnableLifecycleEvents(version string) {
+	loggerSingleton.enableLifecycleEvents(version)
+}
+
+// shutdownTimeout bounds how long Shutdown, and Fatal before exiting, wait
+// for buffered and in flight events to ship.
+const shutdownTimeout = 5 * time.Second
+
+// Shutdown ships the "service.stop" event, when lifecycle events are enabled,
+// flushes the bulk buffer, waiting up to 5 seconds for it and the requests in
+// flight to ship, and closes every subscription. reason describes why the
+// service is stopping.
+func Shutdown(reason string) {
+	loggerSingleton.shutdown(reason)
+}
+
+func (l *logger) enableLifecycleEvents(version string) {
+	l.Lock()
+	l.lifecycle = true
+	l.version = version
+	l.Unlock()
+
+	host, _ := os.Hostname()
+	config := l.configSummary()
+
+	l.buildAndShipMessage("service.start", LogLevelInfo, false, map[string]interface{}{
+		"event":       "service.start",
+		"version":     version,
+		"host":        host,
+		"pid":         os.Getpid(),
+		"go_version":  runtime.Version(),
+		"config":      config,
+		"fingerprint": fingerprintConfig(config),
+	})
+}
+
+func (l *logger) shutdown(reason string) {
+	l.Lock()
+	lifecycle := l.lifecycle
+	version := l.version
+	l.lifecycle = false
+	l.Unlock()
+
+	if lifecycle {
+		l.buildAndShipMessage("service.stop", LogLevelInfo, false, map[string]interface{}{
+			"event":          "service.stop",
+			"version":        version,
+			"reason":         reason,
+			"uptime_seconds": l.uptime().Seconds(),
+		})
+	}
+
+	// Fatal exits as soon as this returns, taking unshipped events with it.
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	l.flushAll(ctx)
+	l.closeSubscribers()
+}
+
+// uptime returns how long the logger has been running.
+func (l *logger) uptime() time.Duration {
+	return l.now().Sub(l.started)
+}
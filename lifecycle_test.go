@@ -0,0 +1,76 @@
+package log
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLifecycleEvents(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.clock = clock
+	l.started = clock.Now()
+
+	l.enableLifecycleEvents("1.2.3")
+	clock.Advance(90 * time.Second)
+	l.shutdown("deploy")
+
+	body := receive(t, bodies)
+
+	for _, want := range []string{`"message":"service.start"`, `"version":"1.2.3"`, `"buffer_size":1000`, `"message":"service.stop"`, `"reason":"deploy"`, `"uptime_seconds":90`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in %q", want, body)
+		}
+	}
+
+	// A second shutdown doesn't report another stop.
+	l.shutdown("again")
+	l.flush()
+
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected request %q", body)
+	default:
+	}
+}
+
+func TestLifecycleStopOnFatal(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+	code := stubExit(t)
+
+	l.lifecycle = true
+	l.buildAndShipMessage("This is fatal.", LogLevelFatal, true, nil)
+
+	if body := receive(t, bodies); !strings.Contains(body, `"reason":"fatal: This is fatal."`) || *code != 1 {
+		t.Errorf("expected a stop event before exiting, got %q", body)
+	}
+}
+
+func TestFatalWaitsForInFlightRequests(t *testing.T) {
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	l.url = server.URL
+
+	shipped := -1
+	osExit = func(int) { shipped = len(bodies) }
+	t.Cleanup(func() { osExit = os.Exit })
+
+	l.buildAndShipMessage("This is fatal.", LogLevelFatal, true, nil)
+
+	if shipped != 1 {
+		t.Errorf("expected the fatal event shipped before exiting, %d were", shipped)
+	}
+}
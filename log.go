@@ -2,21 +2,29 @@ package log
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
 )
 
-var loggerSingleton *logger
+var loggerSingleton = newDefaultLogger()
 
 // Level defined the type for a log level.
 type Level int
 
 const (
+	// LogLevelTrace trace log level, for wire-level logging that is normally
+	// filtered out.
+	LogLevelTrace Level = -1
+
 	// LogLevelDebug debug log level.
 	LogLevelDebug Level = 0
 
@@ -33,80 +41,277 @@ const (
 	LogLevelFatal Level = 4
 )
 
+// String returns the name shipped in the level field of a log message.
+func (l Level) String() string {
+	switch l {
+	case LogLevelTrace:
+		return "TRACE"
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	case LogLevelFatal:
+		return "FATAL"
+	}
+
+	if name, ok := customLevelName(l); ok {
+		return name
+	}
+
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
 type logger struct {
 	token         string
 	Level         Level
 	url           string
+	endpoint      string
 	bulk          bool
 	bufferSize    int
 	flushInterval time.Duration
 	buffer        []*logMessage
 	sync.Mutex
-	tags      []string
-	debugMode bool
+	tags           []string
+	debugMode      bool
+	clock          Clock
+	synchronous    bool
+	stop           chan struct{}
+	client         *http.Client
+	blocking       bool
+	blockLevel     Level
+	blockTimeout   time.Duration
+	onShipped      []func(BatchInfo)
+	onFailed       []func(BatchInfo, error)
+	stats          *stats
+	adaptive       *adaptive
+	errorStorm     *errorStorm
+	panicOnError   bool
+	captureStdout  bool
+	sinks          []namedSink
+	fieldFilters   map[string]FieldFilter
+	filters        []*compiledRule
+	filterSequence int
+	budgets        map[string]*budgetState
+	encoding       encodeOptions
+	started        time.Time
+	lifecycle      bool
+	version        string
+
+	operationStarts  bool
+	fields           map[string]interface{}
+	shippingDisabled bool
+	payloadInspector func(body []byte, events int)
+	transport        transportOptions
+	relay            *relay
+	requestHook      RequestHook
+	spool            *spool
+	spoolSync        SpoolSync
+	spoolOnly        bool
+	schema           *schema
+	routes           map[string]route
+	volumeConfig     VolumeConfig
+	talkers          *topTalkers
+	recent           *eventRing
+	recorder         *flightRecorder
+	boost            *verbosityBoost
+	fingerprints     bool
+	catalog          map[string]CatalogEntry
+	compliance       *Compliance
+	skew             *clockSkew
+	uptimeField      bool
+	store            payloadStore
+	storeReplaying   bool
+	tokenErr         error
+	retry            Retry
+	circuit          circuit
+	oversized        OversizedEvents
+	offload          Offload
+	sensitive        SensitiveFields
+	subjects         SensitiveFields
+	classification   ClassificationPolicy
+	customClient     *http.Client
+	requestTimeout   time.Duration
+	sampleKeys       []string
+	tail             *TailSampling
+	archive          *archive
+	inflight         inflight
+	closed           bool
+	early            *earlyBuffer
+	earlyLimit       int
+	console          []ConsoleWriter
+	consoleMu        sync.Mutex
+	subscribers      []subscriber
+	alerts           []*alertState
+	anomalies        *anomalyHints
 }
 
 type logMessage struct {
 	Timestamp string      `json:"timestamp"`
 	Level     string      `json:"level"`
+	Severity  int         `json:"severity"`
 	Message   string      `json:"message"`
 	Metadata  interface{} `json:"metadata"`
+
+	// Fields are merged into the top level of the JSON object.
+	Fields map[string]interface{} `json:"-"`
+
+	// ack receives the delivery result when the caller asked for one.
+	ack chan error
+
+	// queued is when the message entered the bulk buffer.
+	queued time.Time
+
+	// url and tags override the input the message ships to, see
+	// SetRetentionRoute.
+	url  string
+	tags []string
+
+	// component and size are accounted in the volume statistics, size once
+	// the message is marshalled.
+	component string
+	size      int
+
+	// truncated marks a message already cut short for being too large.
+	truncated bool
+}
+
+// reservedKeys are the top level keys of a shipped message. Fields using them
+// are shipped as "field.<key>" instead.
+var reservedKeys = map[string]bool{"timestamp": true, "level": true, "severity": true, "message": true, "metadata": true}
+
+// MarshalJSON encodes the message with its fields merged into the top level.
+func (m *logMessage) MarshalJSON() ([]byte, error) {
+	type wire logMessage
+
+	b, err := json.Marshal((*wire)(m))
+
+	if err != nil || len(m.Fields) == 0 {
+		return b, err
+	}
+
+	fields := make(map[string]interface{}, len(m.Fields))
+
+	for key, value := range m.Fields {
+		if reservedKeys[key] {
+			key = "field." + key
+		}
+
+		fields[key] = value
+	}
+
+	f, err := json.Marshal(fields)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append(b[:len(b)-1], ','), f[1:]...), nil
 }
 
-// SetupLogger creates a new loggly logger.
+// ErrFiltered is reported on a delivery channel when the message was below
+// the logger's level or dropped by a filter rule and therefore never shipped.
+var ErrFiltered = errors.New("log message was filtered")
+
+// osExit is swapped out by tests so Fatal calls can be exercised.
+var osExit = os.Exit
+
+// SetupLogger creates a new loggly logger, see Setup, which takes options
+// for the settings beyond these.
 func SetupLogger(token string, level Level, tags []string, bulk bool, debugMode bool) {
-	if loggerSingleton != nil {
+	opts := []Option{WithLevel(level), WithTags(tags...), WithDebugMode(debugMode)}
+
+	if bulk {
+		opts = append(opts, WithBulk(0, 0))
+	}
+
+	Setup(token, opts...)
+}
+
+// Setup creates the package logger shipping with token, configured by opts
+// over the defaults of Debug level, no tags and no bulk buffering. Until it
+// is called events are printed to the console and held, then shipped once it
+// is, or once Start is called if Hold was, see SetEarlyBuffer. Other settings
+// made before Setup don't carry over. An empty token disables shipping,
+// leaving the console and sinks.
+func Setup(token string, opts ...Option) {
+	// Once set up the logger may hold events again with Hold, only the
+	// default logger's buffer marks setup as still to come.
+	loggerSingleton.Lock()
+	pending := loggerSingleton.early != nil && loggerSingleton.early.untilSetup
+	loggerSingleton.Unlock()
+
+	if !pending {
 		return
 	}
 
-	// Setup logger with options.
-	loggerSingleton = &logger{
-		token:         token,
-		Level:         level,
-		url:           "",
-		bulk:          bulk,
-		bufferSize:    1000,
-		flushInterval: 10 * time.Second,
-		buffer:        nil,
-		tags:          tags,
-		debugMode:     debugMode,
-	}
-
-	// If the bulk option is set make sure we set the url to the bulk endpoint.
-	if bulk {
-		loggerSingleton.url = "https://logs-01.loggly.com/bulk/" + token + "/tag/" + tagList() + "/"
+	early := loggerSingleton
+	loggerSingleton = newConfiguredLogger(token, opts)
 
-		// Start flush interval
-		go start()
-	} else {
-		loggerSingleton.url = "https://logs-01.loggly.com/inputs/" + token + "/tag/" + tagList() + "/"
+	// Start flush interval
+	if loggerSingleton.bulk {
+		loggerSingleton.startFlushLoop()
 	}
 
+	loggerSingleton.adoptEarly(early.takeEarly())
+}
+
+// SetClock replaces the clock used for timestamps and the bulk flush interval.
+// It is intended for tests that need to drive the bulk pipeline
+// deterministically, see ManualClock.
+func SetClock(clock Clock) {
+	loggerSingleton.Lock()
+	loggerSingleton.clock = clock
+	loggerSingleton.Unlock()
+
+	if loggerSingleton.bulk {
+		loggerSingleton.startFlushLoop()
+	}
+}
+
+// SetSynchronous makes the logger ship on the calling goroutine instead of
+// spawning a goroutine per log call or flush.
+func SetSynchronous(synchronous bool) {
+	loggerSingleton.Lock()
+	loggerSingleton.synchronous = synchronous
+	loggerSingleton.Unlock()
+}
+
+// SetBlocking makes log calls at or above level wait until the message has
+// been shipped, or timeout has elapsed, before returning. This keeps CLIs and
+// cron jobs from exiting before their logs have been sent. In bulk mode the
+// whole buffer is flushed along with the message.
+func SetBlocking(level Level, timeout time.Duration) {
+	loggerSingleton.Lock()
+	loggerSingleton.blocking = true
+	loggerSingleton.blockLevel = level
+	loggerSingleton.blockTimeout = timeout
+	loggerSingleton.Unlock()
+}
+
+// ForceFlush ships the bulk buffer immediately and returns once the request
+// has completed.
+func ForceFlush() {
+	loggerSingleton.flush()
 }
 
 // Stdln prints the output.
 func Stdln(output string) {
 	fmt.Println(output)
+
+	loggerSingleton.captureStd(output)
 }
 
 // Stdf prints the formatted output.
 func Stdf(format string, a ...interface{}) {
-	fmt.Printf(format, a...)
-}
+	output := fmt.Sprintf(format, a...)
 
-// Debugln prints the output.
-func Debugln(output string) {
-	Debugd(output, nil)
-}
-
-// Debugd prints output string and data.
-func Debugd(output string, d interface{}) {
-	buildAndShipMessage(output, "DEBUG", false, d)
-}
+	fmt.Print(output)
 
-// Debugf prints the formatted output.
-func Debugf(format string, a ...interface{}) {
-	Debugln(fmt.Sprintf(format, a...))
+	loggerSingleton.captureStd(output)
 }
 
 // Infoln prints the output.
@@ -121,7 +326,7 @@ func Infof(format string, a ...interface{}) {
 
 // Infod prints output string and data.
 func Infod(output string, d interface{}) {
-	buildAndShipMessage(output, "INFO", false, d)
+	loggerSingleton.buildAndShipMessage(output, LogLevelInfo, false, d)
 }
 
 // Warnln prints the output.
@@ -136,7 +341,7 @@ func Warnf(format string, a ...interface{}) {
 
 // Warnd prints output string and data.
 func Warnd(output string, d interface{}) {
-	buildAndShipMessage(output, "WARN", false, d)
+	loggerSingleton.buildAndShipMessage(output, LogLevelWarn, false, d)
 }
 
 // Errorln prints the output.
@@ -151,7 +356,7 @@ func Errorf(format string, a ...interface{}) {
 
 // Errord prints output string and data.
 func Errord(output string, d interface{}) {
-	buildAndShipMessage(output, "ERROR", false, d)
+	loggerSingleton.buildAndShipMessage(output, LogLevelError, false, d)
 }
 
 // Fatalln prints the output.
@@ -166,43 +371,280 @@ func Fatalf(format string, a ...interface{}) {
 
 // Fatald prints output string and data.
 func Fatald(output string, d interface{}) {
-	buildAndShipMessage(output, "FATAL", true, d)
+	loggerSingleton.buildAndShipMessage(output, LogLevelFatal, true, d)
+
+}
+
+// Logln prints the output at level, which may be a custom level.
+func Logln(level Level, output string) {
+	Logd(level, output, nil)
+}
+
+// Logf prints the formatted output at level, which may be a custom level.
+func Logf(level Level, format string, a ...interface{}) {
+	Logln(level, fmt.Sprintf(format, a...))
+}
+
+// Logd prints output string and data at level, which may be a custom level.
+func Logd(level Level, output string, d interface{}) {
+	loggerSingleton.buildAndShipMessage(output, level, false, d)
+}
+
+// Deliverd ships output string and data at level and returns a channel that
+// receives nil once Loggly has accepted the message, or the error that
+// prevented delivery. In bulk mode the channel resolves when the buffer is
+// flushed.
+func Deliverd(level Level, output string, d interface{}) <-chan error {
+	ack := make(chan error, 1)
 
+	loggerSingleton.log(record{output: output, level: level, data: d, ack: ack})
+
+	return ack
 }
 
 // MARK: Private
 
-func buildAndShipMessage(output string, messageType string, exit bool, d interface{}) {
-	if loggerSingleton.Level > LogLevelDebug {
+func newLogger(token string, level Level, tags []string, bulk bool, debugMode bool) *logger {
+	// Setup logger with options.
+	l := &logger{
+		token:          token,
+		Level:          level,
+		url:            "",
+		bulk:           bulk,
+		bufferSize:     1000,
+		flushInterval:  10 * time.Second,
+		buffer:         nil,
+		tags:           tags,
+		debugMode:      debugMode,
+		clock:          systemClock{},
+		client:         http.DefaultClient,
+		requestTimeout: defaultRequestTimeout,
+		sampleKeys:     DefaultSampleKeys,
+		stats:          newStats(),
+		started:        time.Now(),
+	}
+
+	l.url = inputURL(bulk, token, tags)
+	l.tokenErr = validateToken(token)
+
+	return l
+}
+
+// logglyHost serves the Loggly input endpoints.
+const logglyHost = "logs-01.loggly.com"
+
+// ErrInvalidToken is returned when the customer token is malformed or Loggly
+// rejects it.
+var ErrInvalidToken = errors.New("loggly token is invalid")
+
+var tokenPattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// validateToken checks the token is shaped like a Loggly customer token,
+// which end up as a path segment of the endpoint.
+func validateToken(token string) error {
+	if !tokenPattern.MatchString(token) {
+		return fmt.Errorf("%w: %q is not a customer token", ErrInvalidToken, redactToken(token))
+	}
+
+	return nil
+}
+
+// inputURL builds the endpoint for token and tags. If the bulk option is set
+// it is the bulk endpoint. Tags are percent encoded, commas included as they
+// separate tags, and the tag segment is left out when there are none.
+func inputURL(bulk bool, token string, tags []string) string {
+	endpoint := "inputs"
+	if bulk {
+		endpoint = "bulk"
+	}
+
+	path := "/" + endpoint + "/" + token + "/"
+	rawPath := "/" + endpoint + "/" + url.PathEscape(token) + "/"
+
+	if len(tags) > 0 {
+		escaped := make([]string, len(tags))
+
+		for i, tag := range tags {
+			escaped[i] = strings.Replace(url.PathEscape(tag), ",", "%2C", -1)
+		}
+
+		path += "tag/" + strings.Join(tags, ",") + "/"
+		rawPath += "tag/" + strings.Join(escaped, ",") + "/"
+	}
+
+	u := url.URL{Scheme: "https", Host: logglyHost, Path: path, RawPath: rawPath}
+
+	return u.String()
+}
+
+func (l *logger) buildAndShipMessage(output string, level Level, exit bool, d interface{}) {
+	l.log(record{output: output, level: level, exit: exit, data: d})
+}
+
+// record holds a single log call on its way through the pipeline.
+type record struct {
+	output string
+	level  Level
+	exit   bool
+	data   interface{}
+
+	// ack receives the delivery result when it is not nil.
+	ack chan error
+
+	// printed is set when the caller already wrote the output to the console.
+	printed bool
+
+	// fields are shipped as top level keys of the message.
+	fields map[string]interface{}
+
+	// time, if set, is when the event was logged, for events replayed
+	// later.
+	time time.Time
+}
+
+func (l *logger) log(r record) {
+	if l.holdEarly(r) {
 		return
 	}
 
-	var formattedOutput string
+	output, level, ack := r.output, r.level, r.ack
+	d, noPanic := unwrapNoPanic(r.data)
+	r.fields = l.withComplianceFields(l.withLoggerFields(r.fields))
 
-	if d == nil {
-		// Format message.
-		formattedOutput = fmt.Sprintf("%v [%s] %s", time.Now().Format(time.RFC3339), messageType, output)
+	var permitted bool
+
+	if d, r.fields, permitted = l.classify(d, r.fields); !permitted {
+		if ack != nil {
+			ack <- ErrNotPermitted
+			close(ack)
+		}
+		return
+	}
+
+	l.remember(level, output, d, r.fields)
+
+	if !level.AtLeast(l.effectiveLevel(d, r.fields)) {
+		l.record(level, output, d, r.fields)
+
+		if ack != nil {
+			ack <- ErrFiltered
+			close(ack)
+		}
+		return
+	}
+
+	l.stats.observeMetrics(output, level, d, r.fields)
+	l.evaluateAlerts(output, level, d, r.fields)
+
+	if l.nonCompliant(output, level, d, r.fields) {
+		if ack != nil {
+			ack <- ErrNonCompliant
+			close(ack)
+		}
+		return
+	}
+
+	if l.filtered(output, level, d, r.fields) || l.overBudget(d, r.fields) {
+		if ack != nil {
+			ack <- ErrFiltered
+			close(ack)
+		}
+		return
+	}
+
+	l.countTalker(output, level)
+
+	if level.AtLeast(LogLevelError) {
+		r.fields = l.replayRecorder(r.fields)
+		l.boostComponent(d, r.fields)
+		r.fields = l.withFingerprint(output, r.fields)
+	}
+
+	timestamp := l.now()
+	if !r.time.IsZero() {
+		timestamp = r.time
+	}
+	timestamp, r.fields = l.correctSkew(timestamp, r.fields)
+	r.fields = l.withUptime(r.fields)
+	r.fields = l.withAnomalyScore(output, r.fields)
+
+	if !r.printed {
+		l.printConsole(Event{Time: timestamp, Level: level, Message: output, Metadata: d, Fields: r.fields})
+	}
+
+	// Console output keeps the caller's values, shipped values are encoded.
+	d, fields := l.shippedValues(d, r.fields)
+
+	event := Event{Time: timestamp, Level: level, Message: output, Metadata: d, Fields: fields}
+	l.writeSinks(event)
+	l.publish(event)
+
+	message := l.logglyMessage(event)
+	message.ack = ack
+
+	panicking := level == LogLevelError && !noPanic && l.isPanicOnError()
+
+	// Send message to loggly. Make sure it is out before panicking.
+	if panicking && !l.isShippingDisabled() {
+		l.shipBlocking(message, panicShipTimeout)
 	} else {
-		// Format message.
-		formattedOutput = fmt.Sprintf("%v [%s] %s %+v", time.Now().Format(time.RFC3339), messageType, output, d)
+		l.ship(message, level)
+	}
+
+	if level == LogLevelError || level == LogLevelFatal {
+		l.observeError()
 	}
 
-	fmt.Println(formattedOutput)
+	if panicking {
+		panic(errors.New(output))
+	}
+
+	if r.exit {
+		l.shutdown("fatal: " + output)
+		osExit(1)
+	}
+}
+
+// shippedValues encodes metadata and fields, migrates them to the schema and
+// protects their sensitive fields, for everything leaving the logger other
+// than the console.
+func (l *logger) shippedValues(d interface{}, fields map[string]interface{}) (interface{}, map[string]interface{}) {
+	options := l.encodeOptions()
+	d = encodeMetadata(d, options)
+	fields = encodeFields(fields, options)
+	l.migrate(d, fields)
+
+	return l.protectSensitive(d, fields)
+}
+
+// logglyMessage builds the message shipping an event with shipped values to
+// Loggly, applying the Loggly field filter, offload and retention routes.
+func (l *logger) logglyMessage(e Event) *logMessage {
+	d, fields := e.Metadata, e.Fields
 
-	message := newMessage(time.Now().Format(time.RFC3339), messageType, output, nil)
+	if filter, ok := l.fieldFilter(LogglySink); ok {
+		d = filter.apply(d)
+		fields = filter.applyFields(fields)
+	}
+
+	d = l.offloadMetadata(d)
 
-	// Send message to loggly.
-	ship(message)
+	message := newMessage(e.Time.Format(time.RFC3339), e.Level, e.Message, d)
+	message.Fields = fields
+	message.component, _ = componentOf(d, fields)
 
-	if exit {
-		os.Exit(1)
+	if r, ok := l.route(fields); ok {
+		message.url, message.tags = r.url, r.tags
 	}
+
+	return message
 }
 
-func newMessage(timestamp string, level string, message string, data ...interface{}) *logMessage {
+func newMessage(timestamp string, level Level, message string, data interface{}) *logMessage {
 	formatedMessage := &logMessage{
 		Timestamp: timestamp,
-		Level:     level,
+		Level:     level.String(),
+		Severity:  level.Severity(),
 		Message:   message,
 		Metadata:  data,
 	}
@@ -210,123 +652,336 @@ func newMessage(timestamp string, level string, message string, data ...interfac
 	return formatedMessage
 }
 
-func ship(message *logMessage) {
+func (l *logger) now() time.Time {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.clock.Now()
+}
+
+func (l *logger) isSynchronous() bool {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.synchronous
+}
+
+func (l *logger) ship(message *logMessage, level Level) {
+	if l.isShippingDisabled() {
+		message.resolve(ErrShippingDisabled)
+		return
+	}
+
+	if l.isClosed() {
+		message.resolve(ErrClosed)
+		return
+	}
+
+	// Blocking levels complete the send before the log call returns.
+	if timeout, ok := l.blockingTimeout(level); ok {
+		l.shipBlocking(message, timeout)
+		return
+	}
+
 	// If bulk is set to true then ship on interval else ship the single log event.
-	if loggerSingleton.bulk {
-		go handleBulkLogMessage(message)
+	handle := l.handleLogMessage
+	if l.bulk {
+		handle = l.handleBulkLogMessage
+	}
+
+	if l.isSynchronous() {
+		handle(message)
 	} else {
-		go handleLogMessage(message)
+		l.goShip(func() { handle(message) })
 	}
 }
 
-func handleLogMessage(message *logMessage) {
+// shipBlocking ships message, along with the bulk buffer in bulk mode, on the
+// calling goroutine.
+func (l *logger) shipBlocking(message *logMessage, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if l.bulk {
+		l.enqueue(message)
+		l.flushContext(ctx)
+	} else {
+		l.sendMessage(ctx, message)
+	}
+}
+
+func (l *logger) blockingTimeout(level Level) (time.Duration, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.blockTimeout, l.blocking && level.AtLeast(l.blockLevel)
+}
+
+func (l *logger) handleLogMessage(message *logMessage) {
+	l.sendMessage(context.Background(), message)
+}
+
+func (l *logger) sendMessage(ctx context.Context, message *logMessage) error {
 	requestBody, err := json.Marshal(message)
 
 	if err != nil {
 		fmt.Printf("There was an error marshalling log message: %s", err)
-		return
+		message.resolve(err)
+		return err
 	}
 
-	resp, err := http.Post(loggerSingleton.url, "text/plain", bytes.NewBuffer(requestBody))
-	
+	message.size = len(requestBody)
+
+	err = l.shipBatch(ctx, requestBody, []*logMessage{message})
+
 	if err != nil {
-		if loggerSingleton.debugMode {
+		if l.debugMode {
 			fmt.Printf("There was an error shipping the logs to loggy: %s", err)
 		}
-		return
+		return err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode == 403 {
-		if loggerSingleton.debugMode {
-			fmt.Println("Token is invalid", resp.Status)
-		}
 
+	if l.debugMode {
+		fmt.Println("Log was shipped successfully")
 	}
 
-	if resp.StatusCode == 200 {
-		if loggerSingleton.debugMode {
-			fmt.Println("Log was shipped successfully", resp.Status)
+	return nil
+}
+
+func (l *logger) handleBulkLogMessage(message *logMessage) {
+	count, bufferSize := l.enqueue(message)
+
+	// Send buffer to loggly if the buffer size has been met.
+	if count >= bufferSize {
+		if l.isSynchronous() {
+			l.flush()
+		} else {
+			l.goShip(l.flush)
 		}
 	}
 
 }
 
-func handleBulkLogMessage(message *logMessage) {
-	var count int
+// enqueue adds a message to the bulk buffer and returns the new buffer length
+// along with the current buffer size limit.
+func (l *logger) enqueue(message *logMessage) (int, int) {
+	message.queued = l.now()
 
 	// Lock buffer from outside manipulation.
-	loggerSingleton.Lock()
+	l.Lock()
+	defer l.Unlock()
+
+	l.buffer = append(l.buffer, message)
 
-	loggerSingleton.buffer = append(loggerSingleton.buffer, message)
+	return len(l.buffer), l.bufferSize
+}
 
-	count = len(loggerSingleton.buffer)
+func (l *logger) flush() {
+	l.flushContext(context.Background())
+}
 
-	// Unlock buffer from outside manipulation.
-	loggerSingleton.Unlock()
+func (l *logger) flushContext(ctx context.Context) error {
+	batches := l.formatBulkMessage()
 
-	// Send buffer to loggly if the buffer size has been met.
-	if count >= loggerSingleton.bufferSize {
-		go flush()
+	// Nothing was logged since the last flush.
+	if len(batches) == 0 {
+		return nil
 	}
 
-}
+	start := l.now()
+
+	var err error
+	var messages []*logMessage
 
-func flush() {
-	body := formatBulkMessage()
+	for _, batch := range batches {
+		if batchErr := l.shipBatch(ctx, []byte(batch.body), batch.messages); batchErr != nil && err == nil {
+			err = batchErr
+		}
 
-	loggerSingleton.buffer = nil
+		messages = append(messages, batch.messages...)
+	}
 
-	resp, err := http.Post(loggerSingleton.url, "text/plain", bytes.NewBuffer([]byte(body)))
+	l.adapt(messages, start)
 
-	if resp.StatusCode == 403 {
-		if loggerSingleton.debugMode {
-			fmt.Println("Token is invalid", resp.Status)
+	if err != nil {
+		if l.debugMode {
+			fmt.Printf("There was an error shipping the bulk logs to loggy: %s", err)
 		}
+		return err
+	}
+
+	if l.debugMode {
+		fmt.Println("Logs were shipped successfully")
+	}
+
+	return nil
+}
+
+// post sends a request body to the loggly endpoint at url.
+func (l *logger) post(ctx context.Context, url string, body []byte) error {
+	l.Lock()
+	relay := l.relay
+	l.Unlock()
+
+	if relay != nil {
+		return relay.send(ctx, body)
+	}
+
+	// A malformed token makes for an endpoint that can never succeed.
+	if l.tokenErr != nil {
+		return l.tokenErr
+	}
+
+	if err := l.circuitErr(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+
+	if err != nil {
+		return err
+	}
+
+	l.Lock()
+	client := l.client
+	hook := l.requestHook
+	timeout := l.requestTimeout
+	l.Unlock()
+
+	requestCtx := ctx
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		requestCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	if resp.StatusCode == 200 {
-		if loggerSingleton.debugMode {
-			fmt.Println("Logs were shipped successfully", resp.Status)
+	req = req.WithContext(requestCtx)
+	req.Header.Set("Content-Type", "text/plain")
+
+	if hook != nil {
+		if err := hook(req); err != nil {
+			return err
 		}
 	}
 
+	sent := l.now()
+	resp, err := client.Do(req)
+
 	if err != nil {
-		if loggerSingleton.debugMode {
-			fmt.Printf("There was an error shipping the bulk logs to loggy: %s", err)
+		// A request timing out is worth retrying while the caller's context
+		// still has time.
+		if ctx.Err() == nil && requestCtx.Err() != nil {
+			return fmt.Errorf("loggly request timed out after %s", timeout)
 		}
 
+		return err
 	}
 
 	defer resp.Body.Close()
+
+	l.observeDate(sent, l.now(), resp.Header.Get("Date"))
+
+	class := classifyStatus(resp.StatusCode)
+
+	switch class {
+	case statusAuth:
+		err = newResponseError(resp, ErrInvalidToken)
+	case statusRetry, statusSplit, statusReject:
+		err = newResponseError(resp, nil)
+	}
+
+	l.observeStatus(class, err)
+
+	return err
 }
 
-func start() {
+// startFlushLoop starts the flush interval, replacing any loop already running.
+// A closed logger keeps its loop stopped.
+func (l *logger) startFlushLoop() {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.closed {
+		return
+	}
+
+	if l.stop != nil {
+		close(l.stop)
+	}
+
+	l.stop = make(chan struct{})
+
+	go l.start(l.clock.NewTicker(l.flushInterval), l.stop)
+}
+
+func (l *logger) start(ticker Ticker, stop chan struct{}) {
+	defer ticker.Stop()
+
 	for {
-		time.Sleep(loggerSingleton.flushInterval)
-		go flush()
+		select {
+		case <-ticker.C():
+			if l.isSynchronous() {
+				l.flush()
+			} else {
+				l.goShip(l.flush)
+			}
+		case <-stop:
+			return
+		}
 	}
 }
 
-func tagList() string {
-	return strings.Join(loggerSingleton.tags, ",")
+// bulkBatch is newline delimited JSON bound for one endpoint along with the
+// messages it contains.
+type bulkBatch struct {
+	body     string
+	messages []*logMessage
 }
 
-func formatBulkMessage() string {
-	var output string
+// formatBulkMessage drains the buffer and returns it as a batch per endpoint,
+// in order of each endpoint's first message.
+func (l *logger) formatBulkMessage() []*bulkBatch {
+	var batches []*bulkBatch
+	byURL := map[string]*bulkBatch{}
 
-	loggerSingleton.Lock()
-	defer loggerSingleton.Unlock()
+	l.Lock()
+	buffer := l.buffer
+	l.buffer = nil
+	l.Unlock()
 
-	for _, m := range loggerSingleton.buffer {
+	for _, m := range buffer {
 		b, err := json.Marshal(m)
 
 		if err != nil {
 			fmt.Printf("There was an error marshalling buffer message: %s", err)
+			m.resolve(err)
 			continue
 		}
 
-		output += string(b) + "\n"
+		batch, ok := byURL[m.url]
+
+		if !ok {
+			batch = &bulkBatch{}
+			byURL[m.url] = batch
+			batches = append(batches, batch)
+		}
+
+		m.size = len(b) + 1
+		batch.body += string(b) + "\n"
+		batch.messages = append(batch.messages, m)
+	}
+
+	return batches
+}
+
+// resolve reports the delivery result to the caller waiting on the message.
+func (m *logMessage) resolve(err error) {
+	if m.ack == nil {
+		return
 	}
 
-	return output
+	m.ack <- err
+	close(m.ack)
+	m.ack = nil
 }
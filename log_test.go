@@ -1,10 +1,44 @@
 package log
 
 import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 	"time"
 )
 
+// newTestLogger returns a logger shipping to a local test server, along with
+// a channel receiving every request body the server gets.
+func newTestLogger(t *testing.T, bulk bool) (*logger, chan string) {
+	bodies := make(chan string, 100)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	t.Cleanup(server.Close)
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, bulk, false)
+	l.url = server.URL
+	l.synchronous = true
+
+	return l, bodies
+}
+
+func receive(t *testing.T, bodies chan string) string {
+	select {
+	case body := <-bodies:
+		return body
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for shipped logs")
+	}
+
+	return ""
+}
+
 func TestSetupSingleLogger(t *testing.T) {
 	SetupLogger("yourlogglytoken", 0, []string{"test"}, false, true)
 
@@ -40,6 +74,35 @@ func TestInfod(t *testing.T) {
 	time.Sleep(3 * time.Second)
 }
 
+func TestTraceln(t *testing.T) {
+	Traceln("This is a trace statement.")
+}
+
+func TestTracef(t *testing.T) {
+	Tracef("This is a trace statement %d.", 10000)
+}
+
+func TestTraceFilteredByDefault(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	l.buildAndShipMessage("This is a trace statement.", LogLevelTrace, false, nil)
+	l.flush()
+
+	select {
+	case body := <-bodies:
+		t.Errorf("expected trace to be filtered at the debug level, got %q", body)
+	default:
+	}
+
+	l.Level = LogLevelTrace
+	l.buildAndShipMessage("This is a trace statement.", LogLevelTrace, false, nil)
+	l.flush()
+
+	if body := receive(t, bodies); !strings.Contains(body, `"level":"TRACE"`) {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
 func TestDebugln(t *testing.T) {
 	Debugln("This is a debug statement.")
 }
@@ -73,9 +136,162 @@ func TestErrorf(t *testing.T) {
 }
 
 func TestFatalln(t *testing.T) {
+	code := stubExit(t)
+
 	Fatalln("This is an error.")
+
+	if *code != 1 {
+		t.Errorf("expected exit code 1, got %d", *code)
+	}
 }
 
 func TestFatalf(t *testing.T) {
+	code := stubExit(t)
+
 	Fatalf("This is an error %d.", 10000)
+
+	if *code != 1 {
+		t.Errorf("expected exit code 1, got %d", *code)
+	}
+}
+
+func TestSynchronousSingleShipping(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	l.buildAndShipMessage("This is a synchronous statement.", LogLevelInfo, false, nil)
+
+	if body := receive(t, bodies); !strings.Contains(body, "This is a synchronous statement.") {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestFlushDrainsBuffer(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	l.buildAndShipMessage("This is an info statement 1.", LogLevelInfo, false, nil)
+	l.buildAndShipMessage("This is an info statement 2.", LogLevelInfo, false, nil)
+	l.flush()
+
+	if lines := strings.Count(receive(t, bodies), "\n"); lines != 2 {
+		t.Errorf("expected 2 bulk lines, got %d", lines)
+	}
+
+	// An empty buffer should not produce a request.
+	l.flush()
+
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected request %q", body)
+	default:
+	}
+}
+
+func TestLevelFiltering(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+	l.Level = LogLevelWarn
+
+	l.buildAndShipMessage("This is filtered.", LogLevelInfo, false, nil)
+	l.buildAndShipMessage("This is a warning.", LogLevelWarn, false, nil)
+	l.flush()
+
+	if body := receive(t, bodies); strings.Contains(body, "filtered") || !strings.Contains(body, "warning") {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func stubExit(t *testing.T) *int {
+	code := new(int)
+
+	osExit = func(c int) { *code = c }
+	t.Cleanup(func() { osExit = os.Exit })
+
+	return code
+}
+
+func TestBlockingShipping(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+	l.synchronous = false
+	l.blocking = true
+	l.blockLevel = LogLevelError
+	l.blockTimeout = 5 * time.Second
+
+	l.buildAndShipMessage("This is an error.", LogLevelError, false, nil)
+
+	// The error must have been shipped by the time the call returned.
+	select {
+	case body := <-bodies:
+		if !strings.Contains(body, "This is an error.") {
+			t.Errorf("unexpected body %q", body)
+		}
+	default:
+		t.Fatal("blocking call returned before the log was shipped")
+	}
+}
+
+func TestBlockingTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * time.Second)
+	}))
+	defer server.Close()
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	l.url = server.URL
+	l.blocking = true
+	l.blockTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	l.buildAndShipMessage("This is slow.", LogLevelInfo, false, nil)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("blocking call ignored its timeout, took %v", elapsed)
+	}
+}
+
+func TestDeliveryAck(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	ack := make(chan error, 1)
+	l.log(record{output: "This must be confirmed.", level: LogLevelInfo, ack: ack})
+
+	select {
+	case <-ack:
+		t.Fatal("ack resolved before the buffer was flushed")
+	default:
+	}
+
+	l.flush()
+	receive(t, bodies)
+
+	if err := <-ack; err != nil {
+		t.Errorf("expected successful delivery, got %s", err)
+	}
+}
+
+func TestDeliveryAckFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	l.url = server.URL
+
+	ack := make(chan error, 1)
+	l.log(record{output: "This will be rejected.", level: LogLevelInfo, ack: ack})
+
+	if err := <-ack; err == nil {
+		t.Error("expected a delivery error")
+	}
+}
+
+func TestDeliveryAckFiltered(t *testing.T) {
+	l, _ := newTestLogger(t, false)
+	l.Level = LogLevelError
+
+	ack := make(chan error, 1)
+	l.log(record{output: "This is filtered.", level: LogLevelInfo, ack: ack})
+
+	if err := <-ack; err != ErrFiltered {
+		t.Errorf("expected ErrFiltered, got %v", err)
+	}
 }
@@ -0,0 +1,151 @@
+package log
+
+import (
+	"fmt"
+)
+
+// Logger ships to its own Loggly input with its own configuration, for
+// programs shipping on behalf of several services and for tests that need
+// isolated loggers. The package level functions log through the default
+// Logger, see Default.
+type Logger struct {
+	l *logger
+}
+
+// New creates a Logger shipping with token. Without options it logs at Debug
+// level and above, shipping each event as it is logged. An empty token
+// disables shipping unless WithEndpoint is given, leaving the console and
+// sinks.
+func New(token string, opts ...Option) *Logger {
+	l := newConfiguredLogger(token, opts)
+
+	if l.bulk {
+		l.startFlushLoop()
+	}
+
+	return &Logger{l: l}
+}
+
+// Default returns the Logger the package level functions log through, the
+// one created by SetupLogger once it is called, including for Loggers
+// returned before the call.
+func Default() *Logger {
+	return &Logger{}
+}
+
+// logger returns the logger to log through, the current package level one
+// for the default Logger.
+func (lg *Logger) logger() *logger {
+	if lg.l == nil {
+		return loggerSingleton
+	}
+
+	return lg.l
+}
+
+// Entry returns an Entry logging through the logger with fields, which may
+// be nil.
+func (lg *Logger) Entry(fields map[string]interface{}) *Entry {
+	copied := make(map[string]interface{}, len(fields))
+
+	for key, value := range fields {
+		copied[key] = value
+	}
+
+	return &Entry{logger: lg.l, fields: copied}
+}
+
+// With returns an Entry logging through the logger with the field key.
+func (lg *Logger) With(key string, value interface{}) *Entry {
+	return lg.Entry(nil).With(key, value)
+}
+
+// WithFields returns an Entry logging through the logger with fields.
+func (lg *Logger) WithFields(fields map[string]interface{}) *Entry {
+	return lg.Entry(fields)
+}
+
+// Infoln prints the output.
+func (lg *Logger) Infoln(output string) {
+	lg.Infod(output, nil)
+}
+
+// Infof prints the formatted output.
+func (lg *Logger) Infof(format string, a ...interface{}) {
+	lg.Infoln(fmt.Sprintf(format, a...))
+}
+
+// Infod prints output string and data.
+func (lg *Logger) Infod(output string, d interface{}) {
+	lg.logger().buildAndShipMessage(output, LogLevelInfo, false, d)
+}
+
+// Warnln prints the output.
+func (lg *Logger) Warnln(output string) {
+	lg.Warnd(output, nil)
+}
+
+// Warnf prints the formatted output.
+func (lg *Logger) Warnf(format string, a ...interface{}) {
+	lg.Warnln(fmt.Sprintf(format, a...))
+}
+
+// Warnd prints output string and data.
+func (lg *Logger) Warnd(output string, d interface{}) {
+	lg.logger().buildAndShipMessage(output, LogLevelWarn, false, d)
+}
+
+// Errorln prints the output.
+func (lg *Logger) Errorln(output string) {
+	lg.Errord(output, nil)
+}
+
+// Errorf prints the formatted output.
+func (lg *Logger) Errorf(format string, a ...interface{}) {
+	lg.Errorln(fmt.Sprintf(format, a...))
+}
+
+// Errord prints output string and data.
+func (lg *Logger) Errord(output string, d interface{}) {
+	lg.logger().buildAndShipMessage(output, LogLevelError, false, d)
+}
+
+// Fatalln prints the output.
+func (lg *Logger) Fatalln(output string) {
+	lg.Fatald(output, nil)
+}
+
+// Fatalf prints the formatted output.
+func (lg *Logger) Fatalf(format string, a ...interface{}) {
+	lg.Fatalln(fmt.Sprintf(format, a...))
+}
+
+// Fatald prints output string and data, then exits.
+func (lg *Logger) Fatald(output string, d interface{}) {
+	lg.logger().buildAndShipMessage(output, LogLevelFatal, true, d)
+}
+
+// Logln prints the output at level, which may be a custom level.
+func (lg *Logger) Logln(level Level, output string) {
+	lg.Logd(level, output, nil)
+}
+
+// Logf prints the formatted output at level, which may be a custom level.
+func (lg *Logger) Logf(level Level, format string, a ...interface{}) {
+	lg.Logln(level, fmt.Sprintf(format, a...))
+}
+
+// Logd prints output string and data at level, which may be a custom level.
+func (lg *Logger) Logd(level Level, output string, d interface{}) {
+	lg.logger().buildAndShipMessage(output, level, false, d)
+}
+
+// Deliverd ships output string and data at level and returns a channel that
+// receives the delivery result, see the package level Deliverd.
+func (lg *Logger) Deliverd(level Level, output string, d interface{}) <-chan error {
+	ack := make(chan error, 1)
+
+	lg.logger().log(record{output: output, level: level, data: d, ack: ack})
+
+	return ack
+}
@@ -0,0 +1,96 @@
+package log
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoggersAreIsolated(t *testing.T) {
+	billing, billingBodies := newTestLogger(t, false)
+	search, searchBodies := newTestLogger(t, false)
+	search.Level = LogLevelWarn
+
+	(&Logger{l: billing}).Infod("invoice sent", map[string]interface{}{"invoice": 7})
+	(&Logger{l: search}).Infoln("This is below the search logger's level.")
+	(&Logger{l: search}).Warnln("index stale")
+
+	if body := receive(t, billingBodies); !strings.Contains(body, "invoice sent") {
+		t.Errorf("unexpected billing body %q", body)
+	}
+
+	if body := receive(t, searchBodies); !strings.Contains(body, "index stale") {
+		t.Errorf("unexpected search body %q", body)
+	}
+
+	select {
+	case body := <-billingBodies:
+		t.Errorf("unexpected billing body %q", body)
+	case body := <-searchBodies:
+		t.Errorf("unexpected search body %q", body)
+	default:
+	}
+}
+
+func TestNew(t *testing.T) {
+	lg := New("yourlogglytoken")
+
+	if lg.logger().url != "https://"+logglyHost+"/inputs/yourlogglytoken/" || lg.logger().Level != LogLevelDebug {
+		t.Errorf("unexpected logger %s at %s", lg.logger().url, lg.logger().Level)
+	}
+
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bodies <- r.URL.Path
+	}))
+	defer server.Close()
+
+	lg.logger().url = server.URL + "/custom"
+
+	if err := <-lg.Deliverd(LogLevelInfo, "This is delivered.", nil); err != nil {
+		t.Errorf("expected successful delivery, got %s", err)
+	}
+
+	if path := <-bodies; path != "/custom" {
+		t.Errorf("unexpected path %q", path)
+	}
+}
+
+func TestLoggerEntry(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	fields := map[string]interface{}{"service": "billing"}
+	entry := (&Logger{l: l}).Entry(fields)
+	fields["service"] = "changed"
+
+	entry.Infoln("This carries the service.")
+
+	if body := receive(t, bodies); !strings.Contains(body, `"service":"billing"`) {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestDefaultFollowsSetup(t *testing.T) {
+	previous := loggerSingleton
+	loggerSingleton = newDefaultLogger()
+	t.Cleanup(func() { loggerSingleton = previous })
+
+	lg := Default()
+
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(b)
+	}))
+	defer server.Close()
+
+	Setup("", WithEndpoint(server.URL), WithSynchronous())
+
+	lg.Infoln("This is logged after setup.")
+
+	if body := receive(t, bodies); !strings.Contains(body, "This is logged after setup.") {
+		t.Errorf("unexpected body %q", body)
+	}
+}
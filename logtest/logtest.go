@@ -0,0 +1,160 @@
+// Package logtest provides per test loggers that capture events for
+// assertions.
+//
+//	func TestImport(t *testing.T) {
+//		l := logtest.ForTest(t)
+//		runImport(l.Entry)
+//
+//		if !l.Logged(loggly.LogLevelWarn, "skipped row") {
+//			t.Error("expected a warning for the skipped row")
+//		}
+//	}
+package logtest
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	loggly "github.com/morlockaerospace/loggly"
+)
+
+// TestField carries the name of the test an event was logged by.
+const TestField = "test"
+
+// CITokenEnv names the environment variable holding the token CI test logs
+// are shipped with, see ShipCI.
+const CITokenEnv = "LOGGLY_CI_TOKEN"
+
+// Option configures ForTest.
+type Option func(*options)
+
+type options struct {
+	allowErrors bool
+	token       string
+}
+
+// AllowErrors stops the cleanup check failing the test when it logged Error
+// or Fatal events.
+func AllowErrors() Option {
+	return func(o *options) {
+		o.allowErrors = true
+	}
+}
+
+// ShipCI ships test logs to Loggly with token under the "ci" tag, or with
+// the token in $LOGGLY_CI_TOKEN if token is empty. It only applies when the
+// first ForTest call sets the logger up, so pass it to each call or none.
+func ShipCI(token string) Option {
+	return func(o *options) {
+		if token == "" {
+			token = os.Getenv(CITokenEnv)
+		}
+
+		o.token = token
+	}
+}
+
+var setup sync.Once
+
+// Logger logs through the package logger with the test's name in the test
+// field and captures what it logs.
+type Logger struct {
+	*loggly.Entry
+
+	t      testing.TB
+	mu     sync.Mutex
+	events []loggly.Event
+}
+
+// ForTest returns a logger for t. Unless SetupLogger was already called it
+// sets the logger up at Trace level, shipping nothing unless ShipCI says
+// otherwise, and in every case makes it synchronous so captured events are
+// complete and in order. When the test ends the logger is flushed and, unless
+// AllowErrors is given, the test fails if Error or Fatal events were logged.
+func ForTest(t testing.TB, opts ...Option) *Logger {
+	t.Helper()
+
+	var o options
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	setup.Do(func() {
+		var tags []string
+		if o.token != "" {
+			tags = []string{"ci"}
+		}
+
+		loggly.SetupLogger(o.token, loggly.LogLevelTrace, tags, o.token != "", false)
+		loggly.SetSynchronous(true)
+	})
+
+	ctx := loggly.WithMDC(context.Background())
+	loggly.MDC(ctx).Set(TestField, t.Name())
+
+	l := &Logger{Entry: loggly.Ctx(ctx), t: t}
+
+	sink := "logtest:" + t.Name()
+	loggly.AddSink(sink, l)
+
+	t.Cleanup(func() {
+		loggly.RemoveSink(sink)
+		loggly.ForceFlush()
+
+		if o.allowErrors {
+			return
+		}
+
+		for _, e := range l.Events() {
+			if e.Level.AtLeast(loggly.LogLevelError) {
+				t.Errorf("unexpected %s event: %s", e.Level, e.Message)
+			}
+		}
+	})
+
+	return l
+}
+
+// Write captures the events logged by the logger's test.
+func (l *Logger) Write(e loggly.Event) error {
+	if e.Fields[TestField] != l.t.Name() {
+		return nil
+	}
+
+	l.mu.Lock()
+	l.events = append(l.events, e)
+	l.mu.Unlock()
+
+	return nil
+}
+
+// Events returns the events captured so far, oldest first.
+func (l *Logger) Events() []loggly.Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]loggly.Event(nil), l.events...)
+}
+
+// Logged reports whether an event at level with a message containing
+// substring was captured.
+func (l *Logger) Logged(level loggly.Level, substring string) bool {
+	for _, e := range l.Events() {
+		if e.Level == level && strings.Contains(e.Message, substring) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reset discards the events captured so far.
+func (l *Logger) Reset() {
+	l.mu.Lock()
+	l.events = nil
+	l.mu.Unlock()
+}
@@ -0,0 +1,45 @@
+package logtest
+
+import (
+	"testing"
+
+	loggly "github.com/morlockaerospace/loggly"
+)
+
+func TestForTest(t *testing.T) {
+	l := ForTest(t)
+
+	l.Infoln("import started")
+	l.Warnd("skipped row", map[string]interface{}{"row": 12})
+
+	if !l.Logged(loggly.LogLevelWarn, "skipped row") {
+		t.Errorf("expected the warning to be captured, got %+v", l.Events())
+	}
+
+	if l.Logged(loggly.LogLevelError, "skipped row") {
+		t.Error("expected the level to be matched")
+	}
+
+	events := l.Events()
+
+	if len(events) != 2 || events[0].Fields[TestField] != t.Name() {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
+
+func TestForTestIsolation(t *testing.T) {
+	outer := ForTest(t, AllowErrors())
+
+	t.Run("inner", func(t *testing.T) {
+		inner := ForTest(t, AllowErrors())
+		inner.Errorln("inner failure")
+
+		if len(inner.Events()) != 1 {
+			t.Errorf("unexpected events %+v", inner.Events())
+		}
+	})
+
+	if len(outer.Events()) != 0 {
+		t.Errorf("expected the inner test's events to stay there, got %+v", outer.Events())
+	}
+}
@@ -0,0 +1,99 @@
+package log
+
+import (
+	"context"
+	"sync"
+)
+
+type mdcKey struct{}
+
+// MappedContext holds diagnostic values, like an order or request ID, that
+// enrich every message logged within its scope. Scopes travel in a
+// context.Context rather than goroutine locals, see WithMDC.
+type MappedContext struct {
+	mu     sync.RWMutex
+	values map[string]interface{}
+}
+
+// WithMDC returns a context carrying a new diagnostic scope, starting with a
+// copy of any values from the parent scope. Changes within the new scope
+// don't affect the parent.
+func WithMDC(ctx context.Context) context.Context {
+	return context.WithValue(ctx, mdcKey{}, &MappedContext{values: MDC(ctx).snapshot()})
+}
+
+// MDC returns the diagnostic scope carried by ctx. Without one it returns nil,
+// on which every method is a no-op.
+func MDC(ctx context.Context) *MappedContext {
+	if ctx == nil {
+		return nil
+	}
+
+	m, _ := ctx.Value(mdcKey{}).(*MappedContext)
+
+	return m
+}
+
+// Ctx returns an Entry enriched with the diagnostic values in ctx at the time
+// each message is logged, and logging through the RequestLog it carries.
+func Ctx(ctx context.Context) *Entry {
+	if r := RequestLogFrom(ctx); r != nil {
+		return &Entry{logger: r.Entry.logger, fields: r.Entry.fields, mdc: MDC(ctx), request: r}
+	}
+
+	return &Entry{mdc: MDC(ctx)}
+}
+
+// Set stores a value in the scope.
+func (m *MappedContext) Set(key string, value interface{}) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	m.values[key] = value
+	m.mu.Unlock()
+}
+
+// Get returns a value stored in the scope.
+func (m *MappedContext) Get(key string) (interface{}, bool) {
+	if m == nil {
+		return nil, false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, ok := m.values[key]
+
+	return value, ok
+}
+
+// Remove deletes a value from the scope.
+func (m *MappedContext) Remove(key string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	delete(m.values, key)
+	m.mu.Unlock()
+}
+
+// snapshot returns a copy of the scope's values.
+func (m *MappedContext) snapshot() map[string]interface{} {
+	values := map[string]interface{}{}
+
+	if m == nil {
+		return values
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for key, value := range m.values {
+		values[key] = value
+	}
+
+	return values
+}
@@ -0,0 +1,59 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMDC(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	ctx := WithMDC(context.Background())
+	MDC(ctx).Set("order_id", "A-1")
+
+	child := WithMDC(ctx)
+	MDC(child).Set("step", "charge")
+
+	(&Entry{logger: l, mdc: MDC(child)}).Infoln("charging card")
+	(&Entry{logger: l, mdc: MDC(ctx)}).Infod("order placed", map[string]string{"sku": "X"})
+	l.flush()
+
+	lines := strings.Split(strings.TrimSpace(receive(t, bodies)), "\n")
+
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+
+	if !strings.Contains(lines[0], `"order_id":"A-1"`) || !strings.Contains(lines[0], `"step":"charge"`) {
+		t.Errorf("expected the child scope to inherit values, got %s", lines[0])
+	}
+
+	if strings.Contains(lines[1], "step") || !strings.HasSuffix(lines[1], `"metadata":{"sku":"X"},"order_id":"A-1"}`) {
+		t.Errorf("expected only the parent scope's values at the top level, got %s", lines[1])
+	}
+}
+
+func TestMDCWithoutScope(t *testing.T) {
+	ctx := context.Background()
+
+	MDC(ctx).Set("ignored", true)
+
+	if _, ok := MDC(ctx).Get("ignored"); ok {
+		t.Error("expected a missing scope to ignore values")
+	}
+}
+
+func TestReservedFieldKeys(t *testing.T) {
+	message := newMessage("2020-01-01T00:00:00Z", LogLevelInfo, "hello", nil)
+	message.Fields = map[string]interface{}{"message": "clash"}
+
+	b, err := message.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(b), `"message":"hello"`) || !strings.Contains(string(b), `"field.message":"clash"`) {
+		t.Errorf("unexpected encoding %s", b)
+	}
+}
@@ -0,0 +1,154 @@
+package log
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// LogMetric derives a Prometheus metric from the events matching a rule, so
+// simple metrics such as a count of failed payments need no instrumentation
+// of their own. Metrics count every matching event that passes the logger's
+// level, including those filter rules go on to drop.
+type LogMetric struct {
+	// Name is the metric's Prometheus name, e.g.
+	// "payments_failed_total".
+	Name string
+	Help string
+
+	// Match selects the events counted, its Action is ignored.
+	Match FilterRule
+
+	// Field, if set, names a numeric field, such as "duration_ms", whose
+	// values are observed into a histogram instead of counting events.
+	// Events without the field aren't observed.
+	Field string
+
+	// Buckets are the histogram's upper bounds, the shipping latency
+	// buckets if empty.
+	Buckets []float64
+}
+
+// LogMetricSnapshot is the current value of a LogMetric.
+type LogMetricSnapshot struct {
+	Name string
+	Help string
+
+	// Count is the number of matching events, for metrics without a Field.
+	Count uint64
+
+	// Values is the distribution of the Field's values, nil for metrics
+	// without one.
+	Values *Histogram
+}
+
+var metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+type logMetric struct {
+	metric LogMetric
+	rule   *compiledRule
+	count  uint64
+	values *histogram
+}
+
+// AddLogMetric adds a metric derived from events, served alongside the
+// logger's other statistics by WritePrometheus and PrometheusHandler.
+func AddLogMetric(metric LogMetric) error {
+	return loggerSingleton.stats.addLogMetric(metric)
+}
+
+func (s *stats) addLogMetric(metric LogMetric) error {
+	if !metricName.MatchString(metric.Name) {
+		return fmt.Errorf("invalid metric name %q", metric.Name)
+	}
+
+	match := metric.Match
+	match.Action = FilterKeep
+
+	rule, err := compileRule(match)
+
+	if err != nil {
+		return err
+	}
+
+	m := &logMetric{metric: metric, rule: rule}
+
+	if metric.Field != "" {
+		bounds := metric.Buckets
+		if len(bounds) == 0 {
+			bounds = latencyBounds
+		}
+
+		m.values = newHistogram(append([]float64(nil), bounds...))
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	for _, existing := range s.metrics {
+		if existing.metric.Name == metric.Name {
+			return fmt.Errorf("metric %s already exists", metric.Name)
+		}
+	}
+
+	s.metrics = append(s.metrics, m)
+
+	return nil
+}
+
+// observeMetrics updates the metrics the event matches.
+func (s *stats) observeMetrics(output string, level Level, d interface{}, extra map[string]interface{}) {
+	s.Lock()
+	defer s.Unlock()
+
+	if len(s.metrics) == 0 {
+		return
+	}
+
+	fields := matchableFields(d, extra)
+
+	for _, m := range s.metrics {
+		if !m.rule.matches(output, level, fields) {
+			continue
+		}
+
+		if m.values == nil {
+			m.count++
+			continue
+		}
+
+		if value, ok := getPath(fields, m.metric.Field); ok {
+			if v, ok := numericValue(value); ok {
+				m.values.observe(v)
+			}
+		}
+	}
+}
+
+// numericValue converts a field value to a number, parsing strings.
+func numericValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+func (m *logMetric) snapshot() LogMetricSnapshot {
+	snapshot := LogMetricSnapshot{Name: m.metric.Name, Help: m.metric.Help, Count: m.count}
+
+	if m.values != nil {
+		values := m.values.snapshot()
+		snapshot.Values = &values
+	}
+
+	return snapshot
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLogMetrics(t *testing.T) {
+	l, _ := newTestLogger(t, false)
+	l.shippingDisabled = true
+
+	if err := l.stats.addLogMetric(LogMetric{Name: "payments_failed_total", Help: "Failed payments.", Match: FilterRule{Message: "^payment failed"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.stats.addLogMetric(LogMetric{Name: "checkout_ms", Help: "Checkout duration.", Match: FilterRule{Message: "checkout"}, Field: "duration_ms", Buckets: []float64{100, 1000}}); err != nil {
+		t.Fatal(err)
+	}
+
+	l.log(record{output: "payment failed: card declined", level: LogLevelError})
+	l.log(record{output: "payment failed: timeout", level: LogLevelWarn})
+	l.log(record{output: "payment succeeded", level: LogLevelInfo})
+	l.log(record{output: "checkout complete", level: LogLevelInfo, data: map[string]interface{}{"duration_ms": 250}})
+	l.log(record{output: "checkout complete", level: LogLevelInfo, fields: map[string]interface{}{"duration_ms": "50"}})
+	l.log(record{output: "checkout abandoned", level: LogLevelInfo})
+
+	snapshot := l.stats.snapshot()
+
+	if len(snapshot.LogMetrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %+v", snapshot.LogMetrics)
+	}
+
+	if failed := snapshot.LogMetrics[0]; failed.Count != 2 || failed.Values != nil {
+		t.Errorf("unexpected counter %+v", failed)
+	}
+
+	if checkout := snapshot.LogMetrics[1].Values; checkout == nil || checkout.Count != 2 || checkout.Sum != 300 {
+		t.Errorf("unexpected histogram %+v", checkout)
+	}
+
+	var b bytes.Buffer
+	snapshot.writePrometheus(&b)
+
+	for _, expected := range []string{"# TYPE payments_failed_total counter\npayments_failed_total 2\n", "checkout_ms_bucket{le=\"100\"} 1\n", "checkout_ms_count 2\n"} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected %q in %s", expected, b.String())
+		}
+	}
+}
+
+func TestLogMetricValidation(t *testing.T) {
+	s := newStats()
+
+	if err := s.addLogMetric(LogMetric{Name: "not a name"}); err == nil {
+		t.Error("expected an invalid name to be rejected")
+	}
+
+	if err := s.addLogMetric(LogMetric{Name: "events_total", Match: FilterRule{Message: "("}}); err == nil {
+		t.Error("expected an invalid pattern to be rejected")
+	}
+
+	s.addLogMetric(LogMetric{Name: "events_total"})
+
+	if err := s.addLogMetric(LogMetric{Name: "events_total"}); err == nil {
+		t.Error("expected a duplicate name to be rejected")
+	}
+}
@@ -0,0 +1,102 @@
+package log
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// Middleware recovers panics in next, logging each at Fatal level with the
+// stack and request details and answering 500, without exiting. Recovered
+// panics are counted in Stats. Panics with http.ErrAbortHandler are let
+// through, net/http uses them to abort a response. Under tail sampling each
+// request gets a RequestLog, which Ctx entries on its context log through,
+// ended as failed on a panic or a 5xx response. Requests log through the
+// package level logger of the moment, so handlers may be wrapped before
+// SetupLogger.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loggerSingleton.middleware(next).ServeHTTP(w, r)
+	})
+}
+
+func (l *logger) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.Lock()
+		tail := l.tail != nil
+		l.Unlock()
+
+		var request *RequestLog
+		status := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+		if tail {
+			request = l.startRequest(r.Header.Get("X-Request-Id"))
+			r = r.WithContext(WithRequestLog(r.Context(), request))
+			w = status
+
+			// Runs after the panic handler below has answered.
+			defer func() {
+				if status.status >= 500 {
+					request.End(fmt.Errorf("%s %s answered %d", r.Method, r.URL.Path, status.status))
+				} else {
+					request.End(nil)
+				}
+			}()
+		}
+
+		defer func() {
+			value := recover()
+
+			if value == nil {
+				return
+			}
+
+			if value == http.ErrAbortHandler {
+				panic(value)
+			}
+
+			l.stats.recordPanic()
+
+			fields := map[string]interface{}{
+				"panic":       fmt.Sprint(value),
+				"stack":       string(debug.Stack()),
+				"method":      r.Method,
+				"path":        r.URL.Path,
+				"remote_addr": r.RemoteAddr,
+				"user_agent":  r.UserAgent(),
+			}
+
+			if id := r.Header.Get("X-Request-Id"); id != "" {
+				fields["request_id"] = id
+			}
+
+			if err, ok := value.(error); ok {
+				fields["error"] = err.Error()
+			}
+
+			(&Entry{logger: l, fields: fields, mdc: MDC(r.Context()), request: request}).Logln(LogLevelFatal, fmt.Sprintf("panic serving %s %s: %v", r.Method, r.URL.Path, value))
+
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statusWriter records the status a handler answered with.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Flush lets streaming handlers flush through the wrapper.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,74 @@
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareRecoversPanics(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	code := stubExit(t)
+
+	handler := l.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map write")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set("X-Request-Id", "req-7")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rec.Code)
+	}
+
+	body := receive(t, bodies)
+
+	for _, want := range []string{`"level":"FATAL"`, `"message":"panic serving POST /orders: nil map write"`, `"request_id":"req-7"`, `"stack":"goroutine`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in %q", want, body)
+		}
+	}
+
+	if panics := l.stats.snapshot().PanicsRecovered; panics != 1 || *code != 0 {
+		t.Errorf("expected 1 recovered panic without exiting, got %d and exit code %d", panics, *code)
+	}
+}
+
+func TestMiddlewareFollowsSetup(t *testing.T) {
+	previous := loggerSingleton
+	t.Cleanup(func() { loggerSingleton = previous })
+	stubExit(t)
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map write")
+	}))
+
+	l, bodies := newTestLogger(t, false)
+	loggerSingleton = l
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if body := receive(t, bodies); !strings.Contains(body, "panic serving GET /orders") {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestMiddlewarePassesAbort(t *testing.T) {
+	l, _ := newTestLogger(t, false)
+
+	handler := l.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("expected http.ErrAbortHandler to be re-panicked")
+		}
+	}()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
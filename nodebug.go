@@ -0,0 +1,61 @@
+//go:build loggly_nodebug
+// +build loggly_nodebug
+
+package log
+
+// DebugCompiled reports whether Debug and Trace logging is compiled in.
+const DebugCompiled = false
+
+// Traceln is compiled out by the loggly_nodebug tag.
+func Traceln(output string) {}
+
+// Traced is compiled out by the loggly_nodebug tag.
+func Traced(output string, d interface{}) {}
+
+// Tracef is compiled out by the loggly_nodebug tag.
+func Tracef(format string, a ...interface{}) {}
+
+// Debugln is compiled out by the loggly_nodebug tag.
+func Debugln(output string) {}
+
+// Debugd is compiled out by the loggly_nodebug tag.
+func Debugd(output string, d interface{}) {}
+
+// Debugf is compiled out by the loggly_nodebug tag.
+func Debugf(format string, a ...interface{}) {}
+
+// Traceln is compiled out by the loggly_nodebug tag.
+func (e *Entry) Traceln(output string) {}
+
+// Tracef is compiled out by the loggly_nodebug tag.
+func (e *Entry) Tracef(format string, a ...interface{}) {}
+
+// Traced is compiled out by the loggly_nodebug tag.
+func (e *Entry) Traced(output string, d interface{}) {}
+
+// Debugln is compiled out by the loggly_nodebug tag.
+func (e *Entry) Debugln(output string) {}
+
+// Debugf is compiled out by the loggly_nodebug tag.
+func (e *Entry) Debugf(format string, a ...interface{}) {}
+
+// Debugd is compiled out by the loggly_nodebug tag.
+func (e *Entry) Debugd(output string, d interface{}) {}
+
+// Traceln is compiled out by the loggly_nodebug tag.
+func (lg *Logger) Traceln(output string) {}
+
+// Tracef is compiled out by the loggly_nodebug tag.
+func (lg *Logger) Tracef(format string, a ...interface{}) {}
+
+// Traced is compiled out by the loggly_nodebug tag.
+func (lg *Logger) Traced(output string, d interface{}) {}
+
+// Debugln is compiled out by the loggly_nodebug tag.
+func (lg *Logger) Debugln(output string) {}
+
+// Debugf is compiled out by the loggly_nodebug tag.
+func (lg *Logger) Debugf(format string, a ...interface{}) {}
+
+// Debugd is compiled out by the loggly_nodebug tag.
+func (lg *Logger) Debugd(output string, d interface{}) {}
@@ -0,0 +1,29 @@
+//go:build loggly_nodebug
+// +build loggly_nodebug
+
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDebugCompiledOut(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.Level = LogLevelTrace
+
+	e := &Entry{logger: l}
+	e.Debugd("This is a debug statement.", nil)
+	e.Traceln("This is a trace statement.")
+	e.Infoln("This is an info statement.")
+
+	if body := receive(t, bodies); !strings.Contains(body, "info statement") || DebugCompiled {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected extra body %q", body)
+	default:
+	}
+}
@@ -0,0 +1,168 @@
+package log
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ObjectStore keeps payloads offloaded from events, see SetOffload. Put
+// stores data under key and returns the URL it can be fetched from. Backends
+// such as S3 or GCS implement it over their own clients.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, data []byte) (string, error)
+}
+
+// DirStore is an ObjectStore writing payloads to files in a directory, such
+// as a volume served internally or synced to a bucket.
+type DirStore struct {
+	Dir string
+
+	// BaseURL, if set, is the URL Dir is served from. Otherwise references
+	// are file URLs.
+	BaseURL string
+}
+
+// Put writes data to the file key in the directory.
+func (s DirStore) Put(ctx context.Context, key string, data []byte) (string, error) {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(s.Dir, key)
+
+	// Keys are content addressed, so a payload already stored is unchanged.
+	if _, err := os.Stat(path); err != nil {
+		tmp, err := ioutil.TempFile(s.Dir, ".offload-")
+
+		if err != nil {
+			return "", err
+		}
+
+		_, err = tmp.Write(data)
+
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+
+		if err != nil {
+			os.Remove(tmp.Name())
+			return "", err
+		}
+	}
+
+	if s.BaseURL != "" {
+		return strings.TrimSuffix(s.BaseURL, "/") + "/" + url.PathEscape(key), nil
+	}
+
+	abs, err := filepath.Abs(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(), nil
+}
+
+// defaultOffloadThreshold is the metadata size above which it is offloaded.
+const defaultOffloadThreshold = 16 * 1024
+
+// OffloadedField holds the reference shipped in place of offloaded metadata.
+const OffloadedField = "offloaded"
+
+// Offload configures offloading large metadata to an object store, shipping
+// a reference in its place so events stay small while the full payload is
+// kept. Sinks still receive the full metadata.
+type Offload struct {
+	Store ObjectStore
+
+	// Threshold is the size of encoded metadata above which it is offloaded,
+	// 16KB if zero.
+	Threshold int
+
+	// Prefix is prepended to the object keys, such as "logs/".
+	Prefix string
+
+	// Timeout bounds each upload, 10 seconds if zero. The upload runs in the
+	// log call.
+	Timeout time.Duration
+}
+
+// OffloadReference is shipped under the offloaded key in place of the
+// metadata.
+type OffloadReference struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+	Bytes  int    `json:"bytes"`
+}
+
+// SetOffload offloads metadata larger than the threshold to the store. Pass
+// a zero Offload to ship all metadata inline again. If an upload fails the
+// metadata is shipped inline.
+func SetOffload(offload Offload) {
+	loggerSingleton.Lock()
+	loggerSingleton.offload = offload
+	loggerSingleton.Unlock()
+}
+
+// offloadMetadata returns d, or a reference to it once stored if it is over
+// the threshold.
+func (l *logger) offloadMetadata(d interface{}) interface{} {
+	l.Lock()
+	offload := l.offload
+	l.Unlock()
+
+	if offload.Store == nil || d == nil {
+		return d
+	}
+
+	b, err := json.Marshal(d)
+
+	if err != nil {
+		return d
+	}
+
+	threshold := offload.Threshold
+	if threshold == 0 {
+		threshold = defaultOffloadThreshold
+	}
+
+	if len(b) <= threshold {
+		return d
+	}
+
+	timeout := offload.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	sum := sha256.Sum256(b)
+	hash := hex.EncodeToString(sum[:])
+
+	location, err := offload.Store.Put(ctx, offload.Prefix+hash+".json", b)
+
+	if err != nil {
+		if l.debugMode {
+			fmt.Printf("There was an error offloading log metadata: %s", err)
+		}
+
+		return d
+	}
+
+	return map[string]interface{}{OffloadedField: OffloadReference{URL: location, SHA256: hash, Bytes: len(b)}}
+}
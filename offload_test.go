@@ -0,0 +1,47 @@
+package log
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOffloadMetadata(t *testing.T) {
+	dir := tempSpoolDir(t)
+	l, bodies := newTestLogger(t, false)
+	sink := &memorySink{}
+	l.sinks = append(l.sinks, newNamedSink("memory", sink))
+	l.offload = Offload{Store: DirStore{Dir: dir, BaseURL: "https://blobs.example.com/logs/"}, Threshold: 64}
+
+	l.buildAndShipMessage("small", LogLevelInfo, false, map[string]string{"id": "7"})
+
+	if body := receive(t, bodies); !strings.Contains(body, `"id":"7"`) {
+		t.Errorf("expected small metadata inline, got %q", body)
+	}
+
+	payload := strings.Repeat("x", 100)
+	l.buildAndShipMessage("large", LogLevelInfo, false, map[string]string{"payload": payload})
+
+	sum := sha256.Sum256([]byte(`{"payload":"` + payload + `"}`))
+	hash := hex.EncodeToString(sum[:])
+
+	body := receive(t, bodies)
+
+	if strings.Contains(body, payload) || !strings.Contains(body, `"offloaded":{"url":"https://blobs.example.com/logs/`+hash+`.json","sha256":"`+hash+`","bytes":114}`) {
+		t.Errorf("expected a reference, got %q", body)
+	}
+
+	stored, err := ioutil.ReadFile(filepath.Join(dir, hash+".json"))
+
+	if err != nil || !strings.Contains(string(stored), payload) {
+		t.Errorf("expected the payload stored, got %q, %v", stored, err)
+	}
+
+	if len(sink.events) != 2 || !strings.Contains(fmt.Sprint(sink.events[1].Metadata), payload) {
+		t.Errorf("expected sinks to receive the full metadata, got %+v", sink.events)
+	}
+}
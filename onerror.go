@@ -0,0 +1,40 @@
+package log
+
+import (
+	"time"
+)
+
+// onErrorStartKey is the key OnError takes the call's start time from.
+const onErrorStartKey = "start"
+
+// OnError logs msg at Error level with the error and fields given as
+// alternating keys and values when the function with the named error result
+// err returns a non-nil error. Defer it:
+//
+//	func upload(path string) (err error) {
+//		defer log.OnError(&err, "upload failed", "start", time.Now(), "path", path)
+//		...
+//	}
+//
+// Go evaluates the arguments when the defer statement runs, so a time.Time
+// given under the "start" key is when the call started, and is logged as the
+// call's duration in "duration_ms" instead.
+func OnError(err *error, msg string, keyvals ...interface{}) {
+	loggerSingleton.onError(err, msg, keyvals)
+}
+
+func (l *logger) onError(err *error, msg string, keyvals []interface{}) {
+	if err == nil || *err == nil {
+		return
+	}
+
+	fields := keyvalFields(keyvals)
+	fields["error"] = (*err).Error()
+
+	if start, ok := fields[onErrorStartKey].(time.Time); ok {
+		delete(fields, onErrorStartKey)
+		fields["duration_ms"] = float64(l.now().Sub(start)) / float64(time.Millisecond)
+	}
+
+	(&Entry{logger: l, fields: fields}).Errorln(msg)
+}
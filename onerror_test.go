@@ -0,0 +1,71 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOnError(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.clock = clock
+
+	upload := func(fail bool) (err error) {
+		defer l.onError(&err, "upload failed", []interface{}{"start", clock.Now(), "path", "/tmp/a"})
+
+		clock.Advance(250 * time.Millisecond)
+
+		if fail {
+			return errors.New("disk full")
+		}
+
+		return nil
+	}
+
+	if err := upload(false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := upload(true); err == nil || err.Error() != "disk full" {
+		t.Fatalf("expected the error to be returned unchanged, got %v", err)
+	}
+
+	body := receive(t, bodies)
+
+	for _, want := range []string{`"message":"upload failed"`, `"error":"disk full"`, `"path":"/tmp/a"`, `"duration_ms":250`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in %q", want, body)
+		}
+	}
+
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected body for a successful call %q", body)
+	default:
+	}
+}
+
+func TestOnErrorDeferred(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+	previous := loggerSingleton
+	loggerSingleton = l
+	t.Cleanup(func() { loggerSingleton = previous })
+
+	upload := func() (err error) {
+		defer OnError(&err, "upload failed", "path", "/tmp/a")
+
+		return errors.New("disk full")
+	}
+
+	upload()
+	l.flush()
+
+	body := receive(t, bodies)
+
+	if !strings.Contains(body, `"error":"disk full"`) || strings.Contains(body, "duration_ms") {
+		t.Errorf("expected the error logged without a duration, got %q", body)
+	}
+}
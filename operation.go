@@ -0,0 +1,88 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// Operation times a unit of work started with Begin and logs its outcome when
+// it ends, a lightweight alternative to tracing.
+type Operation struct {
+	logger *logger
+	name   string
+	fields map[string]interface{}
+	start  time.Time
+	once   sync.Once
+}
+
+// SetOperationStartEvents makes Begin log a start event for every operation
+// as well as the completion event.
+func SetOperationStartEvents(enabled bool) {
+	loggerSingleton.Lock()
+	loggerSingleton.operationStarts = enabled
+	loggerSingleton.Unlock()
+}
+
+// Begin starts timing the named operation, e.g. "payment.charge". fields are
+// added to the operation's events.
+func Begin(name string, fields map[string]interface{}) *Operation {
+	return loggerSingleton.begin(name, fields)
+}
+
+func (l *logger) begin(name string, fields map[string]interface{}) *Operation {
+	op := &Operation{logger: l, name: name, fields: map[string]interface{}{}, start: l.now()}
+
+	for key, value := range fields {
+		op.fields[key] = value
+	}
+
+	op.fields["operation"] = name
+
+	l.Lock()
+	starts := l.operationStarts
+	l.Unlock()
+
+	if starts {
+		op.entry(map[string]interface{}{"outcome": "started"}).Infoln(name + " started")
+	}
+
+	return op
+}
+
+// End logs the operation's completion with its duration and outcome, at Info
+// level on success or at Error level with the error. Only the first call has
+// any effect.
+func (op *Operation) End(err error) {
+	op.once.Do(func() {
+		duration := op.logger.now().Sub(op.start)
+
+		fields := map[string]interface{}{
+			"duration_ms": float64(duration) / float64(time.Millisecond),
+			"outcome":     "success",
+		}
+
+		if err != nil {
+			fields["outcome"] = "error"
+			fields["error"] = err.Error()
+
+			op.entry(fields).Errorln(op.name + " failed")
+			return
+		}
+
+		op.entry(fields).Infoln(op.name + " completed")
+	})
+}
+
+func (op *Operation) entry(extra map[string]interface{}) *Entry {
+	fields := make(map[string]interface{}, len(op.fields)+len(extra))
+
+	for key, value := range op.fields {
+		fields[key] = value
+	}
+
+	for key, value := range extra {
+		fields[key] = value
+	}
+
+	return &Entry{logger: op.logger, fields: fields}
+}
@@ -0,0 +1,49 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOperation(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.clock = clock
+	l.operationStarts = true
+
+	op := l.begin("payment.charge", map[string]interface{}{"order_id": "A-1"})
+	clock.Advance(250 * time.Millisecond)
+	op.End(errors.New("card declined"))
+	op.End(nil)
+	l.flush()
+
+	lines := strings.Split(strings.TrimSpace(receive(t, bodies)), "\n")
+
+	if len(lines) != 2 {
+		t.Fatalf("expected a start and a single completion event, got %d lines", len(lines))
+	}
+
+	if !strings.Contains(lines[0], `"message":"payment.charge started"`) || !strings.Contains(lines[0], `"outcome":"started"`) {
+		t.Errorf("unexpected start event %s", lines[0])
+	}
+
+	for _, want := range []string{`"level":"ERROR"`, `"duration_ms":250`, `"error":"card declined"`, `"operation":"payment.charge"`, `"order_id":"A-1"`, `"outcome":"error"`} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("expected %s in %s", want, lines[1])
+		}
+	}
+}
+
+func TestOperationSuccess(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	l.begin("nav.solve", nil).End(nil)
+	l.flush()
+
+	if body := receive(t, bodies); !strings.Contains(body, `"message":"nav.solve completed"`) || !strings.Contains(body, `"outcome":"success"`) {
+		t.Errorf("unexpected body %q", body)
+	}
+}
@@ -0,0 +1,167 @@
+package log
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures a logger created with New or Setup.
+type Option func(*logger)
+
+// WithLevel ships events at level and above.
+func WithLevel(level Level) Option {
+	return func(l *logger) {
+		l.Level = level
+	}
+}
+
+// WithTags tags every event shipped to Loggly.
+func WithTags(tags ...string) Option {
+	return func(l *logger) {
+		l.tags = append([]string(nil), tags...)
+	}
+}
+
+// WithBulk buffers events and ships them to the bulk endpoint, when size
+// events are buffered or every interval. Zero values keep the defaults of
+// 1000 events and 10 seconds.
+func WithBulk(size int, interval time.Duration) Option {
+	return func(l *logger) {
+		l.bulk = true
+
+		if size > 0 {
+			l.bufferSize = size
+		}
+
+		if interval > 0 {
+			l.flushInterval = interval
+		}
+	}
+}
+
+// WithEndpoint ships to url instead of the Loggly input for the token, such
+// as an ingestion gateway. The endpoint carries its own credentials, so the
+// token isn't validated.
+func WithEndpoint(url string) Option {
+	return func(l *logger) {
+		l.endpoint = url
+	}
+}
+
+// WithHTTPClient ships through client, see SetHTTPClient. A nil client
+// keeps the client built from the transport settings.
+func WithHTTPClient(client *http.Client) Option {
+	return func(l *logger) {
+		l.customClient = client
+		l.rebuildClient()
+	}
+}
+
+// WithTransport ships through transport, see SetTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(l *logger) {
+		l.transport.custom = transport
+		l.rebuildClient()
+	}
+}
+
+// WithRequestTimeout bounds each request, see SetRequestTimeout.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(l *logger) {
+		l.requestTimeout = timeout
+	}
+}
+
+// WithDebugMode reports the logger's own failures, such as sinks that fail
+// to write, on the console.
+func WithDebugMode(enabled bool) Option {
+	return func(l *logger) {
+		l.debugMode = enabled
+	}
+}
+
+// WithClock replaces the clock, see SetClock.
+func WithClock(clock Clock) Option {
+	return func(l *logger) {
+		l.clock = clock
+	}
+}
+
+// WithSynchronous ships on the calling goroutine, see SetSynchronous.
+func WithSynchronous() Option {
+	return func(l *logger) {
+		l.synchronous = true
+	}
+}
+
+// WithRequestHook runs hook on every request shipped, see SetRequestHook.
+func WithRequestHook(hook RequestHook) Option {
+	return func(l *logger) {
+		l.requestHook = hook
+	}
+}
+
+// WithRetry sets the retry policy, see SetRetry.
+func WithRetry(retry Retry) Option {
+	return func(l *logger) {
+		l.retry = retry
+	}
+}
+
+// WithOffload offloads large metadata to an object store, see SetOffload.
+func WithOffload(offload Offload) Option {
+	return func(l *logger) {
+		l.offload = offload
+	}
+}
+
+// WithSensitiveFields protects sensitive fields, see SetSensitiveFields.
+func WithSensitiveFields(sensitive SensitiveFields) Option {
+	return func(l *logger) {
+		l.sensitive = sensitive
+	}
+}
+
+// WithClassificationPolicy enforces a data classification policy, see
+// SetClassificationPolicy.
+func WithClassificationPolicy(policy ClassificationPolicy) Option {
+	return func(l *logger) {
+		l.classification = policy
+	}
+}
+
+// WithTailSampling enables tail based retention, see SetTailSampling.
+func WithTailSampling(config TailSampling) Option {
+	return func(l *logger) {
+		if config.MaxEvents <= 0 {
+			config.MaxEvents = defaultTailMaxEvents
+		}
+
+		l.tail = &config
+	}
+}
+
+// newConfiguredLogger creates a logger for token with opts applied over the
+// defaults of Debug level, no tags and no bulk buffering. Shipping is
+// disabled without a token or endpoint. The flush loop is left for the
+// caller to start.
+func newConfiguredLogger(token string, opts []Option) *logger {
+	l := newLogger(token, LogLevelDebug, nil, false, false)
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	// The options may have changed the endpoint the token, tags and bulk
+	// mode resolve to.
+	if l.endpoint != "" {
+		l.url = l.endpoint
+		l.tokenErr = nil
+	} else {
+		l.url = inputURL(l.bulk, l.token, l.tags)
+	}
+
+	l.shippingDisabled = token == "" && l.endpoint == ""
+
+	return l
+}
@@ -0,0 +1,71 @@
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOptions(t *testing.T) {
+	client := &http.Client{}
+	lg := New("yourlogglytoken", WithLevel(LogLevelWarn), WithTags("api", "eu"), WithBulk(50, time.Minute), WithHTTPClient(client))
+	defer close(lg.logger().stop)
+
+	if lg.logger().url != "https://"+logglyHost+"/bulk/yourlogglytoken/tag/api,eu/" {
+		t.Errorf("unexpected url %s", lg.logger().url)
+	}
+
+	if lg.logger().Level != LogLevelWarn || lg.logger().bufferSize != 50 || lg.logger().flushInterval != time.Minute || lg.logger().client != client {
+		t.Errorf("options not applied: %s, %d, %s", lg.logger().Level, lg.logger().bufferSize, lg.logger().flushInterval)
+	}
+}
+
+func TestWithEndpoint(t *testing.T) {
+	paths := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths <- r.URL.Path
+	}))
+	defer server.Close()
+
+	lg := New("", WithEndpoint(server.URL+"/ingest"), WithSynchronous())
+
+	if err := <-lg.Deliverd(LogLevelInfo, "This is delivered.", nil); err != nil {
+		t.Errorf("expected successful delivery, got %s", err)
+	}
+
+	if path := <-paths; path != "/ingest" {
+		t.Errorf("unexpected path %q", path)
+	}
+}
+
+func TestWithHTTPClientNil(t *testing.T) {
+	lg := New("yourlogglytoken", WithHTTPClient(nil))
+
+	if lg.logger().client == nil {
+		t.Error("expected the built client kept")
+	}
+}
+
+func TestNewWithoutToken(t *testing.T) {
+	requests := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r.URL.Path
+	}))
+	defer server.Close()
+
+	lg := New("", WithSynchronous())
+	lg.logger().url = server.URL + "/inputs//tag/"
+
+	if !lg.logger().shippingDisabled {
+		t.Error("expected shipping disabled without a token")
+	}
+
+	lg.Infoln("This stays local.")
+
+	select {
+	case path := <-requests:
+		t.Errorf("unexpected request to %q", path)
+	default:
+	}
+}
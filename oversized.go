@@ -0,0 +1,146 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// OversizedAction is what happens to an event Loggly rejects as too large on
+// its own.
+type OversizedAction int
+
+const (
+	// OversizedTruncate ships the event again with its message cut short and
+	// its metadata and fields dropped.
+	OversizedTruncate OversizedAction = iota
+
+	// OversizedDeadLetter writes the event to the dead letter writer instead
+	// of shipping it.
+	OversizedDeadLetter
+)
+
+// ErrOversized is reported on a delivery channel when an event was too large
+// to ship, even once truncated.
+var ErrOversized = errors.New("log event is too large to ship")
+
+// defaultTruncateBytes bounds the message of a truncated event.
+const defaultTruncateBytes = 64 * 1024
+
+// OversizedEvents configures how events too large for Loggly are handled. A
+// bulk request rejected with a 413 is bisected until the events too large on
+// their own are isolated, then each is handled by Action and a Warn level
+// event reports it.
+type OversizedEvents struct {
+	Action OversizedAction
+
+	// MaxBytes bounds the message of a truncated event, 64KB if zero.
+	MaxBytes int
+
+	// DeadLetter receives the JSON of each dead lettered event as a line. It
+	// is required for OversizedDeadLetter, and otherwise receives events
+	// still too large once truncated.
+	DeadLetter io.Writer
+}
+
+// SetOversizedEvents sets how events too large for Loggly are handled. By
+// default they are truncated.
+func SetOversizedEvents(oversized OversizedEvents) error {
+	if oversized.Action == OversizedDeadLetter && oversized.DeadLetter == nil {
+		return fmt.Errorf("oversized dead lettering requires a writer")
+	}
+
+	loggerSingleton.Lock()
+	loggerSingleton.oversized = oversized
+	loggerSingleton.Unlock()
+
+	return nil
+}
+
+// oversizedEvent reports whether err rejected message as too large on its
+// own.
+func oversizedEvent(err error, messages []*logMessage) bool {
+	var responseErr *ResponseError
+
+	return len(messages) == 1 && errors.As(err, &responseErr) && classifyStatus(responseErr.StatusCode) == statusSplit
+}
+
+// shipOversized truncates or dead letters a message rejected as too large.
+func (l *logger) shipOversized(ctx context.Context, body []byte, message *logMessage) error {
+	l.Lock()
+	oversized := l.oversized
+	l.Unlock()
+
+	report := map[string]interface{}{
+		"oversized_bytes":     len(body),
+		"oversized_level":     message.Level,
+		"oversized_timestamp": message.Timestamp,
+	}
+
+	if oversized.Action == OversizedDeadLetter || message.truncated {
+		if oversized.DeadLetter != nil {
+			line := body
+			if body[len(body)-1] != '\n' {
+				line = append(body[:len(body):len(body)], '\n')
+			}
+
+			if _, err := oversized.DeadLetter.Write(line); err != nil && l.debugMode {
+				fmt.Printf("There was an error dead lettering an oversized event: %s", err)
+			}
+		}
+
+		message.resolve(ErrOversized)
+		l.stats.recordFiltered()
+		l.buildAndShipMessage("oversized event dropped", LogLevelWarn, false, report)
+
+		return ErrOversized
+	}
+
+	max := oversized.MaxBytes
+	if max == 0 {
+		max = defaultTruncateBytes
+	}
+
+	truncated := *message
+	truncated.Message = truncateString(message.Message, max)
+	truncated.Metadata = nil
+	truncated.Fields = map[string]interface{}{"truncated": true, "original_bytes": len(body)}
+	truncated.truncated = true
+
+	// The original takes its ack with it, the truncated copy resolves it.
+	message.ack = nil
+
+	b, err := json.Marshal(&truncated)
+
+	if err != nil {
+		truncated.resolve(err)
+		return err
+	}
+
+	// Bulk bodies end each event with a newline.
+	if body[len(body)-1] == '\n' {
+		b = append(b, '\n')
+	}
+
+	truncated.size = len(b)
+
+	l.buildAndShipMessage("oversized event truncated", LogLevelWarn, false, report)
+
+	return l.shipBatch(ctx, b, []*logMessage{&truncated})
+}
+
+// truncateString cuts s to at most max bytes without splitting a character.
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+
+	return s[:max]
+}
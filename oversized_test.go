@@ -0,0 +1,98 @@
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newOversizedServer(t *testing.T, limit int) (string, chan string) {
+	bodies := make(chan string, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+
+		if len(b) > limit {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		bodies <- string(b)
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL, bodies
+}
+
+func TestOversizedTruncate(t *testing.T) {
+	url, bodies := newOversizedServer(t, 1024)
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	l.url = url
+	l.synchronous = true
+	l.oversized = OversizedEvents{MaxBytes: 100}
+
+	ack := make(chan error, 1)
+	l.log(record{output: strings.Repeat("é", 1000), level: LogLevelInfo, ack: ack})
+
+	if err := <-ack; err != nil {
+		t.Errorf("expected the truncated event to ship, got %s", err)
+	}
+
+	if report := receive(t, bodies); !strings.Contains(report, "oversized event truncated") {
+		t.Errorf("unexpected report %q", report)
+	}
+
+	body := receive(t, bodies)
+
+	if !strings.Contains(body, `"message":"`+strings.Repeat("é", 50)+`"`) || !strings.Contains(body, `"truncated":true`) {
+		t.Errorf("unexpected truncated event %q", body)
+	}
+}
+
+func TestOversizedDeadLetter(t *testing.T) {
+	url, bodies := newOversizedServer(t, 1024)
+
+	var deadLetter bytes.Buffer
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, true, false)
+	l.url = url
+	l.synchronous = true
+	l.oversized = OversizedEvents{Action: OversizedDeadLetter, DeadLetter: &deadLetter}
+
+	ack := make(chan error, 1)
+	l.log(record{output: strings.Repeat("x", 2048), level: LogLevelInfo, ack: ack})
+	l.log(record{output: "This fits.", level: LogLevelInfo})
+	l.flush()
+
+	if err := <-ack; err != ErrOversized {
+		t.Errorf("expected ErrOversized, got %v", err)
+	}
+
+	if lines := strings.Count(deadLetter.String(), "\n"); lines != 1 || !strings.Contains(deadLetter.String(), "xxxx") {
+		t.Errorf("unexpected dead letters %q", deadLetter.String())
+	}
+
+	if body := receive(t, bodies); !strings.Contains(body, "This fits.") {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	l.flush()
+
+	if report := receive(t, bodies); !strings.Contains(report, "oversized event dropped") {
+		t.Errorf("unexpected report %q", report)
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	if s := truncateString("héllo", 2); s != "h" {
+		t.Errorf("expected the character to be kept whole, got %q", s)
+	}
+
+	if s := truncateString("hello", 10); s != "hello" {
+		t.Errorf("unexpected string %q", s)
+	}
+}
@@ -0,0 +1,109 @@
+package log
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultSubjectFields are the identifier fields pseudonymized when
+// SetSubjectPseudonymization is given none.
+var DefaultSubjectFields = []string{"user_id", "email"}
+
+// RotatingSalt is a KeyProvider holding a single random salt that is replaced
+// every period. Old salts are discarded, so subject pseudonyms join up
+// within a period but can't be traced back to a subject, or recomputed to
+// find one, afterwards.
+type RotatingSalt struct {
+	mu      sync.Mutex
+	period  time.Duration
+	clock   Clock
+	current Key
+	expires time.Time
+}
+
+// NewRotatingSalt creates a salt replaced every period, or only by Rotate if
+// period is zero.
+func NewRotatingSalt(period time.Duration) *RotatingSalt {
+	return &RotatingSalt{period: period, clock: systemClock{}}
+}
+
+// CurrentKey returns the salt of the current period, rotating it first if
+// the period has ended. Its ID is when the period started, in UTC.
+func (s *RotatingSalt) CurrentKey() (Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+
+	if s.current.Secret == nil || (s.period > 0 && !now.Before(s.expires)) {
+		if err := s.rotate(now); err != nil {
+			return Key{}, err
+		}
+	}
+
+	return s.current, nil
+}
+
+// KeyByID returns the current salt if it has id. Earlier salts are gone.
+func (s *RotatingSalt) KeyByID(id string) (Key, error) {
+	key, err := s.CurrentKey()
+
+	if err != nil {
+		return Key{}, err
+	}
+
+	if key.ID != id {
+		return Key{}, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+
+	return key, nil
+}
+
+// Rotate replaces the salt now, for rotating on an external schedule such as
+// a cron job or a data subject request.
+func (s *RotatingSalt) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rotate(s.clock.Now())
+}
+
+func (s *RotatingSalt) rotate(now time.Time) error {
+	secret := make([]byte, 32)
+
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+
+	s.current = Key{ID: now.UTC().Format("20060102T150405Z"), Secret: secret}
+	s.expires = now.Add(s.period)
+
+	return nil
+}
+
+// SetSubjectPseudonymization replaces the identifier fields of data
+// subjects, DefaultSubjectFields if none are given, with their HMAC under
+// salt before events are shipped or written to a sink. It applies on top of
+// SetSensitiveFields. Pass a nil salt to stop.
+func SetSubjectPseudonymization(salt *RotatingSalt, fields ...string) {
+	if len(fields) == 0 {
+		fields = DefaultSubjectFields
+	}
+
+	subjects := SensitiveFields{}
+
+	if salt != nil {
+		subjects.Keys = salt
+		subjects.Fields = map[string]SensitiveMode{}
+
+		for _, field := range fields {
+			subjects.Fields[field] = SensitivePseudonymize
+		}
+	}
+
+	loggerSingleton.Lock()
+	loggerSingleton.subjects = subjects
+	loggerSingleton.Unlock()
+}
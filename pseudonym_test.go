@@ -0,0 +1,68 @@
+package log
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRotatingSalt(t *testing.T) {
+	clock := NewManualClock(time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC))
+	salt := NewRotatingSalt(time.Hour)
+	salt.clock = clock
+
+	first, _ := salt.CurrentKey()
+
+	if first.ID != "20200301T120000Z" || len(first.Secret) != 32 {
+		t.Errorf("unexpected key %+v", first)
+	}
+
+	clock.Advance(30 * time.Minute)
+
+	if key, _ := salt.CurrentKey(); Pseudonym(key, "ada") != Pseudonym(first, "ada") {
+		t.Error("expected the salt kept within its period")
+	}
+
+	clock.Advance(30 * time.Minute)
+
+	second, _ := salt.CurrentKey()
+
+	if second.ID != "20200301T130000Z" || Pseudonym(second, "ada") == Pseudonym(first, "ada") {
+		t.Errorf("expected the salt rotated, got %+v", second)
+	}
+
+	if _, err := salt.KeyByID(first.ID); err == nil {
+		t.Error("expected the old salt discarded")
+	}
+
+	salt.Rotate()
+
+	if third, _ := salt.CurrentKey(); Pseudonym(third, "ada") == Pseudonym(second, "ada") {
+		t.Error("expected Rotate to replace the salt")
+	}
+}
+
+func TestSubjectPseudonymization(t *testing.T) {
+	salt := NewRotatingSalt(0)
+	key, _ := salt.CurrentKey()
+
+	l, bodies := newTestLogger(t, false)
+	l.subjects = SensitiveFields{Fields: map[string]SensitiveMode{"user_id": SensitivePseudonymize}, Keys: salt}
+
+	l.buildAndShipMessage("login", LogLevelInfo, false, map[string]interface{}{"user_id": 42, "plan": "pro"})
+
+	var message struct {
+		Metadata struct {
+			UserID string `json:"user_id"`
+			Plan   string
+		}
+	}
+
+	if err := json.Unmarshal([]byte(receive(t, bodies)), &message); err != nil {
+		t.Fatal(err)
+	}
+
+	if message.Metadata.UserID != Pseudonym(key, "42") || message.Metadata.Plan != "pro" {
+		t.Errorf("unexpected metadata %+v", message.Metadata)
+	}
+}
@@ -0,0 +1,88 @@
+package log
+
+// FlightRecorder configures the flight recorder, which keeps events below the
+// logger's level in memory and only ships them when an error occurs.
+type FlightRecorder struct {
+	// Size is how many of the most recent suppressed events are kept.
+	Size int
+
+	// Attach adds the recorded events to the Error or Fatal event as its
+	// "flight_recorder" field instead of shipping them as events of their own
+	// ahead of it.
+	Attach bool
+}
+
+// flightRecorderField is the field recorded events are attached under.
+const flightRecorderField = "flight_recorder"
+
+type flightRecorder struct {
+	config FlightRecorder
+	ring   *eventRing
+}
+
+// SetFlightRecorder enables the flight recorder, giving Error and Fatal
+// events the Debug and Trace context leading up to them without shipping
+// that volume all the time. A zero Size disables it.
+func SetFlightRecorder(config FlightRecorder) {
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	if config.Size <= 0 {
+		loggerSingleton.recorder = nil
+		return
+	}
+
+	loggerSingleton.recorder = &flightRecorder{config: config, ring: newEventRing(config.Size)}
+}
+
+// record keeps an event that was suppressed by the logger's level, with
+// shipped values so sensitive fields are protected before they are kept.
+func (l *logger) record(level Level, output string, d interface{}, fields map[string]interface{}) {
+	l.Lock()
+	recorder := l.recorder
+	l.Unlock()
+
+	if recorder == nil {
+		return
+	}
+
+	d, fields = l.shippedValues(d, fields)
+
+	recorder.ring.add(Event{Time: l.now(), Level: level, Message: output, Metadata: d, Fields: fields})
+}
+
+// replayRecorder drains the flight recorder for an Error or Fatal event,
+// shipping the recorded events or returning fields with them attached.
+func (l *logger) replayRecorder(fields map[string]interface{}) map[string]interface{} {
+	l.Lock()
+	recorder := l.recorder
+	l.Unlock()
+
+	if recorder == nil {
+		return fields
+	}
+
+	events := recorder.ring.drain()
+
+	if len(events) == 0 {
+		return fields
+	}
+
+	if recorder.config.Attach {
+		attached := make(map[string]interface{}, len(fields)+1)
+
+		for key, value := range fields {
+			attached[key] = value
+		}
+
+		attached[flightRecorderField] = events
+
+		return attached
+	}
+
+	for _, e := range events {
+		l.ship(l.logglyMessage(e), e.Level)
+	}
+
+	return fields
+}
@@ -0,0 +1,49 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFlightRecorderShips(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.Level = LogLevelInfo
+	l.recorder = &flightRecorder{config: FlightRecorder{Size: 2}, ring: newEventRing(2)}
+
+	l.buildAndShipMessage("step 1", LogLevelDebug, false, nil)
+	l.buildAndShipMessage("step 2", LogLevelDebug, false, nil)
+	l.buildAndShipMessage("step 3", LogLevelTrace, false, map[string]interface{}{"step": 3})
+	l.buildAndShipMessage("This is an error.", LogLevelError, false, nil)
+
+	for _, want := range []string{`"message":"step 2"`, `"message":"step 3","metadata":{"step":3}`, `"message":"This is an error."`} {
+		if body := receive(t, bodies); !strings.Contains(body, want) {
+			t.Errorf("expected %s in %q", want, body)
+		}
+	}
+
+	// The recorder is emptied once replayed.
+	l.buildAndShipMessage("This is another error.", LogLevelError, false, nil)
+
+	if body := receive(t, bodies); !strings.Contains(body, "another error") {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestFlightRecorderAttaches(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.Level = LogLevelInfo
+	l.recorder = &flightRecorder{config: FlightRecorder{Size: 10, Attach: true}, ring: newEventRing(10)}
+
+	l.buildAndShipMessage("cache miss", LogLevelDebug, false, nil)
+	l.buildAndShipMessage("This is a warning.", LogLevelWarn, false, nil)
+
+	if body := receive(t, bodies); strings.Contains(body, "cache miss") {
+		t.Errorf("expected recorded events to wait for an error, got %q", body)
+	}
+
+	l.buildAndShipMessage("This is an error.", LogLevelError, false, nil)
+
+	if body := receive(t, bodies); !strings.Contains(body, `"flight_recorder":[{"timestamp":`) || !strings.Contains(body, `"message":"cache miss"`) {
+		t.Errorf("unexpected body %q", body)
+	}
+}
@@ -0,0 +1,165 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// SinkFactory builds a sink from its configuration options, as decoded from
+// JSON or YAML.
+type SinkFactory func(options map[string]interface{}) (Sink, error)
+
+// CodecFactory builds a codec from its configuration options.
+type CodecFactory func(options map[string]interface{}) (Codec, error)
+
+// SinkConfig declares a sink in configuration.
+type SinkConfig struct {
+	// Name is what the sink is registered under, for SetFieldFilter.
+	Name string `json:"name"`
+
+	// Type names the factory registered with RegisterSink.
+	Type string `json:"type"`
+
+	Options map[string]interface{} `json:"options"`
+}
+
+var registry = struct {
+	sync.Mutex
+	sinks  map[string]SinkFactory
+	codecs map[string]CodecFactory
+}{
+	sinks:  map[string]SinkFactory{"file": newFileSink},
+	codecs: map[string]CodecFactory{},
+}
+
+// RegisterSink makes a sink type available to NewSink and AddSinks, so other
+// modules can contribute sinks without this package importing their
+// dependencies. It is meant to be called from an init function and panics if
+// the type is already registered.
+func RegisterSink(kind string, factory SinkFactory) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.sinks[kind]; ok {
+		panic("log: sink type " + kind + " registered twice")
+	}
+
+	registry.sinks[kind] = factory
+}
+
+// RegisterCodec makes a codec available to sinks configured with a "codec"
+// option. It panics if the name is already registered.
+func RegisterCodec(name string, factory CodecFactory) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.codecs[name]; ok {
+		panic("log: codec " + name + " registered twice")
+	}
+
+	registry.codecs[name] = factory
+}
+
+// SinkTypes returns the registered sink types, sorted.
+func SinkTypes() []string {
+	registry.Lock()
+	defer registry.Unlock()
+
+	kinds := make([]string, 0, len(registry.sinks))
+	for kind := range registry.sinks {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Strings(kinds)
+
+	return kinds
+}
+
+// NewSink builds a sink of a registered type.
+func NewSink(kind string, options map[string]interface{}) (Sink, error) {
+	registry.Lock()
+	factory, ok := registry.sinks[kind]
+	registry.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown sink type %q", kind)
+	}
+
+	return factory(options)
+}
+
+// NewCodec builds a registered codec.
+func NewCodec(name string, options map[string]interface{}) (Codec, error) {
+	registry.Lock()
+	factory, ok := registry.codecs[name]
+	registry.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown codec %q", name)
+	}
+
+	return factory(options)
+}
+
+// AddSinks builds and registers the declared sinks. Nothing is added unless
+// every sink builds.
+func AddSinks(configs []SinkConfig) error {
+	return loggerSingleton.addSinks(configs)
+}
+
+func (l *logger) addSinks(configs []SinkConfig) error {
+	sinks := make([]namedSink, len(configs))
+
+	for i, config := range configs {
+		sink, err := NewSink(config.Type, config.Options)
+
+		if err != nil {
+			return fmt.Errorf("sink %s: %s", config.Name, err)
+		}
+
+		sinks[i] = newNamedSink(config.Name, sink)
+	}
+
+	l.Lock()
+	l.sinks = append(l.sinks, sinks...)
+	l.Unlock()
+
+	return nil
+}
+
+// decodeOptions decodes configuration options into the struct v.
+func decodeOptions(options map[string]interface{}, v interface{}) error {
+	b, err := json.Marshal(options)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// newFileSink builds a WriterSink appending to the "path" option.
+func newFileSink(options map[string]interface{}) (Sink, error) {
+	var config struct {
+		Path string `json:"path"`
+	}
+
+	if err := decodeOptions(options, &config); err != nil {
+		return nil, err
+	}
+
+	if config.Path == "" {
+		return nil, fmt.Errorf("file sink requires a path")
+	}
+
+	f, err := os.OpenFile(config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWriterSink(f), nil
+}
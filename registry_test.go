@@ -0,0 +1,71 @@
+package log
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegisterSink(t *testing.T) {
+	memory := &memorySink{}
+
+	RegisterSink("memory-test", func(options map[string]interface{}) (Sink, error) {
+		return memory, nil
+	})
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering a type twice to panic")
+		}
+	}()
+
+	if sink, err := NewSink("memory-test", nil); err != nil || sink != memory {
+		t.Errorf("unexpected sink %v: %v", sink, err)
+	}
+
+	if _, err := NewSink("kafka", nil); err == nil {
+		t.Error("expected an unknown sink type to be refused")
+	}
+
+	RegisterSink("memory-test", nil)
+}
+
+func TestAddSinks(t *testing.T) {
+	l, _ := newTestLogger(t, false)
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	err := l.addSinks([]SinkConfig{{Name: "archive", Type: "file", Options: map[string]interface{}{"path": path}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.addSinks([]SinkConfig{{Name: "broken", Type: "file"}}); err == nil || !strings.Contains(err.Error(), "sink broken") {
+		t.Errorf("expected a file sink without a path to be refused, got %v", err)
+	}
+
+	if len(l.sinks) != 1 || l.sinks[0].name != "archive" {
+		t.Fatalf("unexpected sinks %+v", l.sinks)
+	}
+
+	l.buildAndShipMessage("This is archived.", LogLevelInfo, false, nil)
+
+	if b, _ := ioutil.ReadFile(path); !strings.Contains(string(b), "This is archived.") {
+		t.Errorf("unexpected file contents %q", b)
+	}
+}
+
+func TestNewCodec(t *testing.T) {
+	codec, err := NewCodec("gzip", map[string]interface{}{"level": 9})
+	if err != nil || codec.Encoding() != "gzip" {
+		t.Fatalf("unexpected codec %v: %v", codec, err)
+	}
+
+	if _, err := NewCodec("gzip", map[string]interface{}{"level": 42}); err == nil {
+		t.Error("expected an invalid level to be refused")
+	}
+
+	if _, err := NewCodec("zstd", nil); err == nil {
+		t.Error("expected an unknown codec to be refused")
+	}
+}
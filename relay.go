@@ -0,0 +1,143 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// relayWriteTimeout bounds a write to the relay when the context has no
+// deadline.
+const relayWriteTimeout = 10 * time.Second
+
+// SetRelay ships to a local agent instead of Loggly, one JSON event per line,
+// leaving the WAN hop to the agent. The address is either
+// "unix:///path/to/socket" or "tcp://host:port". Pass an empty string to ship
+// to Loggly again.
+//
+// A "codec" query parameter, such as "tcp://host:port?codec=gzip", names a
+// registered codec compressing each batch on its own before it is written.
+// Codecs whose frames concatenate into a valid stream, as gzip members do,
+// let the agent decompress the connection as a single stream.
+func SetRelay(address string) error {
+	var r *relay
+
+	if address != "" {
+		var err error
+
+		if r, err = newRelay(address); err != nil {
+			return err
+		}
+	}
+
+	loggerSingleton.Lock()
+	previous := loggerSingleton.relay
+	loggerSingleton.relay = r
+	loggerSingleton.Unlock()
+
+	if previous != nil {
+		previous.close()
+	}
+
+	return nil
+}
+
+// relay ships NDJSON over a persistent stream connection, reconnecting after
+// failures.
+type relay struct {
+	sync.Mutex
+	network string
+	address string
+	dialer  *net.Dialer
+	conn    net.Conn
+
+	// codec, if set, compresses each batch.
+	codec Codec
+}
+
+func newRelay(address string) (*relay, error) {
+	u, err := url.Parse(address)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid relay address %q: %v", address, err)
+	}
+
+	var r *relay
+
+	switch u.Scheme {
+	case "unix":
+		r = &relay{network: "unix", address: u.Path, dialer: &net.Dialer{}}
+	case "tcp":
+		r = &relay{network: "tcp", address: u.Host, dialer: &net.Dialer{}}
+	default:
+		return nil, fmt.Errorf("unsupported relay scheme %q, expected unix or tcp", u.Scheme)
+	}
+
+	if name := u.Query().Get("codec"); name != "" {
+		if r.codec, err = NewCodec(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// send writes body, terminated by a newline, to the relay.
+func (r *relay) send(ctx context.Context, body []byte) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.conn == nil {
+		conn, err := r.dialer.DialContext(ctx, r.network, r.address)
+
+		if err != nil {
+			return err
+		}
+
+		r.conn = conn
+	}
+
+	deadline, ok := ctx.Deadline()
+
+	if !ok {
+		deadline = time.Now().Add(relayWriteTimeout)
+	}
+
+	r.conn.SetWriteDeadline(deadline)
+
+	if !bytes.HasSuffix(body, []byte("\n")) {
+		body = append(body[:len(body):len(body)], '\n')
+	}
+
+	if r.codec != nil {
+		var err error
+
+		if body, err = r.codec.Compress(body); err != nil {
+			return err
+		}
+	}
+
+	if _, err := r.conn.Write(body); err != nil {
+		// A partial write leaves the stream mid-line, start over on a fresh
+		// connection so the relay sees whole events.
+		r.conn.Close()
+		r.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+func (r *relay) close() {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.conn != nil {
+		r.conn.Close()
+		r.conn = nil
+	}
+}
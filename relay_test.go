@@ -0,0 +1,141 @@
+package log
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func listenRelay(t *testing.T, network, address string) (net.Listener, chan string) {
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	lines := make(chan string, 10)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+		}
+	}()
+
+	return listener, lines
+}
+
+func TestUnixRelay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "relay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "relay.sock")
+	_, lines := listenRelay(t, "unix", socket)
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, true, false)
+	l.synchronous = true
+
+	if l.relay, err = newRelay("unix://" + socket); err != nil {
+		t.Fatal(err)
+	}
+
+	l.buildAndShipMessage("This is an info statement 1.", LogLevelInfo, false, nil)
+	l.buildAndShipMessage("This is an info statement 2.", LogLevelInfo, false, nil)
+	l.flush()
+
+	for i := 1; i <= 2; i++ {
+		if line := <-lines; !strings.Contains(line, "info statement") {
+			t.Errorf("unexpected line %q", line)
+		}
+	}
+}
+
+func TestTCPRelay(t *testing.T) {
+	listener, lines := listenRelay(t, "tcp", "127.0.0.1:0")
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	l.synchronous = true
+
+	var err error
+	if l.relay, err = newRelay("tcp://" + listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+
+	l.buildAndShipMessage("This is relayed.", LogLevelInfo, false, nil)
+
+	if line := <-lines; !strings.Contains(line, `"message":"This is relayed."`) {
+		t.Errorf("unexpected line %q", line)
+	}
+}
+
+func TestRelayAddress(t *testing.T) {
+	if _, err := newRelay("http://localhost"); err == nil {
+		t.Error("expected an error for an unsupported scheme")
+	}
+}
+
+func TestRelayCodec(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	lines := make(chan string, 10)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		// Batches compressed one by one read back as a single gzip stream.
+		r, err := gzip.NewReader(conn)
+		if err != nil {
+			return
+		}
+
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	l.synchronous = true
+
+	if l.relay, err = newRelay("tcp://" + listener.Addr().String() + "?codec=gzip"); err != nil {
+		t.Fatal(err)
+	}
+
+	l.buildAndShipMessage("This is compressed 1.", LogLevelInfo, false, nil)
+	l.buildAndShipMessage("This is compressed 2.", LogLevelInfo, false, nil)
+
+	for i := 1; i <= 2; i++ {
+		if line := <-lines; !strings.Contains(line, fmt.Sprintf(`"message":"This is compressed %d."`, i)) {
+			t.Errorf("unexpected line %q", line)
+		}
+	}
+
+	if _, err := newRelay("tcp://127.0.0.1:1?codec=unknown"); err == nil {
+		t.Error("expected an error for an unknown codec")
+	}
+}
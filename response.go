@@ -0,0 +1,62 @@
+package log
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxErrorBody bounds how much of an error response's body is kept.
+const maxErrorBody = 1024
+
+// ResponseError is returned when an endpoint answers with an unexpected
+// status. Loggly's error bodies explain problems such as a bad tag or an
+// oversized event.
+type ResponseError struct {
+	StatusCode int
+	Status     string
+
+	// Body is the start of the response body, at most 1KB.
+	Body string
+
+	// RetryAfter is how long the endpoint asked clients to wait before
+	// trying again, zero if it didn't say.
+	RetryAfter time.Duration
+
+	// err is the sentinel the status maps to, such as ErrInvalidToken.
+	err error
+}
+
+func (e *ResponseError) Error() string {
+	message := "unexpected response: " + e.Status
+
+	if e.err != nil {
+		message = e.err.Error() + ": " + e.Status
+	}
+
+	if e.Body != "" {
+		message += ": " + e.Body
+	}
+
+	return message
+}
+
+// Unwrap returns the sentinel error for the status, if any.
+func (e *ResponseError) Unwrap() error {
+	return e.err
+}
+
+// newResponseError reads the start of resp's body into a ResponseError.
+func newResponseError(resp *http.Response, err error) *ResponseError {
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	return &ResponseError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       strings.TrimSpace(string(b)),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		err:        err,
+	}
+}
@@ -0,0 +1,59 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseErrorBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid tag\n" + strings.Repeat("x", 2*maxErrorBody)))
+	}))
+	defer server.Close()
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+
+	err := l.post(context.Background(), server.URL, []byte("{}"))
+
+	var responseErr *ResponseError
+
+	if !errors.As(err, &responseErr) {
+		t.Fatalf("expected a ResponseError, got %v", err)
+	}
+
+	if responseErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("unexpected status code %d", responseErr.StatusCode)
+	}
+
+	if !strings.HasPrefix(responseErr.Body, "invalid tag") || len(responseErr.Body) > maxErrorBody {
+		t.Errorf("unexpected body %q", responseErr.Body)
+	}
+
+	if !strings.Contains(err.Error(), "400 Bad Request: invalid tag") {
+		t.Errorf("unexpected error %q", err)
+	}
+}
+
+func TestResponseErrorForbidden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown customer token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+
+	err := l.post(context.Background(), server.URL, []byte("{}"))
+
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+
+	if !strings.HasSuffix(err.Error(), "403 Forbidden: unknown customer token") {
+		t.Errorf("unexpected error %q", err)
+	}
+}
@@ -0,0 +1,118 @@
+package log
+
+import (
+	"encoding/json"
+)
+
+// Retention classes for the retention field, matching Loggly retention tiers.
+const (
+	RetentionShort    = "short"
+	RetentionStandard = "standard"
+	RetentionAudit    = "audit"
+)
+
+// retentionField is the top level field carrying an event's retention class.
+const retentionField = "retention"
+
+// WithRetention returns an Entry whose messages carry the retention class,
+// which SetRetentionRoute can route to a separate Loggly input.
+func WithRetention(class string) *Entry {
+	return &Entry{fields: map[string]interface{}{retentionField: class}}
+}
+
+// WithRetention returns a copy of the entry whose messages carry the
+// retention class.
+func (e *Entry) WithRetention(class string) *Entry {
+	return e.With(retentionField, class)
+}
+
+// SetRetentionRoute ships events of a retention class to the input for token
+// and tags instead of the default one, so each class can land in a Loggly
+// subdomain with matching retention. An empty token keeps the logger's.
+func SetRetentionRoute(class, token string, tags []string) error {
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	if token == "" {
+		token = loggerSingleton.token
+	}
+
+	if err := validateToken(token); err != nil {
+		return err
+	}
+
+	if loggerSingleton.routes == nil {
+		loggerSingleton.routes = map[string]route{}
+	}
+
+	loggerSingleton.routes[class] = route{url: inputURL(loggerSingleton.bulk, token, tags), tags: tags}
+
+	return nil
+}
+
+// InputURLs returns the endpoints events ship to, keyed by retention class
+// with the default input under "", for debugging. They contain the tokens.
+func InputURLs() map[string]string {
+	return loggerSingleton.inputURLs()
+}
+
+func (l *logger) inputURLs() map[string]string {
+	l.Lock()
+	defer l.Unlock()
+
+	urls := map[string]string{"": l.url}
+
+	for class, r := range l.routes {
+		urls[class] = r.url
+	}
+
+	return urls
+}
+
+// route is the input a retention class ships to.
+type route struct {
+	url  string
+	tags []string
+}
+
+// route returns the route for the retention class in fields, ok is false for
+// the default input.
+func (l *logger) route(fields map[string]interface{}) (route, bool) {
+	class, ok := fields[retentionField].(string)
+
+	if !ok {
+		return route{}, false
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	r, ok := l.routes[class]
+
+	return r, ok
+}
+
+// payloadURL returns the endpoint for a shipped message, so spooled messages
+// replay to the input they were routed to.
+func (l *logger) payloadURL(payload []byte) string {
+	l.Lock()
+	routes := len(l.routes)
+	url := l.url
+	l.Unlock()
+
+	if routes == 0 {
+		return url
+	}
+
+	var fields map[string]interface{}
+
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return url
+	}
+
+	if r, ok := l.route(fields); ok {
+		return r.url
+	}
+
+	return url
+}
@@ -0,0 +1,108 @@
+package log
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRetentionRouting(t *testing.T) {
+	paths := make(chan string, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		paths <- r.URL.Path + " " + string(b)
+	}))
+	defer server.Close()
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, true, false)
+	l.url = server.URL + "/default"
+	l.synchronous = true
+	l.routes = map[string]route{RetentionAudit: {url: server.URL + "/audit"}}
+
+	(&Entry{logger: l}).WithRetention(RetentionAudit).Infoln("This is an audit event.")
+	(&Entry{logger: l}).Infoln("This is a regular event.")
+	(&Entry{logger: l}).WithRetention(RetentionShort).Infoln("This has no route.")
+	l.flush()
+
+	audit, regular := <-paths, <-paths
+
+	if !strings.HasPrefix(audit, "/audit ") || !strings.Contains(audit, `"retention":"audit"`) || strings.Contains(audit, "regular") {
+		t.Errorf("unexpected audit request %q", audit)
+	}
+
+	if !strings.HasPrefix(regular, "/default ") || !strings.Contains(regular, "regular event") || !strings.Contains(regular, "no route") {
+		t.Errorf("unexpected default request %q", regular)
+	}
+}
+
+func TestRetentionPayloadURL(t *testing.T) {
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, true, false)
+	l.routes = map[string]route{RetentionAudit: {url: "https://audit"}}
+
+	if url := l.payloadURL([]byte(`{"message":"x","retention":"audit"}`)); url != "https://audit" {
+		t.Errorf("expected the audit route, got %s", url)
+	}
+
+	if url := l.payloadURL([]byte(`{"message":"x"}`)); url != l.url {
+		t.Errorf("expected the default endpoint, got %s", url)
+	}
+}
+
+func TestInputURL(t *testing.T) {
+	if url := inputURL(true, "token", []string{"a", "b"}); url != "https://logs-01.loggly.com/bulk/token/tag/a,b/" {
+		t.Errorf("unexpected bulk url %s", url)
+	}
+
+	if url := inputURL(false, "token", nil); url != "https://logs-01.loggly.com/inputs/token/" {
+		t.Errorf("unexpected url %s", url)
+	}
+
+	if url := inputURL(true, "token", []string{"a b", "c,d", "e/f"}); url != "https://logs-01.loggly.com/bulk/token/tag/a%20b,c%2Cd,e%2Ff/" {
+		t.Errorf("expected tags to be percent encoded, got %s", url)
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	if err := validateToken("8c0f1e2a-6b1d-4c41-9a0e-2f2a0c5b7d11"); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	for _, token := range []string{"", "token/with/slashes", "token?x=1"} {
+		if err := validateToken(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken for %q, got %v", token, err)
+		}
+	}
+}
+
+func TestMalformedTokenFailsFast(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.tokenErr = validateToken("not a token")
+
+	ack := make(chan error, 1)
+	l.log(record{output: "This is never sent.", level: LogLevelInfo, ack: ack})
+
+	if err := <-ack; !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected body %q", body)
+	default:
+	}
+}
+
+func TestInputURLs(t *testing.T) {
+	l, _ := newTestLogger(t, true)
+	l.routes = map[string]route{RetentionAudit: {url: inputURL(true, "audittoken", []string{"audit"})}}
+
+	urls := l.inputURLs()
+
+	if urls[""] != l.url || urls[RetentionAudit] != "https://logs-01.loggly.com/bulk/audittoken/tag/audit/" {
+		t.Errorf("unexpected urls %v", urls)
+	}
+}
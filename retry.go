@@ -0,0 +1,286 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// Retry configures how failed shipments are retried. Rate limited (429) and
+// server error (5xx) responses are retried, as are network errors, honouring
+// any Retry-After header.
+type Retry struct {
+	// Attempts is the most deliveries tried per batch, 1 if zero.
+	Attempts int
+
+	// Min and Max bound the exponential backoff between attempts, 1 second
+	// and 30 seconds if zero.
+	Min time.Duration
+	Max time.Duration
+
+	// Jitter is the fraction, up to 1, of each backoff randomly taken off it
+	// so that many instances failing together don't retry in step.
+	Jitter float64
+}
+
+// DefaultRetry tries transient failures five times, backing off up to 15
+// seconds in all, to ride out a short Loggly outage.
+var DefaultRetry = Retry{Attempts: 5, Min: time.Second, Max: 30 * time.Second, Jitter: 0.5}
+
+// CircuitBreaker configures how long shipping pauses after Loggly rejects the
+// token with a 401 or 403. Authentication failures don't resolve themselves,
+// so rather than sending every event to be rejected the circuit opens and
+// shipments fail fast with ErrCircuitOpen, spooling if a spool is set, until
+// Cooldown passes and a single shipment tests the token again.
+type CircuitBreaker struct {
+	// Cooldown is how long the circuit stays open, 1 minute if zero.
+	Cooldown time.Duration
+}
+
+// ErrCircuitOpen is returned for shipments attempted while the circuit is
+// open after an authentication failure.
+var ErrCircuitOpen = errors.New("loggly circuit open")
+
+// statusClass is how the shipper treats a response status.
+type statusClass int
+
+const (
+	// statusSuccess is any 2xx.
+	statusSuccess statusClass = iota
+
+	// statusRetry is 429 and 5xx, worth another attempt after a backoff.
+	statusRetry
+
+	// statusSplit is 413, the batch is halved and each half shipped.
+	statusSplit
+
+	// statusAuth is 401 and 403, which open the circuit.
+	statusAuth
+
+	// statusReject is any other status, which no retry will fix.
+	statusReject
+)
+
+func classifyStatus(code int) statusClass {
+	switch {
+	case code >= 200 && code <= 299:
+		return statusSuccess
+	case code == 429 || (code >= 500 && code <= 599):
+		return statusRetry
+	case code == 413:
+		return statusSplit
+	case code == 401 || code == 403:
+		return statusAuth
+	default:
+		return statusReject
+	}
+}
+
+// SetRetry sets how failed shipments are retried. By default each batch is
+// attempted once, see DefaultRetry.
+func SetRetry(retry Retry) {
+	loggerSingleton.Lock()
+	loggerSingleton.retry = retry
+	loggerSingleton.Unlock()
+}
+
+// SetCircuitBreaker sets how long shipping pauses after an authentication
+// failure.
+func SetCircuitBreaker(breaker CircuitBreaker) {
+	loggerSingleton.Lock()
+	loggerSingleton.circuit.config = breaker
+	loggerSingleton.Unlock()
+}
+
+type circuit struct {
+	config    CircuitBreaker
+	openUntil time.Time
+	cause     error
+}
+
+// circuitErr returns ErrCircuitOpen while the circuit is open.
+func (l *logger) circuitErr() error {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.circuit.cause == nil || !l.clock.Now().Before(l.circuit.openUntil) {
+		return nil
+	}
+
+	return fmt.Errorf("%w until %s: %s", ErrCircuitOpen, l.circuit.openUntil.Format(time.RFC3339), l.circuit.cause)
+}
+
+// observeStatus opens the circuit on an authentication failure and closes it
+// on any other response.
+func (l *logger) observeStatus(class statusClass, err error) {
+	l.Lock()
+	defer l.Unlock()
+
+	if class != statusAuth {
+		l.circuit.cause = nil
+		return
+	}
+
+	cooldown := l.circuit.config.Cooldown
+	if cooldown == 0 {
+		cooldown = time.Minute
+	}
+
+	l.circuit.cause = err
+	l.circuit.openUntil = l.clock.Now().Add(cooldown)
+}
+
+// postRetrying posts body, retrying under the retry policy, and returns the
+// number of attempts made along with the last error.
+func (l *logger) postRetrying(ctx context.Context, url string, body []byte) (int, error) {
+	l.Lock()
+	retry := l.retry
+	l.Unlock()
+
+	if retry.Min == 0 {
+		retry.Min = time.Second
+	}
+
+	if retry.Max == 0 {
+		retry.Max = 30 * time.Second
+	}
+
+	backoff := retry.Min
+	attempt := 1
+
+	for {
+		err := l.post(ctx, url, body)
+
+		if err == nil || attempt >= retry.Attempts {
+			return attempt, err
+		}
+
+		wait, ok := retryDelay(err)
+
+		if !ok {
+			return attempt, err
+		}
+
+		if delay := jitter(backoff, retry.Jitter); wait < delay {
+			wait = delay
+		}
+
+		if !sleepContext(ctx, wait) {
+			return attempt, err
+		}
+
+		if backoff *= 2; backoff > retry.Max {
+			backoff = retry.Max
+		}
+
+		attempt++
+	}
+}
+
+// jitter takes a random part of up to fraction off d.
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+
+	if fraction > 1 {
+		fraction = 1
+	}
+
+	return d - time.Duration(rand.Float64()*fraction*float64(d))
+}
+
+// retryDelay reports whether err is worth retrying and how long the endpoint
+// asked to wait first.
+func retryDelay(err error) (time.Duration, bool) {
+	var responseErr *ResponseError
+
+	if errors.As(err, &responseErr) {
+		return responseErr.RetryAfter, classifyStatus(responseErr.StatusCode) == statusRetry
+	}
+
+	// Anything else was refused before a request was made, or is a network
+	// error that may well pass.
+	switch {
+	case errors.Is(err, ErrCircuitOpen), errors.Is(err, ErrInvalidToken):
+		return 0, false
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return 0, false
+	}
+
+	return 0, true
+}
+
+// spoolable reports whether a shipment that failed with err is worth keeping
+// for replay: errors retryDelay retries, authentication failures and the open
+// circuit following them, which pass once the credentials are fixed, and
+// shipments cut short by their context. Payloads the endpoint rejects and an
+// invalid token would only fail again.
+func spoolable(err error) bool {
+	if _, retry := retryDelay(err); retry {
+		return true
+	}
+
+	var responseErr *ResponseError
+
+	if errors.As(err, &responseErr) {
+		return classifyStatus(responseErr.StatusCode) == statusAuth
+	}
+
+	return errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP
+// date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := time.Parse(time.RFC1123, value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
+
+// splitBatch halves a bulk batch rejected as too large, using each message's
+// encoded size to cut body. It reports false if the batch can't be split.
+func splitBatch(err error, body []byte, messages []*logMessage) ([][]byte, [][]*logMessage, bool) {
+	var responseErr *ResponseError
+
+	if len(messages) < 2 || !errors.As(err, &responseErr) || classifyStatus(responseErr.StatusCode) != statusSplit {
+		return nil, nil, false
+	}
+
+	half := len(messages) / 2
+	cut := 0
+
+	for i, m := range messages {
+		if i == half {
+			break
+		}
+
+		cut += m.size
+	}
+
+	total := cut
+	for _, m := range messages[half:] {
+		total += m.size
+	}
+
+	// Sizes that don't add up mean the body wasn't built from these messages
+	// alone.
+	if total != len(body) {
+		return nil, nil, false
+	}
+
+	return [][]byte{body[:cut], body[cut:]}, [][]*logMessage{messages[:half], messages[half:]}, true
+}
@@ -0,0 +1,193 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClassifyStatus(t *testing.T) {
+	tests := map[int]statusClass{
+		200: statusSuccess,
+		202: statusSuccess,
+		204: statusSuccess,
+		400: statusReject,
+		401: statusAuth,
+		403: statusAuth,
+		404: statusReject,
+		413: statusSplit,
+		429: statusRetry,
+		500: statusRetry,
+		503: statusRetry,
+	}
+
+	for code, expected := range tests {
+		if class := classifyStatus(code); class != expected {
+			t.Errorf("expected class %d for %d, got %d", expected, code, class)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	l.url = server.URL
+	l.synchronous = true
+	l.retry = Retry{Attempts: 5, Min: time.Millisecond, Max: time.Millisecond}
+
+	var shipped []BatchInfo
+	l.onShipped = append(l.onShipped, func(info BatchInfo) {
+		shipped = append(shipped, info)
+	})
+
+	l.buildAndShipMessage("This ships on the third attempt.", LogLevelInfo, false, nil)
+
+	if len(shipped) != 1 || shipped[0].Attempt != 3 {
+		t.Errorf("expected 1 batch shipped on attempt 3, got %+v", shipped)
+	}
+}
+
+func TestRetrySkipsRejections(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	l.retry = Retry{Attempts: 5, Min: time.Millisecond}
+
+	attempt, err := l.postRetrying(context.Background(), server.URL, []byte("{}"))
+
+	if err == nil || attempt != 1 || atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("expected a single failed attempt, got %d attempts: %v", attempt, err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"Wed, 01 Jan 2020 00:00:30 GMT": 30 * time.Second,
+		"Tue, 31 Dec 2019 23:00:00 GMT": 0,
+		"soon":                          0,
+	}
+
+	for value, expected := range tests {
+		if wait := parseRetryAfter(value, now); wait != expected {
+			t.Errorf("expected %s for %q, got %s", expected, value, wait)
+		}
+	}
+}
+
+func TestCircuitOpensOnAuthFailure(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	l.clock = clock
+	l.circuit.config = CircuitBreaker{Cooldown: time.Minute}
+
+	if err := l.post(context.Background(), server.URL, []byte("{}")); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+
+	if err := l.post(context.Background(), server.URL, []byte("{}")); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("expected 1 request while the circuit is open, got %d", n)
+	}
+
+	clock.Advance(time.Minute)
+
+	if err := l.post(context.Background(), server.URL, []byte("{}")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected the token to be tested again, got %v", err)
+	}
+}
+
+func TestSplitOversizedBatch(t *testing.T) {
+	bodies := make(chan string, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+
+		if strings.Count(string(b), "\n") > 1 {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		bodies <- string(b)
+	}))
+	defer server.Close()
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, true, false)
+	l.url = server.URL
+	l.synchronous = true
+
+	acks := make([]chan error, 3)
+
+	for i := range acks {
+		acks[i] = make(chan error, 1)
+		l.log(record{output: "This is split.", level: LogLevelInfo, ack: acks[i]})
+	}
+
+	if err := l.flushContext(context.Background()); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	for _, ack := range acks {
+		if err := <-ack; err != nil {
+			t.Errorf("expected successful delivery, got %s", err)
+		}
+	}
+
+	if len(bodies) != 3 {
+		t.Errorf("expected 3 requests, got %d", len(bodies))
+	}
+}
+
+func TestJitter(t *testing.T) {
+	if d := jitter(time.Second, 0); d != time.Second {
+		t.Errorf("expected no jitter, got %s", d)
+	}
+
+	for i := 0; i < 100; i++ {
+		if d := jitter(time.Second, 0.5); d < 500*time.Millisecond || d > time.Second {
+			t.Fatalf("jittered backoff %s out of range", d)
+		}
+
+		if d := jitter(time.Second, 2); d < 0 || d > time.Second {
+			t.Fatalf("fully jittered backoff %s out of range", d)
+		}
+	}
+}
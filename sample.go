@@ -0,0 +1,70 @@
+package log
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+)
+
+// DefaultSampleKeys are the fields sampling decisions are keyed by unless
+// SetSampleKeys is called.
+var DefaultSampleKeys = []string{"trace_id", "request_id"}
+
+// SetSampleKeys makes sampling, by sample filter rules and budgets, decide
+// per value of the first of keys an event carries, in its metadata or its
+// fields, rather than per event. Either all or none of a request's events
+// are kept, and since the decision is a hash of the value, every instance
+// logging a trace agrees on it. An event carrying none of the keys is
+// sampled on its own. Pass no keys to sample every event on its own.
+func SetSampleKeys(keys ...string) {
+	loggerSingleton.Lock()
+	loggerSingleton.sampleKeys = append([]string(nil), keys...)
+	loggerSingleton.Unlock()
+}
+
+// sampled reports whether an event is kept by sampling at rate, keyed by
+// the first of keys it carries.
+func sampled(rate float64, keys []string, d interface{}, fields map[string]interface{}) bool {
+	if rate >= 1 {
+		return true
+	}
+
+	for _, key := range keys {
+		if value, ok := sampleKey(d, fields, key); ok {
+			return sampleFraction(value) < rate
+		}
+	}
+
+	return rand.Float64() < rate
+}
+
+func sampleKey(d interface{}, fields map[string]interface{}, key string) (string, bool) {
+	if value, ok := fieldValue(d, key); ok && value != "" {
+		return value, true
+	}
+
+	if value, ok := fields[key]; ok && value != nil && value != "" {
+		return fmt.Sprint(value), true
+	}
+
+	return "", false
+}
+
+// sampleFraction maps a key to a fraction between 0 and 1. Lower rates keep
+// a subset of the keys higher rates keep.
+func sampleFraction(key string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+
+	// FNV leaves the high bits of similar keys, such as sequential request
+	// IDs, close together, so mix them before scaling.
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return float64(x) / (math.MaxUint64 + 1.0)
+}
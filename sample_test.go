@@ -0,0 +1,57 @@
+package log
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSampledByKey(t *testing.T) {
+	keys := DefaultSampleKeys
+	kept := 0
+
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("req-%d", i)
+		decision := sampled(0.3, keys, map[string]string{"request_id": id}, nil)
+
+		// Every event of a request gets the same decision, wherever the key is.
+		for j := 0; j < 3; j++ {
+			if sampled(0.3, keys, nil, map[string]interface{}{"request_id": id}) != decision {
+				t.Fatalf("inconsistent decision for %s", id)
+			}
+		}
+
+		// Requests kept at a lower rate are kept at higher ones.
+		if decision && !sampled(0.6, keys, nil, map[string]interface{}{"request_id": id}) {
+			t.Fatalf("%s kept at 0.3 but not 0.6", id)
+		}
+
+		if decision {
+			kept++
+		}
+	}
+
+	if kept < 250 || kept > 350 {
+		t.Errorf("expected about 300 requests kept, got %d", kept)
+	}
+
+	if !sampled(1, keys, nil, map[string]interface{}{"trace_id": "abc"}) || sampled(0, keys, nil, map[string]interface{}{"trace_id": "abc"}) {
+		t.Error("expected rates of 1 and 0 to keep and drop everything")
+	}
+}
+
+func TestSampleRuleKeepsWholeRequests(t *testing.T) {
+	l, _ := newTestLogger(t, false)
+
+	if _, err := l.addFilter(FilterRule{Action: FilterSample, Rate: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 50; i++ {
+		fields := map[string]interface{}{"trace_id": fmt.Sprintf("trace-%d", i)}
+		first := l.filtered("handling", LogLevelInfo, nil, fields)
+
+		if l.filtered("handled", LogLevelInfo, nil, fields) != first {
+			t.Fatalf("trace-%d was partly sampled", i)
+		}
+	}
+}
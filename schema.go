@@ -0,0 +1,112 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SchemaMigration moves fields from the previous schema version to Version,
+// so code still logging old field names ships events in the current schema.
+// Paths are dotted, as for FieldFilter.
+type SchemaMigration struct {
+	// Version is the schema version the migration produces.
+	Version int
+
+	// Rename maps old field paths to new ones.
+	Rename map[string]string
+
+	// Remove lists fields dropped from the schema.
+	Remove []string
+}
+
+// Apply migrates fields in place.
+func (m SchemaMigration) Apply(fields map[string]interface{}) {
+	// Rename in a stable order so chained renames behave the same every time.
+	from := make([]string, 0, len(m.Rename))
+
+	for path := range m.Rename {
+		from = append(from, path)
+	}
+
+	sort.Strings(from)
+
+	for _, path := range from {
+		if value, ok := getPath(fields, path); ok {
+			deletePath(fields, path)
+			setPath(fields, m.Rename[path], value)
+		}
+	}
+
+	for _, path := range m.Remove {
+		deletePath(fields, path)
+	}
+}
+
+type schema struct {
+	version    int
+	migrations []SchemaMigration
+}
+
+// SetSchemaVersion stamps every message with a schema_version field and
+// applies the migrations up to version, in order, to each message's metadata
+// and fields. Bump the version whenever a field set changes so Loggly derived
+// fields and alerts can key on it.
+func SetSchemaVersion(version int, migrations ...SchemaMigration) error {
+	s, err := newSchema(version, migrations)
+
+	if err != nil {
+		return err
+	}
+
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	loggerSingleton.schema = s
+	loggerSingleton.setField("schema_version", version)
+
+	return nil
+}
+
+func newSchema(version int, migrations []SchemaMigration) (*schema, error) {
+	if version < 1 {
+		return nil, fmt.Errorf("schema version %d must be at least 1", version)
+	}
+
+	sorted := append([]SchemaMigration(nil), migrations...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i, m := range sorted {
+		if m.Version < 2 || m.Version > version {
+			return nil, fmt.Errorf("migration to version %d is outside versions 2 to %d", m.Version, version)
+		}
+
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("more than one migration to version %d", m.Version)
+		}
+	}
+
+	return &schema{version: version, migrations: sorted}, nil
+}
+
+// migrate applies the schema's migrations to encoded metadata and fields.
+func (l *logger) migrate(d interface{}, fields map[string]interface{}) {
+	l.Lock()
+	s := l.schema
+	l.Unlock()
+
+	if s == nil {
+		return
+	}
+
+	metadata, _ := d.(map[string]interface{})
+
+	for _, m := range s.migrations {
+		if metadata != nil {
+			m.Apply(metadata)
+		}
+
+		if fields != nil {
+			m.Apply(fields)
+		}
+	}
+}
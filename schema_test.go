@@ -0,0 +1,67 @@
+package log
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchemaMigrationApply(t *testing.T) {
+	fields := map[string]interface{}{
+		"user":    map[string]interface{}{"id": 7},
+		"latency": 12,
+		"legacy":  true,
+	}
+
+	SchemaMigration{
+		Version: 2,
+		Rename:  map[string]string{"user.id": "user_id", "latency": "duration_ms"},
+		Remove:  []string{"legacy"},
+	}.Apply(fields)
+
+	want := map[string]interface{}{"user": map[string]interface{}{}, "user_id": 7, "duration_ms": 12}
+
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("got %+v, want %+v", fields, want)
+	}
+}
+
+func TestNewSchema(t *testing.T) {
+	if _, err := newSchema(0, nil); err == nil {
+		t.Error("expected an error for version 0")
+	}
+
+	if _, err := newSchema(2, []SchemaMigration{{Version: 3}}); err == nil {
+		t.Error("expected an error for a migration past the current version")
+	}
+
+	if _, err := newSchema(3, []SchemaMigration{{Version: 2}, {Version: 2}}); err == nil {
+		t.Error("expected an error for duplicate migrations")
+	}
+
+	s, err := newSchema(3, []SchemaMigration{{Version: 3}, {Version: 2}})
+	if err != nil || s.migrations[0].Version != 2 {
+		t.Errorf("expected migrations in version order, got %+v: %v", s, err)
+	}
+}
+
+func TestSchemaVersionShipped(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	var err error
+	if l.schema, err = newSchema(3, []SchemaMigration{
+		{Version: 2, Rename: map[string]string{"host": "hostname"}},
+		{Version: 3, Rename: map[string]string{"hostname": "host.name"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	l.setField("schema_version", 3)
+
+	l.buildAndShipMessage("This is migrated.", LogLevelInfo, false, map[string]interface{}{"host": "fc-1"})
+
+	body := receive(t, bodies)
+
+	if !strings.Contains(body, `"schema_version":3`) || !strings.Contains(body, `"metadata":{"host":{"name":"fc-1"}}`) {
+		t.Errorf("unexpected body %q", body)
+	}
+}
@@ -0,0 +1,278 @@
+package log
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SensitiveMode is how a sensitive field's value is protected before it
+// leaves the process.
+type SensitiveMode int
+
+const (
+	// SensitivePseudonymize replaces the value with its HMAC, so equal
+	// values can still be correlated in Loggly without being readable.
+	SensitivePseudonymize SensitiveMode = iota
+
+	// SensitiveEncrypt replaces the value with its AES-GCM encryption, which
+	// holders of the key can reverse with DecryptField.
+	SensitiveEncrypt
+)
+
+// Key is a secret used to protect sensitive fields. The ID is shipped with
+// every protected value so keys can be rotated.
+type Key struct {
+	ID string
+
+	// Secret is the HMAC key, and the AES key when encrypting, so it must be
+	// 16, 24 or 32 bytes long for SensitiveEncrypt.
+	Secret []byte
+}
+
+// KeyProvider supplies the keys protecting sensitive fields, such as from a
+// KMS or secret manager.
+type KeyProvider interface {
+	// CurrentKey returns the key new values are protected with.
+	CurrentKey() (Key, error)
+
+	// KeyByID returns a key that protected earlier values, for DecryptField.
+	KeyByID(id string) (Key, error)
+}
+
+// ErrUnknownKey is returned by a KeyProvider for a key ID it doesn't hold.
+var ErrUnknownKey = errors.New("unknown key")
+
+// StaticKeys is a KeyProvider holding its keys in memory, protecting new
+// values with the Current one.
+type StaticKeys struct {
+	Current string
+	Keys    map[string][]byte
+}
+
+// CurrentKey returns the Current key.
+func (s StaticKeys) CurrentKey() (Key, error) {
+	return s.KeyByID(s.Current)
+}
+
+// KeyByID returns the key with id.
+func (s StaticKeys) KeyByID(id string) (Key, error) {
+	secret, ok := s.Keys[id]
+
+	if !ok {
+		return Key{}, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+
+	return Key{ID: id, Secret: secret}, nil
+}
+
+// SensitiveFields configures which fields are protected before shipping.
+type SensitiveFields struct {
+	// Fields maps the dotted path of each sensitive field, in the metadata
+	// or the event's fields, to how it is protected.
+	Fields map[string]SensitiveMode
+
+	Keys KeyProvider
+}
+
+// unprotectedValue replaces sensitive values that couldn't be protected,
+// failing closed rather than shipping them.
+const unprotectedValue = "[unprotected]"
+
+// Prefixes of protected values, followed by the key ID and the payload.
+const (
+	pseudonymPrefix = "hmac:"
+	encryptedPrefix = "enc:"
+)
+
+// SetSensitiveFields protects the sensitive fields of every event before it
+// is shipped or written to a sink. Values are protected as strings, non
+// string values by their JSON encoding. The console still shows the raw
+// values. Pass a zero SensitiveFields to stop protecting fields.
+func SetSensitiveFields(sensitive SensitiveFields) error {
+	if len(sensitive.Fields) > 0 && sensitive.Keys == nil {
+		return fmt.Errorf("sensitive fields require a key provider")
+	}
+
+	loggerSingleton.Lock()
+	loggerSingleton.sensitive = sensitive
+	loggerSingleton.Unlock()
+
+	return nil
+}
+
+// protectSensitive returns copies of d and fields with their sensitive and
+// subject identifier values protected.
+func (l *logger) protectSensitive(d interface{}, fields map[string]interface{}) (interface{}, map[string]interface{}) {
+	l.Lock()
+	sensitive := l.sensitive
+	subjects := l.subjects
+	l.Unlock()
+
+	d, fields = l.protectFields(sensitive, d, fields)
+
+	return l.protectFields(subjects, d, fields)
+}
+
+func (l *logger) protectFields(sensitive SensitiveFields, d interface{}, fields map[string]interface{}) (interface{}, map[string]interface{}) {
+	if len(sensitive.Fields) == 0 {
+		return d, fields
+	}
+
+	key, keyErr := sensitive.Keys.CurrentKey()
+
+	if keyErr != nil && l.debugMode {
+		fmt.Printf("There was an error getting the sensitive field key: %s", keyErr)
+	}
+
+	protect := func(values map[string]interface{}) bool {
+		changed := false
+
+		for path, mode := range sensitive.Fields {
+			value, ok := getPath(values, path)
+
+			if !ok {
+				continue
+			}
+
+			protected := unprotectedValue
+
+			if keyErr == nil {
+				if p, err := protectValue(mode, key, value); err == nil {
+					protected = p
+				} else if l.debugMode {
+					fmt.Printf("There was an error protecting sensitive field %s: %s", path, err)
+				}
+			}
+
+			if _, flat := values[path]; flat {
+				values[path] = protected
+			} else {
+				setPath(values, path, protected)
+			}
+
+			changed = true
+		}
+
+		return changed
+	}
+
+	if d != nil {
+		if values, ok := toFieldMap(d); ok && protect(values) {
+			d = values
+		}
+	}
+
+	if len(fields) > 0 {
+		if values, ok := toFieldMap(fields); ok && protect(values) {
+			fields = values
+		}
+	}
+
+	return d, fields
+}
+
+func protectValue(mode SensitiveMode, key Key, value interface{}) (string, error) {
+	plain, ok := value.(string)
+
+	if !ok {
+		b, err := json.Marshal(value)
+
+		if err != nil {
+			return "", err
+		}
+
+		plain = string(b)
+	}
+
+	if mode == SensitiveEncrypt {
+		aead, err := newAEAD(key.Secret)
+
+		if err != nil {
+			return "", err
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return "", err
+		}
+
+		sealed := aead.Seal(nonce, nonce, []byte(plain), []byte(key.ID))
+
+		return encryptedPrefix + key.ID + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+	}
+
+	return Pseudonym(key, plain), nil
+}
+
+// Pseudonym returns the value a pseudonymized field holds for plain under
+// key, for looking up a known value in Loggly.
+func Pseudonym(key Key, plain string) string {
+	mac := hmac.New(sha256.New, key.Secret)
+	mac.Write([]byte(plain))
+
+	return pseudonymPrefix + key.ID + ":" + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// DecryptField returns the plain value of a field protected with
+// SensitiveEncrypt, looking its key up by the ID it carries.
+func DecryptField(value string, keys KeyProvider) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return "", fmt.Errorf("value is not an encrypted field")
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, encryptedPrefix), ":", 2)
+
+	if len(parts) != 2 {
+		return "", fmt.Errorf("encrypted field has no key ID")
+	}
+
+	key, err := keys.KeyByID(parts[0])
+
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[1])
+
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(key.Secret)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("encrypted field is truncated")
+	}
+
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(key.ID))
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+func newAEAD(secret []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(secret)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
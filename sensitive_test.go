@@ -0,0 +1,97 @@
+package log
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSensitiveFields(t *testing.T) {
+	keys := StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": []byte("0123456789abcdef0123456789abcdef")}}
+	key, _ := keys.CurrentKey()
+
+	l, bodies := newTestLogger(t, false)
+	l.sensitive = SensitiveFields{
+		Fields: map[string]SensitiveMode{"user.email": SensitivePseudonymize, "card": SensitiveEncrypt, "tenant": SensitivePseudonymize},
+		Keys:   keys,
+	}
+
+	entry := &Entry{logger: l, fields: map[string]interface{}{"tenant": "acme"}}
+	entry.Infod("checkout", map[string]interface{}{"user": map[string]interface{}{"email": "ada@example.com"}, "card": "4111111111111111", "total": 12})
+
+	body := receive(t, bodies)
+
+	if strings.Contains(body, "ada@example.com") || strings.Contains(body, "4111") || strings.Contains(body, `"acme"`) {
+		t.Fatalf("expected sensitive values protected, got %q", body)
+	}
+
+	var message struct {
+		Metadata struct {
+			User  struct{ Email string }
+			Card  string
+			Total int
+		}
+		Tenant string
+	}
+
+	if err := json.Unmarshal([]byte(body), &message); err != nil {
+		t.Fatal(err)
+	}
+
+	if message.Metadata.User.Email != Pseudonym(key, "ada@example.com") || message.Tenant != Pseudonym(key, "acme") || message.Metadata.Total != 12 {
+		t.Errorf("unexpected message %+v", message)
+	}
+
+	if plain, err := DecryptField(message.Metadata.Card, keys); err != nil || plain != "4111111111111111" {
+		t.Errorf("expected the card to decrypt, got %q, %v", plain, err)
+	}
+
+	if entry.fields["tenant"] != "acme" {
+		t.Errorf("expected the entry's fields unchanged, got %v", entry.fields)
+	}
+}
+
+func TestSensitiveFieldsFailClosed(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.sensitive = SensitiveFields{Fields: map[string]SensitiveMode{"token": SensitivePseudonymize}, Keys: StaticKeys{Current: "missing"}}
+
+	l.buildAndShipMessage("login", LogLevelInfo, false, map[string]string{"token": "secret"})
+
+	if body := receive(t, bodies); !strings.Contains(body, `"token":"`+unprotectedValue+`"`) {
+		t.Errorf("expected the value withheld, got %q", body)
+	}
+}
+
+func TestSensitiveFieldsKeptProtected(t *testing.T) {
+	keys := StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": []byte("0123456789abcdef0123456789abcdef")}}
+
+	for _, attach := range []bool{false, true} {
+		l, bodies := newTestLogger(t, false)
+		l.Level = LogLevelInfo
+		l.sensitive = SensitiveFields{Fields: map[string]SensitiveMode{"email": SensitivePseudonymize}, Keys: keys}
+		l.recorder = &flightRecorder{config: FlightRecorder{Size: 10, Attach: attach}, ring: newEventRing(10)}
+		l.recent = newEventRing(10)
+
+		l.buildAndShipMessage("lookup", LogLevelDebug, false, map[string]string{"email": "ada@example.com"})
+		l.buildAndShipMessage("failed", LogLevelError, false, map[string]string{"email": "ada@example.com"})
+
+		shipped := receive(t, bodies)
+		if !attach {
+			shipped += receive(t, bodies)
+		}
+
+		if strings.Contains(shipped, "ada@example.com") || !strings.Contains(shipped, "lookup") {
+			t.Errorf("expected the replayed event protected, got %q", shipped)
+		}
+
+		var bundle strings.Builder
+
+		if err := l.recent.write(&bundle); err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(bundle.String(), "ada@example.com") || !strings.Contains(bundle.String(), "lookup") {
+			t.Errorf("expected the buffered events protected, got %q", bundle.String())
+		}
+	}
+}
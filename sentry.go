@@ -0,0 +1,235 @@
+//go:build !loggly_minimal
+// +build !loggly_minimal
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SentrySink forwards Error and Fatal events to Sentry, alongside normal
+// shipping to Loggly. Register it with AddSink.
+type SentrySink struct {
+	// Level is the lowest level forwarded, Error if zero.
+	Level Level
+
+	// Tags are added to every Sentry event, along with the event's component.
+	Tags map[string]string
+
+	// Environment and Release are reported to Sentry. The event's environment
+	// field takes precedence over Environment.
+	Environment string
+	Release     string
+
+	// Client sends the requests, http.DefaultClient if nil.
+	Client *http.Client
+
+	// Timeout bounds each request, 10 seconds if zero.
+	Timeout time.Duration
+
+	endpoint string
+	auth     string
+}
+
+// NewSentrySink creates a sink forwarding to the project of a Sentry DSN, of
+// the form https://<key>@<host>/<project>.
+func NewSentrySink(dsn string) (*SentrySink, error) {
+	u, err := url.Parse(dsn)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry dsn: %s", err)
+	}
+
+	key := ""
+	if u.User != nil {
+		key = u.User.Username()
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+
+	if key == "" || u.Host == "" || i < 0 || path[i+1:] == "" {
+		return nil, fmt.Errorf("invalid sentry dsn %q", dsn)
+	}
+
+	endpoint := u.Scheme + "://" + u.Host + path[:i] + "/api/" + path[i+1:] + "/store/"
+	auth := "Sentry sentry_version=7, sentry_client=loggly-go/1.0, sentry_key=" + key
+
+	if secret, ok := u.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+
+	return &SentrySink{endpoint: endpoint, auth: auth}, nil
+}
+
+// sentryEvent is the subset of the Sentry event payload the sink fills in.
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Logger      string                 `json:"logger"`
+	Platform    string                 `json:"platform"`
+	Message     string                 `json:"message"`
+	Environment string                 `json:"environment,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Fingerprint []string               `json:"fingerprint,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+}
+
+// Write forwards an event at or above the sink's level.
+func (s *SentrySink) Write(e Event) error {
+	level := s.Level
+	if level == 0 {
+		level = LogLevelError
+	}
+
+	if !e.Level.AtLeast(level) {
+		return nil
+	}
+
+	b, err := json.Marshal(s.event(e))
+
+	if err != nil {
+		return err
+	}
+
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(b))
+
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newResponseError(resp, nil)
+	}
+
+	return nil
+}
+
+// event converts a log event to a Sentry event. Metadata and the remaining
+// fields, such as the stack, become extra data.
+func (s *SentrySink) event(e Event) sentryEvent {
+	event := sentryEvent{
+		EventID:     newEventID(),
+		Timestamp:   e.Time.UTC().Format(time.RFC3339),
+		Level:       sentryLevel(e.Level),
+		Logger:      "loggly",
+		Platform:    "go",
+		Message:     e.Message,
+		Environment: s.Environment,
+		Release:     s.Release,
+		Tags:        map[string]string{},
+		Extra:       map[string]interface{}{},
+	}
+
+	for key, value := range s.Tags {
+		event.Tags[key] = value
+	}
+
+	if e.Metadata != nil {
+		event.Extra["metadata"] = e.Metadata
+	}
+
+	if component, ok := componentOf(e.Metadata, e.Fields); ok {
+		event.Tags["component"] = component
+	}
+
+	for key, value := range e.Fields {
+		text, _ := value.(string)
+
+		switch key {
+		case fingerprintField:
+			event.Fingerprint = []string{text}
+		case "environment":
+			event.Environment = text
+		case "component":
+		default:
+			event.Extra[key] = value
+		}
+	}
+
+	return event
+}
+
+func sentryLevel(level Level) string {
+	switch {
+	case level.AtLeast(LogLevelFatal):
+		return "fatal"
+	case level.AtLeast(LogLevelError):
+		return "error"
+	case level.AtLeast(LogLevelWarn):
+		return "warning"
+	case level.AtLeast(LogLevelInfo):
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// newEventID returns a random Sentry event ID, 32 hex characters.
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+func init() {
+	RegisterSink("sentry", func(options map[string]interface{}) (Sink, error) {
+		var config struct {
+			DSN         string            `json:"dsn"`
+			Environment string            `json:"environment"`
+			Release     string            `json:"release"`
+			Tags        map[string]string `json:"tags"`
+		}
+
+		if err := decodeOptions(options, &config); err != nil {
+			return nil, err
+		}
+
+		sink, err := NewSentrySink(config.DSN)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sink.Environment, sink.Release, sink.Tags = config.Environment, config.Release, config.Tags
+
+		return sink, nil
+	})
+}
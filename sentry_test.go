@@ -0,0 +1,94 @@
+//go:build !loggly_minimal
+// +build !loggly_minimal
+
+package log
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSentrySink(t *testing.T) {
+	s, err := NewSentrySink("https://public@sentry.example.com/prefix/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.endpoint != "https://sentry.example.com/prefix/api/42/store/" || !strings.HasSuffix(s.auth, "sentry_key=public") {
+		t.Errorf("unexpected endpoint %q and auth %q", s.endpoint, s.auth)
+	}
+
+	for _, dsn := range []string{"https://sentry.example.com/42", "https://public@sentry.example.com/", "::"} {
+		if _, err := NewSentrySink(dsn); err == nil {
+			t.Errorf("expected an error for %q", dsn)
+		}
+	}
+}
+
+func TestSentrySink(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	events := make(chan sentryEvent, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+
+		var event sentryEvent
+		json.Unmarshal(b, &event)
+
+		requests <- r
+		events <- event
+	}))
+	defer server.Close()
+
+	s, err := NewSentrySink("http://public@" + strings.TrimPrefix(server.URL, "http://") + "/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Tags = map[string]string{"service": "nav"}
+
+	if err := s.Write(Event{Time: time.Now(), Level: LogLevelWarn, Message: "This is a warning."}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Write(Event{
+		Time:     time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Level:    LogLevelError,
+		Message:  "This is an error.",
+		Metadata: map[string]interface{}{"component": "radio"},
+		Fields:   map[string]interface{}{fingerprintField: "abc123", "stack": "main.main", "environment": "staging"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := <-requests
+	event := <-events
+
+	if r.URL.Path != "/api/42/store/" || !strings.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key=public") {
+		t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+	}
+
+	if event.Level != "error" || event.Message != "This is an error." || event.Timestamp != "2020-01-01T00:00:00Z" || len(event.EventID) != 32 {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	if len(event.Fingerprint) != 1 || event.Fingerprint[0] != "abc123" || event.Environment != "staging" {
+		t.Errorf("unexpected fingerprint %v or environment %q", event.Fingerprint, event.Environment)
+	}
+
+	if event.Tags["service"] != "nav" || event.Tags["component"] != "radio" || event.Extra["stack"] != "main.main" {
+		t.Errorf("unexpected tags %v or extra %v", event.Tags, event.Extra)
+	}
+
+	select {
+	case <-events:
+		t.Error("expected only one event to be forwarded")
+	default:
+	}
+}
@@ -0,0 +1,101 @@
+package log
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Session fields stamped on every event of a session.
+const (
+	sessionField   = "session"
+	sessionIDField = "session_id"
+)
+
+// Session logs the events of a long running job, such as a batch job or a
+// test run, stamping each with the session's name and ID. End logs a summary
+// of the session.
+type Session struct {
+	*Entry
+
+	name  string
+	id    string
+	start time.Time
+
+	mu         sync.Mutex
+	counts     map[Level]int
+	firstError string
+	lastError  string
+	once       sync.Once
+}
+
+// StartSession starts a session named name, e.g. "nightly-import".
+func StartSession(name string) *Session {
+	return loggerSingleton.startSession(name)
+}
+
+func (l *logger) startSession(name string) *Session {
+	s := &Session{name: name, id: newSessionID(), start: l.now(), counts: map[Level]int{}}
+	s.Entry = &Entry{logger: l, fields: map[string]interface{}{sessionField: name, sessionIDField: s.id}, session: s}
+
+	return s
+}
+
+// ID returns the session's ID.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// End logs the session's summary: its duration, the number of events per
+// level and the first and last error. Only the first call has any effect.
+func (s *Session) End() {
+	s.once.Do(func() {
+		l := s.Entry.logger
+
+		s.mu.Lock()
+
+		counts := make(map[string]int, len(s.counts))
+		for level, n := range s.counts {
+			counts[level.String()] = n
+		}
+
+		summary := map[string]interface{}{
+			"duration_ms": float64(l.now().Sub(s.start)) / float64(time.Millisecond),
+			"counts":      counts,
+		}
+
+		if s.firstError != "" {
+			summary["first_error"] = s.firstError
+			summary["last_error"] = s.lastError
+		}
+
+		s.mu.Unlock()
+
+		// The summary itself isn't counted.
+		(&Entry{logger: l, fields: s.Entry.fields}).Infod("session "+s.name+" ended", summary)
+	})
+}
+
+// count tallies an event logged through the session.
+func (s *Session) count(level Level, output string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts[level]++
+
+	if level.AtLeast(LogLevelError) {
+		if s.firstError == "" {
+			s.firstError = output
+		}
+
+		s.lastError = output
+	}
+}
+
+func newSessionID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,60 @@
+package log
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSession(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.clock = clock
+
+	s := l.startSession("nightly-import")
+	s.Infoln("import started")
+	s.Errorln("row 12 invalid")
+	s.WithRetention(RetentionAudit).Errorln("row 40 invalid")
+
+	for i := 0; i < 3; i++ {
+		var event map[string]interface{}
+		json.Unmarshal([]byte(receive(t, bodies)), &event)
+
+		if event[sessionField] != "nightly-import" || event[sessionIDField] != s.ID() {
+			t.Errorf("expected session fields, got %+v", event)
+		}
+	}
+
+	clock.Advance(2 * time.Second)
+	s.End()
+	s.End()
+
+	var summary struct {
+		Message   string `json:"message"`
+		SessionID string `json:"session_id"`
+		Metadata  struct {
+			DurationMS float64        `json:"duration_ms"`
+			Counts     map[string]int `json:"counts"`
+			FirstError string         `json:"first_error"`
+			LastError  string         `json:"last_error"`
+		} `json:"metadata"`
+	}
+
+	json.Unmarshal([]byte(receive(t, bodies)), &summary)
+
+	if summary.Message != "session nightly-import ended" || summary.SessionID != s.ID() {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+
+	m := summary.Metadata
+
+	if m.DurationMS != 2000 || m.Counts["INFO"] != 1 || m.Counts["ERROR"] != 2 || m.FirstError != "row 12 invalid" || m.LastError != "row 40 invalid" {
+		t.Errorf("unexpected summary metadata %+v", m)
+	}
+
+	select {
+	case body := <-bodies:
+		t.Errorf("expected a single summary, got %q", body)
+	default:
+	}
+}
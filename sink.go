@@ -0,0 +1,228 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogglySink is the name of the built-in sink shipping to Loggly, for use
+// with SetFieldFilter.
+const LogglySink = "loggly"
+
+// Event is a log message as delivered to sinks.
+type Event struct {
+	Time     time.Time
+	Level    Level
+	Message  string
+	Metadata interface{}
+
+	// Fields are the event's top level fields, such as those added by an
+	// Entry or the MDC.
+	Fields map[string]interface{}
+}
+
+// MarshalJSON encodes the event the same way it is shipped to Loggly.
+func (e Event) MarshalJSON() ([]byte, error) {
+	message := newMessage(e.Time.Format(time.RFC3339), e.Level, e.Message, e.Metadata)
+	message.Fields = e.Fields
+
+	return json.Marshal(message)
+}
+
+// Sink receives every event that passes the logger's level, alongside the
+// events shipped to Loggly.
+type Sink interface {
+	Write(e Event) error
+}
+
+type namedSink struct {
+	name  string
+	sink  Sink
+	queue *sinkQueue
+}
+
+func newNamedSink(name string, sink Sink) namedSink {
+	return namedSink{name: name, sink: sink, queue: &sinkQueue{}}
+}
+
+// sinkQueueSize bounds the events waiting for a sink that can't keep up,
+// later ones are dropped until it catches up.
+const sinkQueueSize = 10000
+
+// sinkQueue holds the events waiting for a sink, in the order they were
+// logged.
+type sinkQueue struct {
+	mu      sync.Mutex
+	events  []Event
+	running bool
+}
+
+// AddSink registers an additional sink under name. Sinks are written to in
+// order on a separate goroutine unless the logger is synchronous, and Flush
+// and Close wait for the writes.
+func AddSink(name string, sink Sink) {
+	loggerSingleton.Lock()
+	loggerSingleton.sinks = append(loggerSingleton.sinks, newNamedSink(name, sink))
+	loggerSingleton.Unlock()
+}
+
+// RemoveSink removes the sinks registered under name, reporting whether there
+// were any.
+func RemoveSink(name string) bool {
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	sinks := make([]namedSink, 0, len(loggerSingleton.sinks))
+
+	for _, s := range loggerSingleton.sinks {
+		if s.name != name {
+			sinks = append(sinks, s)
+		}
+	}
+
+	removed := len(sinks) < len(loggerSingleton.sinks)
+	loggerSingleton.sinks = sinks
+
+	return removed
+}
+
+// SetFieldFilter sets the metadata fields shipped to the named sink, use
+// LogglySink for Loggly itself.
+func SetFieldFilter(sink string, filter FieldFilter) {
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	if loggerSingleton.fieldFilters == nil {
+		loggerSingleton.fieldFilters = map[string]FieldFilter{}
+	}
+
+	loggerSingleton.fieldFilters[sink] = filter
+}
+
+// SetFieldFilters replaces every sink's field filter with the declared set.
+func SetFieldFilters(filters map[string]FieldFilter) {
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	loggerSingleton.fieldFilters = map[string]FieldFilter{}
+
+	for sink, filter := range filters {
+		loggerSingleton.fieldFilters[sink] = filter
+	}
+}
+
+func (l *logger) fieldFilter(sink string) (FieldFilter, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	filter, ok := l.fieldFilters[sink]
+
+	return filter, ok
+}
+
+// writeSinks delivers event to every registered sink, filtering its
+// metadata per sink.
+func (l *logger) writeSinks(event Event) {
+	l.Lock()
+	sinks := l.sinks
+	l.Unlock()
+
+	for _, s := range sinks {
+		e := event
+
+		if filter, ok := l.fieldFilter(s.name); ok {
+			e.Metadata = filter.apply(e.Metadata)
+			e.Fields = filter.applyFields(e.Fields)
+		}
+
+		if l.isSynchronous() {
+			l.writeSink(s, e)
+		} else {
+			l.queueSink(s, e)
+		}
+	}
+}
+
+func (l *logger) writeSink(s namedSink, e Event) {
+	if err := s.sink.Write(e); err != nil && l.debugMode {
+		fmt.Printf("There was an error writing to the %s sink: %s", s.name, err)
+	}
+}
+
+// queueSink queues event for the sink, starting a worker tracked as in
+// flight to write the queue unless one is running already.
+func (l *logger) queueSink(s namedSink, e Event) {
+	q := s.queue
+	q.mu.Lock()
+
+	if len(q.events) >= sinkQueueSize {
+		q.mu.Unlock()
+
+		if l.debugMode {
+			fmt.Printf("The %s sink is falling behind, dropping an event", s.name)
+		}
+		return
+	}
+
+	q.events = append(q.events, e)
+	start := !q.running
+	q.running = true
+	q.mu.Unlock()
+
+	if start {
+		l.goShip(func() { l.drainSink(s) })
+	}
+}
+
+// drainSink writes the sink's queued events until the queue is empty.
+func (l *logger) drainSink(s namedSink) {
+	q := s.queue
+
+	for {
+		q.mu.Lock()
+
+		if len(q.events) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+
+		events := q.events
+		q.events = nil
+		q.mu.Unlock()
+
+		for _, e := range events {
+			l.writeSink(s, e)
+		}
+	}
+}
+
+// WriterSink writes events to an io.Writer as newline delimited JSON.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink creates a sink writing to w.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Write writes a single event line.
+func (s *WriterSink) Write(e Event) error {
+	b, err := json.Marshal(e)
+
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.w.Write(append(b, '\n'))
+
+	return err
+}
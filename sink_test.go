@@ -0,0 +1,106 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memorySink struct {
+	events []Event
+}
+
+func (s *memorySink) Write(e Event) error {
+	s.events = append(s.events, e)
+	return nil
+}
+
+func TestSinkFieldFilters(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	audit := &memorySink{}
+	l.sinks = append(l.sinks, newNamedSink("audit", audit))
+	l.fieldFilters = map[string]FieldFilter{
+		"audit":    {Deny: []string{"debug"}},
+		LogglySink: {Deny: []string{"payload"}},
+	}
+
+	l.buildAndShipMessage("This is an info statement.", LogLevelInfo, false, map[string]interface{}{
+		"debug":   "verbose",
+		"payload": "body",
+	})
+	l.flush()
+
+	if len(audit.events) != 1 {
+		t.Fatalf("expected 1 audit event, got %d", len(audit.events))
+	}
+
+	if metadata := audit.events[0].Metadata.(map[string]interface{}); metadata["debug"] != nil || metadata["payload"] != "body" {
+		t.Errorf("unexpected audit metadata %+v", metadata)
+	}
+
+	if body := receive(t, bodies); strings.Contains(body, "payload") || !strings.Contains(body, "verbose") {
+		t.Errorf("unexpected loggly body %q", body)
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	l, _ := newTestLogger(t, true)
+
+	var out bytes.Buffer
+	l.sinks = append(l.sinks, newNamedSink("file", NewWriterSink(&out)))
+
+	l.buildAndShipMessage("This is a warning.", LogLevelWarn, false, nil)
+
+	if !strings.HasSuffix(out.String(), `"level":"WARN","severity":4,"message":"This is a warning.","metadata":null}`+"\n") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+type slowSink struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (s *slowSink) Write(e Event) error {
+	time.Sleep(time.Millisecond)
+
+	s.mu.Lock()
+	s.messages = append(s.messages, e.Message)
+	s.mu.Unlock()
+
+	return nil
+}
+
+func TestSinkWritesInOrderBeforeFlushReturns(t *testing.T) {
+	l, _ := newTestLogger(t, true)
+	l.synchronous = false
+
+	sink := &slowSink{}
+	l.sinks = append(l.sinks, newNamedSink("slow", sink))
+
+	for i := 0; i < 20; i++ {
+		l.buildAndShipMessage(fmt.Sprintf("event %d", i), LogLevelInfo, false, nil)
+	}
+
+	if err := l.flushAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	if len(sink.messages) != 20 {
+		t.Fatalf("expected every event written before Flush returned, got %d", len(sink.messages))
+	}
+
+	for i, message := range sink.messages {
+		if message != fmt.Sprintf("event %d", i) {
+			t.Fatalf("expected events in order, got %v", sink.messages)
+		}
+	}
+}
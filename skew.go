@@ -0,0 +1,129 @@
+package log
+
+import (
+	"net/http"
+	"time"
+)
+
+// ClockSkew configures detection of skew between the local clock and
+// Loggly's, estimated from the Date header of its responses.
+type ClockSkew struct {
+	// Threshold is the offset beyond which the clock counts as skewed, 5
+	// seconds if zero. The Date header has a resolution of a second.
+	Threshold time.Duration
+
+	// Correct shifts event timestamps by the estimated offset while the
+	// clock is skewed, as well as annotating them.
+	Correct bool
+}
+
+// clockOffsetField is the top level field carrying the estimated offset of a
+// skewed clock.
+const clockOffsetField = "clock_offset_ms"
+
+type clockSkew struct {
+	config ClockSkew
+	offset time.Duration
+	known  bool
+	active bool
+}
+
+// SetClockSkewDetection compares the local clock against Loggly's on every
+// response. While the offset exceeds the threshold events carry a
+// "clock_offset_ms" field, and a Warn level "clock skew detected" event is
+// shipped when skew is first detected. This matters on devices without a
+// reliable real time clock.
+func SetClockSkewDetection(config ClockSkew) {
+	if config.Threshold <= 0 {
+		config.Threshold = 5 * time.Second
+	}
+
+	loggerSingleton.Lock()
+	loggerSingleton.skew = &clockSkew{config: config}
+	loggerSingleton.Unlock()
+}
+
+// ClockOffset returns the latest estimate of how far Loggly's clock is ahead
+// of the local one, and whether there is an estimate yet.
+func ClockOffset() (time.Duration, bool) {
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	if loggerSingleton.skew == nil {
+		return 0, false
+	}
+
+	return loggerSingleton.skew.offset, loggerSingleton.skew.known
+}
+
+// observeDate updates the offset estimate from a response's Date header,
+// given when the request was sent and its response received.
+func (l *logger) observeDate(sent, received time.Time, date string) {
+	l.Lock()
+	skew := l.skew
+	l.Unlock()
+
+	if skew == nil || date == "" {
+		return
+	}
+
+	server, err := http.ParseTime(date)
+
+	if err != nil {
+		return
+	}
+
+	// The header is truncated to the second, aim for the middle of it and
+	// of the round trip.
+	local := sent.Add(received.Sub(sent) / 2)
+	offset := server.Add(500 * time.Millisecond).Sub(local)
+
+	l.Lock()
+
+	skew.offset, skew.known = offset, true
+
+	skewed := offset > skew.config.Threshold || offset < -skew.config.Threshold
+	started := skewed && !skew.active
+	skew.active = skewed
+	threshold := skew.config.Threshold
+
+	l.Unlock()
+
+	if started {
+		l.buildAndShipMessage("clock skew detected", LogLevelWarn, false, map[string]interface{}{
+			"offset_ms":    milliseconds(offset),
+			"threshold_ms": milliseconds(threshold),
+		})
+	}
+}
+
+// correctSkew annotates an event while the clock is skewed, shifting its
+// timestamp if correction is enabled.
+func (l *logger) correctSkew(timestamp time.Time, fields map[string]interface{}) (time.Time, map[string]interface{}) {
+	l.Lock()
+	skew := l.skew
+	if skew == nil || !skew.active {
+		l.Unlock()
+		return timestamp, fields
+	}
+	offset, correct := skew.offset, skew.config.Correct
+	l.Unlock()
+
+	annotated := make(map[string]interface{}, len(fields)+1)
+
+	for key, value := range fields {
+		annotated[key] = value
+	}
+
+	annotated[clockOffsetField] = milliseconds(offset)
+
+	if correct {
+		timestamp = timestamp.Add(offset)
+	}
+
+	return timestamp, annotated
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClockSkew(t *testing.T) {
+	bodies := make(chan string, 10)
+	serverTime := time.Date(2020, 1, 1, 0, 1, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		w.Header().Set("Date", serverTime.Format(http.TimeFormat))
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	l.url = server.URL
+	l.synchronous = true
+	l.clock = NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.skew = &clockSkew{config: ClockSkew{Threshold: 5 * time.Second, Correct: true}}
+
+	l.buildAndShipMessage("first", LogLevelInfo, false, nil)
+
+	if body := receive(t, bodies); strings.Contains(body, clockOffsetField) {
+		t.Errorf("expected no annotation before the offset is known, got %q", body)
+	}
+
+	if warning := receive(t, bodies); !strings.Contains(warning, `"message":"clock skew detected"`) || !strings.Contains(warning, `"offset_ms":60500`) {
+		t.Errorf("unexpected warning %q", warning)
+	}
+
+	if offset, ok := l.skew.offset, l.skew.known; !ok || offset != 60500*time.Millisecond {
+		t.Errorf("unexpected offset %v", offset)
+	}
+
+	l.buildAndShipMessage("second", LogLevelInfo, false, nil)
+
+	if body := receive(t, bodies); !strings.Contains(body, `"timestamp":"2020-01-01T00:01:00Z"`) || !strings.Contains(body, `"clock_offset_ms":60500`) {
+		t.Errorf("expected a corrected and annotated event, got %q", body)
+	}
+
+	// The warning is only shipped when skew is first detected.
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected body %q", body)
+	default:
+	}
+}
@@ -0,0 +1,213 @@
+//go:build go1.21 && !loggly_minimal
+// +build go1.21,!loggly_minimal
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"math"
+)
+
+// ToSlogLevel converts a level to the equivalent slog.Level. Levels here are
+// spaced by 1 and slog levels by 4, so Debug, Info, Warn and Error map onto
+// their slog namesakes and custom levels are placed by their rank.
+func ToSlogLevel(level Level) slog.Level {
+	return slog.Level(math.Round((level.rank() - LogLevelInfo.rank()) * 4))
+}
+
+// FromSlogLevel converts a slog.Level to the equivalent level, following the
+// level names mapped with SetLevelMapping, such as "warn" or "info+2", like
+// the other adapters. By default it is the inverse of ToSlogLevel: slog
+// levels between the built-in ones map onto the custom level ranked there, or
+// the built-in level below them.
+func FromSlogLevel(level slog.Level) Level {
+	if mapped, err := ParseLevel(level.String()); err == nil {
+		return mapped
+	}
+
+	rank := LogLevelInfo.rank() + float64(level)/4
+
+	if custom, ok := customLevelRanked(rank); ok {
+		return custom
+	}
+
+	switch {
+	case rank >= LogLevelFatal.rank():
+		return LogLevelFatal
+	case rank <= LogLevelTrace.rank():
+		return LogLevelTrace
+	}
+
+	return Level(math.Floor(rank))
+}
+
+// SlogHandler is a slog.Handler logging through a Logger, so code written
+// against log/slog ships to Loggly. Attributes become the event's metadata,
+// groups nested objects within it, and values scoped to the context with
+// WithMDC become fields.
+type SlogHandler struct {
+	logger *Logger
+	attrs  map[string]interface{}
+	groups []string
+}
+
+// NewSlogHandler creates a handler logging through logger, or the default
+// Logger if it is nil.
+func NewSlogHandler(logger *Logger) *SlogHandler {
+	if logger == nil {
+		logger = Default()
+	}
+
+	return &SlogHandler{logger: logger}
+}
+
+// Enabled reports whether the logger ships events at level.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	l := h.logger.logger()
+
+	l.Lock()
+	defer l.Unlock()
+
+	// A boosted component may log below the logger's level.
+	if l.boost != nil && len(l.boost.until) > 0 {
+		return true
+	}
+
+	return FromSlogLevel(level).AtLeast(l.Level)
+}
+
+// Handle logs the record.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	metadata := copyAttrs(h.attrs)
+	group := groupMap(metadata, h.groups)
+
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(group, a)
+		return true
+	})
+
+	var d interface{}
+	if len(metadata) > 0 {
+		d = metadata
+	}
+
+	entry := &Entry{logger: h.logger.l, mdc: MDC(ctx)}
+
+	if request := RequestLogFrom(ctx); request != nil {
+		entry.fields, entry.request = request.Entry.fields, request
+	}
+	entry.log(r.Message, FromSlogLevel(r.Level), false, d)
+
+	return nil
+}
+
+// WithAttrs returns a handler adding attrs to every event.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	metadata := copyAttrs(h.attrs)
+	group := groupMap(metadata, h.groups)
+
+	for _, a := range attrs {
+		addAttr(group, a)
+	}
+
+	return &SlogHandler{logger: h.logger, attrs: metadata, groups: h.groups}
+}
+
+// WithGroup returns a handler nesting later attributes under name.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	groups := append(append([]string(nil), h.groups...), name)
+
+	return &SlogHandler{logger: h.logger, attrs: h.attrs, groups: groups}
+}
+
+// groupMap returns the map nested under groups in metadata, creating it.
+func groupMap(metadata map[string]interface{}, groups []string) map[string]interface{} {
+	for _, name := range groups {
+		next, ok := metadata[name].(map[string]interface{})
+
+		if !ok {
+			next = map[string]interface{}{}
+			metadata[name] = next
+		}
+
+		metadata = next
+	}
+
+	return metadata
+}
+
+func addAttr(m map[string]interface{}, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		m[a.Key] = slogValue(a.Value)
+		return
+	}
+
+	attrs := a.Value.Group()
+
+	if len(attrs) == 0 {
+		return
+	}
+
+	// A group without a key is inlined.
+	group := m
+
+	if a.Key != "" {
+		group = groupMap(m, []string{a.Key})
+	}
+
+	for _, attr := range attrs {
+		addAttr(group, attr)
+	}
+}
+
+func slogValue(v slog.Value) interface{} {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindFloat64:
+		return v.Float64()
+	case slog.KindBool:
+		return v.Bool()
+	case slog.KindDuration:
+		return v.Duration()
+	case slog.KindTime:
+		return v.Time()
+	}
+
+	return v.Any()
+}
+
+// copyAttrs deep copies the nested attribute maps so handlers derived from
+// one another don't share them.
+func copyAttrs(attrs map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(attrs))
+
+	for key, value := range attrs {
+		if group, ok := value.(map[string]interface{}); ok {
+			value = copyAttrs(group)
+		}
+
+		copied[key] = value
+	}
+
+	return copied
+}
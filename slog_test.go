@@ -0,0 +1,119 @@
+//go:build go1.21 && !loggly_minimal
+// +build go1.21,!loggly_minimal
+
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestSlogLevels(t *testing.T) {
+	notice, err := RegisterLevel("NOTICE", 1.5)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		level Level
+		slog  slog.Level
+	}{
+		{LogLevelTrace, slog.LevelDebug - 4},
+		{LogLevelDebug, slog.LevelDebug},
+		{LogLevelInfo, slog.LevelInfo},
+		{LogLevelWarn, slog.LevelWarn},
+		{LogLevelError, slog.LevelError},
+		{LogLevelFatal, slog.LevelError + 4},
+		{notice, slog.LevelInfo + 2},
+	}
+
+	for _, c := range cases {
+		if got := ToSlogLevel(c.level); got != c.slog {
+			t.Errorf("ToSlogLevel(%s): got %s, want %s", c.level, got, c.slog)
+		}
+
+		if got := FromSlogLevel(c.slog); got != c.level {
+			t.Errorf("FromSlogLevel(%s): got %s, want %s", c.slog, got, c.level)
+		}
+	}
+}
+
+func TestSlogLevelMapping(t *testing.T) {
+	defer ResetLevelMapping()
+
+	SetLevelMapping(map[string]Level{"warn": LogLevelError, "info+2": LogLevelWarn})
+
+	if got := FromSlogLevel(slog.LevelWarn); got != LogLevelError {
+		t.Errorf("expected the mapping to apply to WARN, got %s", got)
+	}
+
+	if got := FromSlogLevel(slog.LevelInfo + 2); got != LogLevelWarn {
+		t.Errorf("expected the mapping to apply to INFO+2, got %s", got)
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.Level = LogLevelInfo
+
+	logger := slog.New(NewSlogHandler(&Logger{l: l})).With("service", "billing").WithGroup("request").With("id", 7)
+
+	logger.Debug("This is below the logger's level.")
+
+	ctx := WithMDC(context.Background())
+	MDC(ctx).Set("tenant", "acme")
+
+	logger.WarnContext(ctx, "slow response", "took", 1500*time.Millisecond, slog.Group("db", "queries", 3), slog.Group("empty"))
+
+	var message struct {
+		Level    string
+		Message  string
+		Tenant   string
+		Metadata struct {
+			Service string
+			Request struct {
+				ID   int
+				Took string
+				DB   struct{ Queries int }
+			}
+		}
+	}
+
+	if err := json.Unmarshal([]byte(receive(t, bodies)), &message); err != nil {
+		t.Fatal(err)
+	}
+
+	m := message.Metadata
+
+	if message.Level != "WARN" || message.Message != "slow response" || message.Tenant != "acme" {
+		t.Errorf("unexpected message %+v", message)
+	}
+
+	if m.Service != "billing" || m.Request.ID != 7 || m.Request.Took == "" || m.Request.DB.Queries != 3 {
+		t.Errorf("unexpected metadata %+v", m)
+	}
+
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected body %q", body)
+	default:
+	}
+}
+
+func TestSlogHandlerDefault(t *testing.T) {
+	previous := loggerSingleton
+	loggerSingleton = newDefaultLogger()
+	t.Cleanup(func() { loggerSingleton = previous })
+
+	loggerSingleton.Level = LogLevelWarn
+
+	handler := NewSlogHandler(nil)
+
+	if handler.Enabled(context.Background(), slog.LevelInfo) || !handler.Enabled(context.Background(), slog.LevelError) {
+		t.Error("expected the default handler to follow the package level logger")
+	}
+}
@@ -0,0 +1,1209 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Spool segments start with spoolMagic and the format version, followed by
+// records of a 4 byte big endian payload length, a CRC-32C of the rest of the
+// record, an 8 byte unix nanosecond timestamp and the payload, a JSON encoded
+// message.
+const (
+	spoolMagic        = "LGSP"
+	spoolVersion      = 2
+	spoolHeaderSize   = int64(len(spoolMagic) + 1)
+	spoolRecordHeader = 16
+	spoolSegmentSize  = 4 << 20
+	spoolSegmentAge   = 10 * time.Second
+	spoolSegmentExt   = ".seg"
+	spoolIndexFile    = "index.json"
+	spoolLockFile     = "spool.lock"
+	spoolUploadLock   = "upload.lock"
+	spoolQuarantine   = "quarantine"
+	spoolReplayBatch  = 100
+)
+
+var spoolCRC = crc32.MakeTable(crc32.Castagnoli)
+
+// errCorruptRecord is returned for a record failing its checksum. The record is
+// still framed correctly, so reading can carry on past it.
+var errCorruptRecord = errors.New("spool record failed its checksum")
+
+// ErrSpooled is reported on a delivery channel when shipping failed and the
+// message was spooled to disk for replay.
+var ErrSpooled = errors.New("shipping failed, message spooled to disk")
+
+// SpoolSnapshot describes the messages waiting in the spool.
+type SpoolSnapshot struct {
+	Segments int
+	Events   int
+	Bytes    int64
+
+	// Oldest and Newest bound the spooled messages' times.
+	Oldest time.Time
+	Newest time.Time
+
+	// Corrupted counts records skipped during replay for failing their
+	// checksum, and Quarantined segments moved to the quarantine directory
+	// because they could not be read, and batches moved there because the
+	// endpoint rejected them. Both count since the spool was opened.
+	Corrupted   int
+	Quarantined int
+}
+
+// SetSpool spools batches that fail to ship to segments in dir, replaying
+// them once shipping succeeds again, including after a restart. Pass an empty
+// string to stop spooling, messages already spooled stay on disk.
+//
+// Processes on the same host may share dir. Each writes its own segments and
+// a single process at a time replays them all, so nothing ships twice.
+func SetSpool(dir string) error {
+	var s *spool
+
+	if dir != "" {
+		var err error
+
+		if s, err = openSpool(dir); err != nil {
+			return err
+		}
+	}
+
+	loggerSingleton.Lock()
+	previous := loggerSingleton.spool
+	loggerSingleton.spool = s
+	policy := loggerSingleton.spoolSync
+	loggerSingleton.Unlock()
+
+	if s != nil {
+		s.setSync(policy)
+	}
+
+	if previous != nil {
+		previous.close()
+	}
+
+	if s != nil {
+		loggerSingleton.replaySpool()
+	}
+
+	return nil
+}
+
+// SpoolSync sets when spool writes are flushed to stable storage with fsync.
+// The zero value leaves it to the operating system, which is fastest but can
+// lose recently spooled messages on power loss. Conditions combine, a sync
+// happens when any is met.
+type SpoolSync struct {
+	// EveryBatch syncs after every spooled batch, so ErrSpooled is only
+	// reported once the batch is durable.
+	EveryBatch bool
+
+	// Bytes syncs once this many bytes were written since the last sync.
+	Bytes int64
+
+	// Interval syncs pending writes at most this long after they are made.
+	Interval time.Duration
+}
+
+// SetSpoolSync sets the spool's fsync policy.
+func SetSpoolSync(policy SpoolSync) {
+	loggerSingleton.Lock()
+	loggerSingleton.spoolSync = policy
+	s := loggerSingleton.spool
+	loggerSingleton.Unlock()
+
+	if s != nil {
+		s.setSync(policy)
+	}
+}
+
+// SpoolStats describes the spool from its in memory index, without touching
+// the disk.
+func SpoolStats() SpoolSnapshot {
+	loggerSingleton.Lock()
+	s := loggerSingleton.spool
+	loggerSingleton.Unlock()
+
+	if s == nil {
+		return SpoolSnapshot{}
+	}
+
+	return s.stats()
+}
+
+// segment indexes a spool segment file.
+type segment struct {
+	Name  string    `json:"name"`
+	Count int       `json:"count"`
+	Bytes int64     `json:"bytes"`
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+
+	// Offset and Replayed track replay progress, so replay resumes mid
+	// segment after a crash.
+	Offset   int64 `json:"offset"`
+	Replayed int   `json:"replayed"`
+}
+
+func (seg *segment) pending() int {
+	return seg.Count - seg.Replayed
+}
+
+type spoolRecord struct {
+	time time.Time
+	data []byte
+}
+
+// spool stores messages in segment files under dir. Several processes may
+// share dir: each appends to segments of its own, index updates are serialized
+// by a lock on spoolLockFile, and only the process holding spoolUploadLock
+// replays, so nothing is shipped twice.
+type spool struct {
+	sync.Mutex
+	dir      string
+	segments []*segment
+
+	// head is the segment this process appends to through current. current
+	// stays locked while open, which tells other processes that the segment
+	// is still being written.
+	head    *segment
+	current *os.File
+
+	// segmentAge seals the head this long after it was started, so an
+	// uploader in another process picks it up without waiting for it to fill.
+	segmentAge time.Duration
+	sealTimer  *time.Timer
+
+	dirLock    *os.File
+	uploadLock *os.File
+
+	// scanned records segments written by other processes that were checked
+	// for records missing from the index.
+	scanned map[string]bool
+
+	replaying bool
+
+	policy   SpoolSync
+	unsynced int64
+	timer    *time.Timer
+	fsync    func(f *os.File) error
+
+	corrupted   int
+	quarantined int
+}
+
+// spoolBatch is a run of records read from a segment for replay.
+type spoolBatch struct {
+	name     string
+	payloads [][]byte
+
+	// records counts the records read, including corrupted ones that were
+	// skipped, and offset is just past the last of them.
+	records int
+	offset  int64
+}
+
+// openSpool opens the spool in dir, recovering records appended after the
+// index was last written and truncating torn final records.
+func openSpool(dir string) (*spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	dirLock, err := os.OpenFile(filepath.Join(dir, spoolLockFile), os.O_CREATE|os.O_RDWR, 0644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := &spool{
+		dir:        dir,
+		dirLock:    dirLock,
+		scanned:    map[string]bool{},
+		fsync:      (*os.File).Sync,
+		segmentAge: spoolSegmentAge,
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.update(s.reconcile); err != nil {
+		dirLock.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// update reloads the index, applies fn and writes the index back, all under
+// the directory lock. It must be called with the lock held.
+func (s *spool) update(fn func() error) error {
+	if _, err := lockFile(s.dirLock, true); err != nil {
+		return err
+	}
+
+	defer unlockFile(s.dirLock)
+
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	if fn != nil {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+
+	return s.writeIndex()
+}
+
+// load replaces the in memory index with the one on disk, keeping the head's
+// counts, which only this process knows. It must be called with the lock
+// held.
+func (s *spool) load() error {
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, spoolIndexFile))
+
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var segments []*segment
+
+	// A damaged index is rebuilt from the segments themselves on open.
+	if len(b) > 0 && json.Unmarshal(b, &segments) != nil {
+		return nil
+	}
+
+	found := false
+
+	for i, seg := range segments {
+		if s.head != nil && seg.Name == s.head.Name {
+			s.head.Offset, s.head.Replayed = seg.Offset, seg.Replayed
+			segments[i] = s.head
+			found = true
+		}
+	}
+
+	if s.head != nil && !found {
+		segments = append(segments, s.head)
+	}
+
+	s.segments = segments
+
+	return nil
+}
+
+// reconcile brings the index in line with the segments on disk. It must be
+// called from update.
+func (s *spool) reconcile() error {
+	indexed := map[string]bool{}
+
+	var segments []*segment
+
+	for _, seg := range s.segments {
+		if _, err := os.Stat(filepath.Join(s.dir, seg.Name)); err == nil {
+			indexed[seg.Name] = true
+			segments = append(segments, seg)
+		}
+	}
+
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*"+spoolSegmentExt))
+
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if name := filepath.Base(path); !indexed[name] {
+			segments = append(segments, &segment{Name: name, Bytes: spoolHeaderSize, Offset: spoolHeaderSize})
+		}
+	}
+
+	sort.Slice(segments, func(i, j int) bool { return segments[i].Name < segments[j].Name })
+	s.segments = segments
+
+	for _, seg := range segments {
+		if sealed, err := s.sealed(seg); err != nil || !sealed {
+			continue
+		}
+
+		if err := s.scan(seg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sealed reports whether seg was written by another process that is done
+// writing it. It must be called with the lock held.
+func (s *spool) sealed(seg *segment) (bool, error) {
+	if seg == s.head {
+		return false, nil
+	}
+
+	f, err := os.Open(filepath.Join(s.dir, seg.Name))
+
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	defer f.Close()
+
+	return lockFile(f, false)
+}
+
+// scan indexes the records of a sealed segment missing from the index,
+// quarantining the segment if it is unreadable and removing it if it was
+// fully replayed. It must be called from update.
+func (s *spool) scan(seg *segment) error {
+	if s.scanned[seg.Name] {
+		return nil
+	}
+
+	s.scanned[seg.Name] = true
+
+	if err := s.recover(seg); err != nil {
+		return s.quarantine(seg)
+	}
+
+	if seg.pending() == 0 {
+		s.remove(seg)
+		return os.Remove(filepath.Join(s.dir, seg.Name))
+	}
+
+	return nil
+}
+
+// recover indexes records past seg.Bytes.
+func (s *spool) recover(seg *segment) error {
+	path := filepath.Join(s.dir, seg.Name)
+	f, err := os.OpenFile(path, os.O_RDWR, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	if err := readSpoolHeader(f); err != nil {
+		return err
+	}
+
+	if _, err := f.Seek(seg.Bytes, io.SeekStart); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(f)
+
+	for {
+		record, size, err := readSpoolRecord(r)
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err == io.ErrUnexpectedEOF {
+			// The process died mid write, drop the partial record. A corrupted
+			// length looks the same, so keep a copy of what is dropped.
+			if err := s.quarantineTail(f, seg); err != nil {
+				return err
+			}
+
+			return f.Truncate(seg.Bytes)
+		}
+
+		if err == errCorruptRecord {
+			// Count it so offsets stay right, replay skips it.
+			seg.Count++
+			seg.Bytes += size
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		seg.add(record.time, size)
+	}
+}
+
+// quarantineTail copies the segment from seg.Bytes on to the quarantine
+// directory before it is truncated.
+func (s *spool) quarantineTail(f *os.File, seg *segment) error {
+	if _, err := f.Seek(seg.Bytes, io.SeekStart); err != nil {
+		return err
+	}
+
+	tail, err := ioutil.ReadAll(f)
+
+	if err != nil || len(tail) == 0 {
+		return err
+	}
+
+	dir := filepath.Join(s.dir, spoolQuarantine)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%s.%d.tail", seg.Name, seg.Bytes)), tail, 0644)
+}
+
+// reject writes payloads of the batch the endpoint rejected for good to the
+// quarantine directory, keeping them for inspection rather than retrying
+// them forever.
+func (s *spool) reject(batch *spoolBatch, payloads [][]byte) error {
+	dir := filepath.Join(s.dir, spoolQuarantine)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	s.Lock()
+	s.quarantined++
+	name := fmt.Sprintf("%s.%d.%d.rejected", batch.name, batch.offset, s.quarantined)
+	s.Unlock()
+
+	body := append(bytes.Join(payloads, []byte("\n")), '\n')
+
+	return ioutil.WriteFile(filepath.Join(dir, name), body, 0644)
+}
+
+// quarantine moves an unreadable segment out of the spool. It must be called
+// from update.
+func (s *spool) quarantine(seg *segment) error {
+	if seg == s.head {
+		unlockFile(s.current)
+		s.current.Close()
+		s.current = nil
+		s.head = nil
+	}
+
+	s.remove(seg)
+
+	dir := filepath.Join(s.dir, spoolQuarantine)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	s.quarantined++
+
+	return os.Rename(filepath.Join(s.dir, seg.Name), filepath.Join(dir, seg.Name))
+}
+
+// find returns the indexed segment called name. It must be called with the
+// lock held.
+func (s *spool) find(name string) *segment {
+	for _, seg := range s.segments {
+		if seg.Name == name {
+			return seg
+		}
+	}
+
+	return nil
+}
+
+// remove drops seg from the index. It must be called with the lock held.
+func (s *spool) remove(seg *segment) {
+	for i, candidate := range s.segments {
+		if candidate == seg {
+			s.segments = append(s.segments[:i:i], s.segments[i+1:]...)
+			return
+		}
+	}
+}
+
+func (seg *segment) add(t time.Time, size int64) {
+	if seg.Count == 0 {
+		seg.First = t
+	}
+
+	seg.Count++
+	seg.Bytes += size
+	seg.Last = t
+}
+
+// append writes records to the head segment, starting a new one when it is
+// full.
+func (s *spool) append(records []spoolRecord) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.current == nil || s.head.Bytes >= spoolSegmentSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	seg := s.head
+
+	var buf bytes.Buffer
+
+	for _, record := range records {
+		writeSpoolRecord(&buf, record)
+	}
+
+	if _, err := s.current.Write(buf.Bytes()); err != nil {
+		// Cut off whatever part of the write made it, so the segment ends on
+		// a record boundary.
+		s.current.Truncate(seg.Bytes)
+		return err
+	}
+
+	for _, record := range records {
+		seg.add(record.time, int64(spoolRecordHeader+len(record.data)))
+	}
+
+	s.unsynced += int64(buf.Len())
+
+	if s.policy.EveryBatch || (s.policy.Bytes > 0 && s.unsynced >= s.policy.Bytes) {
+		return s.sync()
+	}
+
+	if s.policy.Interval > 0 && s.timer == nil {
+		s.timer = time.AfterFunc(s.policy.Interval, s.syncPending)
+	}
+
+	return nil
+}
+
+func (s *spool) setSync(policy SpoolSync) {
+	s.Lock()
+	s.policy = policy
+	s.Unlock()
+}
+
+// sync flushes the head segment to stable storage. It must be called with the
+// lock held.
+func (s *spool) sync() error {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+
+	if s.current == nil || s.unsynced == 0 {
+		return nil
+	}
+
+	if err := s.fsync(s.current); err != nil {
+		return err
+	}
+
+	s.unsynced = 0
+
+	return nil
+}
+
+func (s *spool) syncPending() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.timer = nil
+	s.sync()
+}
+
+func (s *spool) durable() bool {
+	return s.policy != SpoolSync{}
+}
+
+// syncDir makes file creations, removals and renames in the spool directory
+// durable.
+func (s *spool) syncDir() error {
+	d, err := os.Open(s.dir)
+
+	if err != nil {
+		return err
+	}
+
+	defer d.Close()
+
+	return s.fsync(d)
+}
+
+// seal stops writing the head segment, handing it over to the uploader. It
+// must be called from update, so the head's final counts are indexed.
+func (s *spool) seal() {
+	if s.sealTimer != nil {
+		s.sealTimer.Stop()
+		s.sealTimer = nil
+	}
+
+	if s.current == nil {
+		return
+	}
+
+	// Writes to the old segment must not be lost behind the new one.
+	if s.durable() {
+		s.sync()
+	}
+
+	unlockFile(s.current)
+	s.current.Close()
+	s.current = nil
+	s.head = nil
+}
+
+// rotate starts a new head segment. Segments are named by creation time and
+// process so that processes sharing the directory never collide. It must be
+// called with the lock held.
+func (s *spool) rotate() error {
+	return s.update(func() error {
+		s.seal()
+
+		name := fmt.Sprintf("%019d-%d%s", time.Now().UnixNano(), os.Getpid(), spoolSegmentExt)
+		f, err := os.OpenFile(filepath.Join(s.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0644)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := lockFile(f, true); err != nil {
+			f.Close()
+			return err
+		}
+
+		if _, err := f.Write(append([]byte(spoolMagic), spoolVersion)); err != nil {
+			f.Close()
+			return err
+		}
+
+		s.current = f
+		s.head = &segment{Name: name, Bytes: spoolHeaderSize, Offset: spoolHeaderSize}
+		s.segments = append(s.segments, s.head)
+		s.sealTimer = time.AfterFunc(s.segmentAge, func() { s.sealHead(name) })
+
+		if s.durable() {
+			return s.syncDir()
+		}
+
+		return nil
+	})
+}
+
+// sealHead seals the head if it is still the segment called name.
+func (s *spool) sealHead(name string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.head == nil || s.head.Name != name {
+		return
+	}
+
+	s.update(func() error {
+		s.seal()
+		return nil
+	})
+}
+
+// acquireUpload makes this process the spool's uploader if no other process
+// is. It must be called with the lock held.
+func (s *spool) acquireUpload() bool {
+	if s.uploadLock != nil {
+		return true
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, spoolUploadLock), os.O_CREATE|os.O_RDWR, 0644)
+
+	if err != nil {
+		return false
+	}
+
+	if ok, err := lockFile(f, false); err != nil || !ok {
+		f.Close()
+		return false
+	}
+
+	s.uploadLock = f
+
+	return true
+}
+
+// candidate returns the oldest segment with records pending replay, either
+// the head or a sealed segment. It must be called with the lock held.
+func (s *spool) candidate() (*segment, error) {
+	for _, seg := range s.segments {
+		if seg != s.head {
+			sealed, err := s.sealed(seg)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if !sealed {
+				continue
+			}
+
+			if !s.scanned[seg.Name] {
+				name := seg.Name
+
+				err := s.update(func() error {
+					if seg := s.find(name); seg != nil {
+						return s.scan(seg)
+					}
+
+					return nil
+				})
+
+				if err != nil {
+					return nil, err
+				}
+
+				// The index was reloaded, start over.
+				return s.candidate()
+			}
+		}
+
+		if seg.pending() > 0 {
+			return seg, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// next reads up to max records from the oldest segment with pending records,
+// skipping corrupted records and quarantining segments that cannot be read.
+// It returns nil once nothing is pending, or if another process is the
+// uploader.
+func (s *spool) next(max int) (*spoolBatch, error) {
+	for {
+		s.Lock()
+
+		if !s.acquireUpload() {
+			s.Unlock()
+			return nil, nil
+		}
+
+		// Reading the index without the directory lock is safe, it is only
+		// ever replaced by a rename.
+		if err := s.load(); err != nil {
+			s.Unlock()
+			return nil, err
+		}
+
+		seg, err := s.candidate()
+
+		if err != nil || seg == nil {
+			s.Unlock()
+			return nil, err
+		}
+
+		name, offset, limit := seg.Name, seg.Offset, seg.Bytes
+		s.Unlock()
+
+		batch, err := s.read(name, offset, limit, max)
+
+		if err == nil {
+			return batch, nil
+		}
+
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+
+		s.Lock()
+		err = s.update(func() error {
+			if seg := s.find(name); seg != nil {
+				return s.quarantine(seg)
+			}
+
+			return nil
+		})
+		s.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *spool) read(name string, offset, limit int64, max int) (*spoolBatch, error) {
+	f, err := os.Open(filepath.Join(s.dir, name))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(f)
+	batch := &spoolBatch{name: name, offset: offset}
+
+	for batch.records < max && batch.offset < limit {
+		record, size, err := readSpoolRecord(r)
+
+		if err == errCorruptRecord {
+			s.Lock()
+			s.corrupted++
+			s.Unlock()
+		} else if err != nil {
+			return nil, err
+		} else {
+			batch.payloads = append(batch.payloads, record.data)
+		}
+
+		batch.records++
+		batch.offset += size
+	}
+
+	return batch, nil
+}
+
+// commit marks the batch as replayed, removing the segment once it is fully
+// replayed and no longer being written.
+func (s *spool) commit(batch *spoolBatch) error {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.update(func() error {
+		seg := s.find(batch.name)
+
+		if seg == nil {
+			return nil
+		}
+
+		seg.Offset = batch.offset
+		seg.Replayed += batch.records
+
+		if seg.pending() == 0 && seg != s.head {
+			s.remove(seg)
+			return os.Remove(filepath.Join(s.dir, seg.Name))
+		}
+
+		return nil
+	})
+}
+
+// stats describes the spool as of the last index update.
+func (s *spool) stats() SpoolSnapshot {
+	s.Lock()
+	defer s.Unlock()
+
+	var snapshot SpoolSnapshot
+
+	for _, seg := range s.segments {
+		if seg.pending() == 0 {
+			continue
+		}
+
+		if snapshot.Segments == 0 {
+			snapshot.Oldest = seg.First
+		}
+
+		snapshot.Segments++
+		snapshot.Events += seg.pending()
+		snapshot.Bytes += seg.Bytes - seg.Offset
+		snapshot.Newest = seg.Last
+	}
+
+	snapshot.Corrupted = s.corrupted
+	snapshot.Quarantined = s.quarantined
+
+	return snapshot
+}
+
+// writeIndex persists the index, replacing it atomically. It must be called
+// from update.
+func (s *spool) writeIndex() error {
+	b, err := json.Marshal(s.segments)
+
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(s.dir, spoolIndexFile)
+	f, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+
+	// Without a sync the rename can land before the data, leaving an empty
+	// index after a power loss.
+	if s.durable() {
+		if err := s.fsync(f); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+
+	if s.durable() {
+		return s.syncDir()
+	}
+
+	return nil
+}
+
+// close seals the head segment and gives up the upload lock.
+func (s *spool) close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	err := s.update(func() error {
+		s.seal()
+		return nil
+	})
+
+	if s.uploadLock != nil {
+		unlockFile(s.uploadLock)
+		s.uploadLock.Close()
+		s.uploadLock = nil
+	}
+
+	s.dirLock.Close()
+
+	return err
+}
+
+func readSpoolHeader(r io.Reader) error {
+	header := make([]byte, spoolHeaderSize)
+
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+
+	if string(header[:len(spoolMagic)]) != spoolMagic {
+		return errors.New("not a spool segment")
+	}
+
+	if header[len(spoolMagic)] != spoolVersion {
+		return fmt.Errorf("unsupported spool version %d", header[len(spoolMagic)])
+	}
+
+	return nil
+}
+
+func writeSpoolRecord(buf *bytes.Buffer, record spoolRecord) {
+	var header [spoolRecordHeader]byte
+
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(record.data)))
+	binary.BigEndian.PutUint64(header[8:16], uint64(record.time.UnixNano()))
+	binary.BigEndian.PutUint32(header[4:8], crc32.Update(crc32.Checksum(header[8:16], spoolCRC), spoolCRC, record.data))
+
+	buf.Write(header[:])
+	buf.Write(record.data)
+}
+
+// readSpoolRecord reads a record and its size on disk, returning io.EOF at a
+// clean end, io.ErrUnexpectedEOF for a torn record and errCorruptRecord for a
+// record failing its checksum.
+func readSpoolRecord(r io.Reader) (spoolRecord, int64, error) {
+	var header [spoolRecordHeader]byte
+
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return spoolRecord{}, 0, err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint32(header[0:4]))
+
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return spoolRecord{}, 0, err
+	}
+
+	size := int64(spoolRecordHeader + len(data))
+
+	if crc32.Update(crc32.Checksum(header[8:16], spoolCRC), spoolCRC, data) != binary.BigEndian.Uint32(header[4:8]) {
+		return spoolRecord{}, size, errCorruptRecord
+	}
+
+	record := spoolRecord{
+		time: time.Unix(0, int64(binary.BigEndian.Uint64(header[8:16]))),
+		data: data,
+	}
+
+	return record, size, nil
+}
+
+// spoolMessages writes messages that failed to ship to the spool, reporting
+// whether they were spooled.
+func (l *logger) spoolMessages(messages []*logMessage) bool {
+	l.Lock()
+	s := l.spool
+	l.Unlock()
+
+	if s == nil {
+		return l.storeMessages(messages)
+	}
+
+	if len(messages) == 0 {
+		return false
+	}
+
+	records := make([]spoolRecord, 0, len(messages))
+	now := l.now()
+
+	for _, m := range messages {
+		b, err := json.Marshal(m)
+
+		if err != nil {
+			return false
+		}
+
+		records = append(records, spoolRecord{time: now, data: b})
+	}
+
+	if err := s.append(records); err != nil {
+		if l.debugMode {
+			fmt.Printf("There was an error spooling messages: %s", err)
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// replaySpool ships spooled messages until the spool is empty or a shipment
+// fails with an error worth retrying later. Only one replay runs at a time.
+func (l *logger) replaySpool() {
+	l.Lock()
+	s := l.spool
+	store := l.store
+	spoolOnly := l.spoolOnly
+	l.Unlock()
+
+	// In spool only mode replay is left to an Uploader.
+	if spoolOnly || (s == nil && store == nil) {
+		return
+	}
+
+	if s == nil {
+		if l.isSynchronous() {
+			l.replayStore()
+		} else {
+			l.goShip(l.replayStore)
+		}
+
+		return
+	}
+
+	s.Lock()
+	if s.replaying {
+		s.Unlock()
+		return
+	}
+	s.replaying = true
+	s.Unlock()
+
+	replay := func() {
+		defer func() {
+			s.Lock()
+			s.replaying = false
+			s.Unlock()
+		}()
+
+		// Outside of bulk mode the endpoint takes a single message per request.
+		size := 1
+		if l.bulk {
+			size = spoolReplayBatch
+		}
+
+		for {
+			next, err := s.next(size)
+
+			if err != nil || next == nil {
+				return
+			}
+
+			// Payloads are grouped by the input they were routed to, a failure
+			// part way replays the whole batch again. A batch of nothing but
+			// corrupted records has nothing to ship.
+			var urls []string
+			groups := map[string][][]byte{}
+
+			for _, payload := range next.payloads {
+				url := l.payloadURL(payload)
+
+				if _, ok := groups[url]; !ok {
+					urls = append(urls, url)
+				}
+
+				groups[url] = append(groups[url], payload)
+			}
+
+			for _, url := range urls {
+				if !l.replayPayloads(s, next, url, groups[url]) {
+					return
+				}
+			}
+
+			if err := s.commit(next); err != nil {
+				return
+			}
+		}
+	}
+
+	if l.isSynchronous() {
+		replay()
+	} else {
+		l.goShip(replay)
+	}
+}
+
+// replayPayloads posts payloads spooled for url, quarantining the ones the
+// endpoint rejects by halving the batch until they are isolated, as
+// Uploader.upload does. It reports false when replay should stop with the
+// payloads left in the spool.
+func (l *logger) replayPayloads(s *spool, batch *spoolBatch, url string, payloads [][]byte) bool {
+	body := bytes.Join(payloads, []byte("\n"))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	start := time.Now()
+	err := l.post(ctx, url, body)
+	cancel()
+
+	l.stats.recordBatch(BatchInfo{Events: len(payloads), Bytes: len(body), Latency: time.Since(start), Attempt: 1}, err)
+
+	switch {
+	case err == nil:
+		return true
+	case spoolable(err):
+		return false
+	case len(payloads) < 2:
+		return s.reject(batch, payloads) == nil
+	}
+
+	half := len(payloads) / 2
+
+	return l.replayPayloads(s, batch, url, payloads[:half]) && l.replayPayloads(s, batch, url, payloads[half:])
+}
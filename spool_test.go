@@ -0,0 +1,427 @@
+package log
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func tempSpoolDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return dir
+}
+
+func appendRecords(t *testing.T, s *spool, n int) {
+	for i := 0; i < n; i++ {
+		record := spoolRecord{time: time.Unix(int64(1000+i), 0), data: []byte(fmt.Sprintf(`{"message":"record %d"}`, i))}
+
+		if err := s.append([]spoolRecord{record}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSpoolRecovery(t *testing.T) {
+	dir := tempSpoolDir(t)
+
+	s, err := openSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appendRecords(t, s, 3)
+
+	// Simulate a crash mid write: the index is stale and the last record torn.
+	s.current.Write([]byte{0, 0, 0, 40, 1, 2})
+	s.current.Close()
+
+	s, err = openSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := s.stats()
+	if stats.Events != 3 || stats.Segments != 1 || !stats.Oldest.Equal(time.Unix(1000, 0)) || !stats.Newest.Equal(time.Unix(1002, 0)) {
+		t.Fatalf("unexpected stats after recovery %+v", stats)
+	}
+
+	batch, err := s.next(2)
+	if err != nil || len(batch.payloads) != 2 || string(batch.payloads[1]) != `{"message":"record 1"}` {
+		t.Fatalf("unexpected replay %+v: %v", batch, err)
+	}
+
+	if err := s.commit(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	s.close()
+
+	// Replay resumes mid segment after reopening.
+	s, err = openSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if batch, _ := s.next(10); len(batch.payloads) != 1 || string(batch.payloads[0]) != `{"message":"record 2"}` {
+		t.Errorf("expected replay to resume at record 2, got %q", batch.payloads)
+	}
+
+	if stats := s.stats(); stats.Events != 1 || stats.Bytes != int64(spoolRecordHeader+len(`{"message":"record 2"}`)) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestSpoolRemovesReplayedSegments(t *testing.T) {
+	dir := tempSpoolDir(t)
+
+	s, err := openSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appendRecords(t, s, 1)
+
+	if err := s.rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	batch, _ := s.next(10)
+	s.commit(batch)
+
+	if _, err := os.Stat(filepath.Join(dir, batch.name)); !os.IsNotExist(err) {
+		t.Errorf("expected the replayed segment to be removed, got %v", err)
+	}
+}
+
+func TestSpoolReplay(t *testing.T) {
+	var failing int32 = 1
+	bodies := make(chan string, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(b)
+	}))
+	defer server.Close()
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, true, false)
+	l.url = server.URL
+	l.synchronous = true
+
+	var err error
+	if l.spool, err = openSpool(tempSpoolDir(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	ack := make(chan error, 1)
+	l.log(record{output: "This is spooled.", level: LogLevelInfo, ack: ack})
+	l.flush()
+
+	if err := <-ack; err != ErrSpooled {
+		t.Fatalf("expected ErrSpooled, got %v", err)
+	}
+
+	if stats := l.spool.stats(); stats.Events != 1 {
+		t.Fatalf("expected 1 spooled event, got %+v", stats)
+	}
+
+	atomic.StoreInt32(&failing, 0)
+
+	l.buildAndShipMessage("This ships directly.", LogLevelInfo, false, nil)
+	l.flush()
+
+	if body := <-bodies; !strings.Contains(body, "This ships directly.") {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	if body := <-bodies; !strings.Contains(body, "This is spooled.") {
+		t.Errorf("expected the spooled message to be replayed, got %q", body)
+	}
+
+	if stats := l.spool.stats(); stats.Events != 0 {
+		t.Errorf("expected an empty spool, got %+v", stats)
+	}
+}
+
+func TestSpoolReplayQuarantinesRejected(t *testing.T) {
+	var failing int32 = 1
+	bodies := make(chan string, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+
+		switch {
+		case atomic.LoadInt32(&failing) == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case strings.Contains(string(b), "poisoned"):
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			bodies <- string(b)
+		}
+	}))
+	defer server.Close()
+
+	dir := tempSpoolDir(t)
+
+	l := newLogger("yourlogglytoken", 0, []string{"test"}, true, false)
+	l.url = server.URL
+	l.synchronous = true
+
+	var err error
+	if l.spool, err = openSpool(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	l.buildAndShipMessage("This is poisoned.", LogLevelInfo, false, nil)
+	l.buildAndShipMessage("This is fine.", LogLevelInfo, false, nil)
+	l.flush()
+
+	atomic.StoreInt32(&failing, 0)
+
+	// A rejected batch isn't spooled, it would fail again.
+	ack := make(chan error, 1)
+	l.log(record{output: "This is poisoned too.", level: LogLevelInfo, ack: ack})
+	l.flush()
+
+	if err := <-ack; err == nil || err == ErrSpooled {
+		t.Fatalf("expected the rejection, got %v", err)
+	}
+
+	if stats := l.spool.stats(); stats.Events != 2 {
+		t.Fatalf("expected 2 spooled events, got %+v", stats)
+	}
+
+	// Replay quarantines the rejected message and delivers the rest.
+	l.buildAndShipMessage("This ships directly.", LogLevelInfo, false, nil)
+	l.flush()
+
+	if body := <-bodies; !strings.Contains(body, "This ships directly.") {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	if body := <-bodies; !strings.Contains(body, "This is fine.") {
+		t.Errorf("expected the spooled message to be replayed, got %q", body)
+	}
+
+	if stats := l.spool.stats(); stats.Events != 0 {
+		t.Errorf("expected an empty spool, got %+v", stats)
+	}
+
+	rejected, _ := filepath.Glob(filepath.Join(dir, spoolQuarantine, "*.rejected"))
+
+	if len(rejected) != 1 {
+		t.Fatalf("expected the rejected message quarantined, got %v", rejected)
+	}
+
+	if b, _ := ioutil.ReadFile(rejected[0]); !strings.Contains(string(b), "This is poisoned.") {
+		t.Errorf("unexpected quarantined payloads %q", b)
+	}
+}
+
+func TestSpoolSyncPolicy(t *testing.T) {
+	cases := []struct {
+		policy SpoolSync
+		want   int32
+	}{
+		{SpoolSync{}, 0},
+		{SpoolSync{EveryBatch: true}, 4},
+		{SpoolSync{Bytes: 100}, 1},
+	}
+
+	for _, c := range cases {
+		s, err := openSpool(tempSpoolDir(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Count segment syncs only, directory and index syncs come on top.
+		var syncs int32
+		s.fsync = func(f *os.File) error {
+			if strings.HasSuffix(f.Name(), spoolSegmentExt) {
+				atomic.AddInt32(&syncs, 1)
+			}
+			return nil
+		}
+		s.setSync(c.policy)
+
+		// Each record is 38 bytes on disk.
+		appendRecords(t, s, 4)
+
+		if syncs != c.want {
+			t.Errorf("%+v: expected %d syncs, got %d", c.policy, c.want, syncs)
+		}
+	}
+}
+
+func TestSpoolSyncInterval(t *testing.T) {
+	s, err := openSpool(tempSpoolDir(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	synced := make(chan string, 10)
+	s.fsync = func(f *os.File) error {
+		synced <- f.Name()
+		return nil
+	}
+	s.setSync(SpoolSync{Interval: 10 * time.Millisecond})
+
+	appendRecords(t, s, 2)
+
+	for {
+		select {
+		case name := <-synced:
+			if strings.HasSuffix(name, spoolSegmentExt) {
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected the pending writes to be synced")
+		}
+	}
+}
+
+func TestSpoolCorruption(t *testing.T) {
+	dir := tempSpoolDir(t)
+
+	s, err := openSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appendRecords(t, s, 3)
+	name := s.segments[0].Name
+	s.close()
+
+	// Flip a payload byte in the second record.
+	path := filepath.Join(dir, name)
+	b, _ := ioutil.ReadFile(path)
+	second := spoolHeaderSize + spoolRecordHeader + int64(len(`{"message":"record 0"}`))
+	b[second+spoolRecordHeader+2] ^= 0xff
+	ioutil.WriteFile(path, b, 0644)
+
+	// A segment with a broken header can't be read at all.
+	ioutil.WriteFile(filepath.Join(dir, "garbage"+spoolSegmentExt), []byte("garbage"), 0644)
+
+	s, err = openSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, spoolQuarantine, "garbage"+spoolSegmentExt)); err != nil {
+		t.Errorf("expected the unreadable segment to be quarantined: %s", err)
+	}
+
+	batch, err := s.next(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if batch.records != 3 || len(batch.payloads) != 2 || string(batch.payloads[1]) != `{"message":"record 2"}` {
+		t.Errorf("expected the corrupted record to be skipped, got %d records and %q", batch.records, batch.payloads)
+	}
+
+	if stats := s.stats(); stats.Corrupted != 1 || stats.Quarantined != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestSpoolQuarantinesTruncatedSegment(t *testing.T) {
+	dir := tempSpoolDir(t)
+
+	s, err := openSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appendRecords(t, s, 2)
+	name := s.segments[0].Name
+
+	// The index claims more than the segment holds, as after losing writes.
+	os.Truncate(filepath.Join(dir, name), spoolHeaderSize+spoolRecordHeader+5)
+
+	if _, err := s.next(10); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, spoolQuarantine, name)); err != nil {
+		t.Errorf("expected the segment to be quarantined: %s", err)
+	}
+
+	if stats := s.stats(); stats.Events != 0 || stats.Quarantined != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestSpoolSharedBetweenProcesses(t *testing.T) {
+	dir := tempSpoolDir(t)
+
+	// Separate opens lock independently, like separate processes would.
+	first, err := openSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := openSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appendRecords(t, first, 2)
+	appendRecords(t, second, 1)
+
+	if first.head.Name == second.head.Name {
+		t.Fatalf("expected per process segments, both write %s", first.head.Name)
+	}
+
+	// The first to ask becomes the uploader, it replays its own segment but
+	// not the one the second is still writing.
+	batch, err := first.next(10)
+	if err != nil || batch == nil || batch.name != first.head.Name || batch.records != 2 {
+		t.Fatalf("unexpected batch %+v: %v", batch, err)
+	}
+	first.commit(batch)
+
+	if batch, err := second.next(10); batch != nil || err != nil {
+		t.Errorf("expected only the uploader to replay, got %+v: %v", batch, err)
+	}
+
+	if batch, _ := first.next(10); batch != nil {
+		t.Errorf("expected the segment being written to be left alone, got %+v", batch)
+	}
+
+	// Once the second is done with its segment the uploader replays it.
+	name := second.head.Name
+	second.close()
+
+	batch, err = first.next(10)
+	if err != nil || batch == nil || batch.name != name || string(batch.payloads[0]) != `{"message":"record 0"}` {
+		t.Fatalf("unexpected batch %+v: %v", batch, err)
+	}
+	first.commit(batch)
+
+	if batch, _ := first.next(10); batch != nil {
+		t.Errorf("expected nothing left to replay, got %+v", batch)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+		t.Errorf("expected the replayed segment to be removed, got %v", err)
+	}
+
+	first.close()
+}
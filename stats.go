@@ -0,0 +1,268 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Histogram is a snapshot of a bucketed distribution.
+type Histogram struct {
+	// Bounds are the inclusive upper bounds of each bucket.
+	Bounds []float64
+
+	// Counts holds the observations per bucket. It has one more entry than
+	// Bounds for observations above the last bound.
+	Counts []uint64
+
+	// Count is the total number of observations.
+	Count uint64
+
+	// Sum is the sum of every observation.
+	Sum float64
+}
+
+// Mean returns the average observation, or zero if there were none.
+func (h Histogram) Mean() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+
+	return h.Sum / float64(h.Count)
+}
+
+// StatsSnapshot holds the logger's shipping counters and distributions.
+type StatsSnapshot struct {
+	EventsShipped  uint64
+	EventsFailed   uint64
+	BatchesShipped uint64
+	BatchesFailed  uint64
+	BytesShipped   uint64
+
+	// EventsFiltered counts events dropped by filter rules and budgets.
+	EventsFiltered uint64
+
+	// PanicsRecovered counts panics recovered by Middleware.
+	PanicsRecovered uint64
+
+	// Latency is the distribution of request latency in seconds.
+	Latency Histogram
+
+	// PayloadSize is the distribution of request body sizes in bytes.
+	PayloadSize Histogram
+
+	// VolumeDay is the UTC day the shipped volume below covers, reset daily.
+	VolumeDay time.Time
+	Volume    Volume
+
+	// ComponentVolume and TagVolume break the day's volume down by component
+	// field and by Loggly tag, showing what drives ingest costs.
+	ComponentVolume map[string]Volume
+	TagVolume       map[string]Volume
+
+	// LogMetrics are the metrics derived from events, see AddLogMetric.
+	LogMetrics []LogMetricSnapshot
+}
+
+var (
+	latencyBounds     = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	payloadSizeBounds = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576, 5242880}
+)
+
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+func (h *histogram) observe(v float64) {
+	i := 0
+	for i < len(h.bounds) && v > h.bounds[i] {
+		i++
+	}
+
+	h.counts[i]++
+	h.count++
+	h.sum += v
+}
+
+func (h *histogram) snapshot() Histogram {
+	counts := make([]uint64, len(h.counts))
+	copy(counts, h.counts)
+
+	return Histogram{Bounds: h.bounds, Counts: counts, Count: h.count, Sum: h.sum}
+}
+
+type stats struct {
+	sync.Mutex
+	eventsShipped  uint64
+	eventsFailed   uint64
+	batchesShipped uint64
+	batchesFailed  uint64
+	bytesShipped   uint64
+	eventsFiltered uint64
+	panics         uint64
+	latency        *histogram
+	payloadSize    *histogram
+	volume         *volume
+	metrics        []*logMetric
+}
+
+func newStats() *stats {
+	return &stats{
+		latency:     newHistogram(latencyBounds),
+		payloadSize: newHistogram(payloadSizeBounds),
+	}
+}
+
+func (s *stats) recordBatch(info BatchInfo, err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if err != nil {
+		s.batchesFailed++
+		s.eventsFailed += uint64(info.Events)
+	} else {
+		s.batchesShipped++
+		s.eventsShipped += uint64(info.Events)
+		s.bytesShipped += uint64(info.Bytes)
+	}
+
+	s.latency.observe(info.Latency.Seconds())
+	s.payloadSize.observe(float64(info.Bytes))
+}
+
+func (s *stats) recordFiltered() {
+	s.Lock()
+	s.eventsFiltered++
+	s.Unlock()
+}
+
+func (s *stats) recordPanic() {
+	s.Lock()
+	s.panics++
+	s.Unlock()
+}
+
+func (s *stats) snapshot() StatsSnapshot {
+	s.Lock()
+	defer s.Unlock()
+
+	snapshot := StatsSnapshot{
+		EventsShipped:   s.eventsShipped,
+		EventsFailed:    s.eventsFailed,
+		BatchesShipped:  s.batchesShipped,
+		BatchesFailed:   s.batchesFailed,
+		BytesShipped:    s.bytesShipped,
+		EventsFiltered:  s.eventsFiltered,
+		PanicsRecovered: s.panics,
+		Latency:         s.latency.snapshot(),
+		PayloadSize:     s.payloadSize.snapshot(),
+	}
+
+	for _, m := range s.metrics {
+		snapshot.LogMetrics = append(snapshot.LogMetrics, m.snapshot())
+	}
+
+	if s.volume != nil {
+		snapshot.VolumeDay = s.volume.day
+		snapshot.Volume = s.volume.total
+		snapshot.ComponentVolume = copyVolumes(s.volume.components)
+		snapshot.TagVolume = copyVolumes(s.volume.tags)
+	}
+
+	return snapshot
+}
+
+// Stats returns a snapshot of the logger's shipping statistics.
+func Stats() StatsSnapshot {
+	return loggerSingleton.stats.snapshot()
+}
+
+// WritePrometheus writes the logger's statistics to w in the Prometheus text
+// exposition format.
+func WritePrometheus(w io.Writer) error {
+	return loggerSingleton.stats.snapshot().writePrometheus(w)
+}
+
+// PrometheusHandler returns an http.Handler serving the logger's statistics
+// for Prometheus to scrape.
+func PrometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		WritePrometheus(w)
+	})
+}
+
+func (s StatsSnapshot) writePrometheus(w io.Writer) error {
+	counters := []struct {
+		name  string
+		help  string
+		value uint64
+	}{
+		{"loggly_events_shipped_total", "Log events accepted by Loggly.", s.EventsShipped},
+		{"loggly_events_failed_total", "Log events that failed to ship.", s.EventsFailed},
+		{"loggly_batches_shipped_total", "Requests accepted by Loggly.", s.BatchesShipped},
+		{"loggly_batches_failed_total", "Requests that failed.", s.BatchesFailed},
+		{"loggly_bytes_shipped_total", "Request body bytes accepted by Loggly.", s.BytesShipped},
+		{"loggly_events_filtered_total", "Log events dropped by filter rules and budgets.", s.EventsFiltered},
+		{"loggly_panics_recovered_total", "Panics recovered by the HTTP middleware.", s.PanicsRecovered},
+	}
+
+	for _, c := range counters {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.name, c.help, c.name, c.name, c.value); err != nil {
+			return err
+		}
+	}
+
+	if err := writePrometheusHistogram(w, "loggly_ship_latency_seconds", "Shipping request latency.", s.Latency); err != nil {
+		return err
+	}
+
+	if err := writePrometheusHistogram(w, "loggly_payload_bytes", "Shipping request body size.", s.PayloadSize); err != nil {
+		return err
+	}
+
+	for _, m := range s.LogMetrics {
+		var err error
+
+		if m.Values != nil {
+			err = writePrometheusHistogram(w, m.Name, m.Help, *m.Values)
+		} else {
+			_, err = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", m.Name, m.Help, m.Name, m.Name, m.Count)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writePrometheusHistogram(w io.Writer, name string, help string, h Histogram) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name); err != nil {
+		return err
+	}
+
+	var cumulative uint64
+
+	for i, bound := range h.Bounds {
+		cumulative += h.Counts[i]
+
+		if _, err := fmt.Fprintf(w, "%s_bucket{le=\"%g\"} %d\n", name, bound, cumulative); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n%s_sum %g\n%s_count %d\n", name, h.Count, name, h.Sum, name, h.Count)
+
+	return err
+}
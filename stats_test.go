@@ -0,0 +1,65 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistogramObserve(t *testing.T) {
+	h := newHistogram([]float64{1, 10})
+
+	h.observe(0.5)
+	h.observe(1)
+	h.observe(5)
+	h.observe(50)
+
+	snapshot := h.snapshot()
+
+	if snapshot.Counts[0] != 2 || snapshot.Counts[1] != 1 || snapshot.Counts[2] != 1 {
+		t.Errorf("unexpected bucket counts %v", snapshot.Counts)
+	}
+
+	if snapshot.Count != 4 || snapshot.Mean() != 56.5/4 {
+		t.Errorf("unexpected count %d and mean %g", snapshot.Count, snapshot.Mean())
+	}
+}
+
+func TestStatsRecordedOnShip(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	l.buildAndShipMessage("This is an info statement.", LogLevelInfo, false, nil)
+	body := receive(t, bodies)
+
+	stats := l.stats.snapshot()
+
+	if stats.EventsShipped != 1 || stats.BatchesShipped != 1 || stats.BytesShipped != uint64(len(body)) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	if stats.Latency.Count != 1 || stats.PayloadSize.Count != 1 {
+		t.Errorf("expected one latency and payload observation, got %d and %d", stats.Latency.Count, stats.PayloadSize.Count)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	s := newStats()
+	s.recordBatch(BatchInfo{Events: 3, Bytes: 300, Latency: 20 * time.Millisecond, Attempt: 1}, nil)
+
+	var out bytes.Buffer
+	if err := s.snapshot().writePrometheus(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"loggly_events_shipped_total 3",
+		`loggly_ship_latency_seconds_bucket{le="0.025"} 1`,
+		`loggly_payload_bytes_bucket{le="+Inf"} 1`,
+		"loggly_payload_bytes_sum 300",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in output:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package log
+
+import (
+	"regexp"
+	"strings"
+)
+
+// stdPrefix matches level prefixes such as "ERROR:" or "[warn]" on plain
+// output.
+var stdPrefix = regexp.MustCompile(`^\s*(?:\[([A-Za-z]+)\]|([A-Za-z]+):)\s*`)
+
+// SetCaptureStd makes Stdln and Stdf ship their output as well as printing
+// it. The level is inferred from prefixes like "ERROR:" or "[WARN]", which are
+// stripped from the shipped message, and defaults to Info.
+func SetCaptureStd(enabled bool) {
+	loggerSingleton.Lock()
+	loggerSingleton.captureStdout = enabled
+	loggerSingleton.Unlock()
+}
+
+func (l *logger) captureStd(output string) {
+	l.Lock()
+	enabled := l.captureStdout
+	l.Unlock()
+
+	if !enabled {
+		return
+	}
+
+	output = strings.TrimRight(output, "\r\n")
+
+	if output == "" {
+		return
+	}
+
+	level, message := inferLevel(output)
+
+	l.log(record{output: message, level: level, printed: true})
+}
+
+// inferLevel returns the level named by output's prefix and the output with
+// the prefix removed.
+func inferLevel(output string) (Level, string) {
+	match := stdPrefix.FindStringSubmatchIndex(output)
+
+	if match == nil {
+		return LogLevelInfo, output
+	}
+
+	var name string
+	if match[2] >= 0 {
+		name = output[match[2]:match[3]]
+	} else {
+		name = output[match[4]:match[5]]
+	}
+
+	level, err := ParseLevel(name)
+
+	if err != nil {
+		return LogLevelInfo, output
+	}
+
+	return level, output[match[1]:]
+}
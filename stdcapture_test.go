@@ -0,0 +1,43 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInferLevel(t *testing.T) {
+	cases := []struct {
+		output  string
+		level   Level
+		message string
+	}{
+		{"ERROR: disk full", LogLevelError, "disk full"},
+		{"[warn] low battery", LogLevelWarn, "low battery"},
+		{"Warning:voltage sag", LogLevelWarn, "voltage sag"},
+		{"started in 3s", LogLevelInfo, "started in 3s"},
+		{"Note: not a level", LogLevelInfo, "Note: not a level"},
+	}
+
+	for _, c := range cases {
+		level, message := inferLevel(c.output)
+
+		if level != c.level || message != c.message {
+			t.Errorf("inferLevel(%q) = %s, %q, want %s, %q", c.output, level, message, c.level, c.message)
+		}
+	}
+}
+
+func TestCaptureStd(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	l.captureStd("ERROR: not captured yet\n")
+
+	l.captureStdout = true
+	l.captureStd("ERROR: disk full\n")
+	l.flush()
+
+	body := receive(t, bodies)
+	if strings.Contains(body, "not captured") || !strings.Contains(body, `"level":"ERROR","severity":3,"message":"disk full"`) {
+		t.Errorf("unexpected body %q", body)
+	}
+}
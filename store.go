@@ -0,0 +1,166 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// payloadStore keeps messages that failed to ship where there is no
+// filesystem for the spool, such as browser localStorage under js/wasm.
+// Payloads are kept in order and read from the front.
+type payloadStore interface {
+	save(payloads [][]byte) error
+	peek(n int) ([][]byte, error)
+	drop(n int) error
+}
+
+// storeMessages saves messages that failed to ship to the payload store,
+// reporting whether they were kept.
+func (l *logger) storeMessages(messages []*logMessage) bool {
+	l.Lock()
+	store := l.store
+	l.Unlock()
+
+	if store == nil || len(messages) == 0 {
+		return false
+	}
+
+	payloads := make([][]byte, len(messages))
+
+	for i, m := range messages {
+		b, err := json.Marshal(m)
+
+		if err != nil {
+			return false
+		}
+
+		payloads[i] = b
+	}
+
+	if err := store.save(payloads); err != nil {
+		if l.debugMode {
+			fmt.Printf("There was an error storing messages: %s", err)
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// replayStore ships stored messages until the store is empty or a shipment
+// fails.
+func (l *logger) replayStore() {
+	l.Lock()
+	store := l.store
+	replaying := l.storeReplaying
+	if store != nil && !replaying {
+		l.storeReplaying = true
+	}
+	l.Unlock()
+
+	// Only one replay runs at a time.
+	if store == nil || replaying {
+		return
+	}
+
+	defer func() {
+		l.Lock()
+		l.storeReplaying = false
+		l.Unlock()
+	}()
+
+	size := 1
+	if l.bulk {
+		size = spoolReplayBatch
+	}
+
+	for {
+		payloads, err := store.peek(size)
+
+		if err != nil || len(payloads) == 0 {
+			return
+		}
+
+		// Ship one input's payloads at a time so a failure part way leaves
+		// the rest in order.
+		url := l.payloadURL(payloads[0])
+		n := 1
+
+		for n < len(payloads) && l.payloadURL(payloads[n]) == url {
+			n++
+		}
+
+		body := bytes.Join(payloads[:n], []byte("\n"))
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		start := time.Now()
+		err = l.post(ctx, url, body)
+		cancel()
+
+		l.stats.recordBatch(BatchInfo{Events: n, Bytes: len(body), Latency: time.Since(start), Attempt: 1}, err)
+
+		// Payloads the endpoint rejects are dropped, a store has nowhere to
+		// quarantine them.
+		if (err != nil && spoolable(err)) || store.drop(n) != nil {
+			return
+		}
+	}
+}
+
+// memoryStore is a payloadStore bounded to limit bytes, dropping the oldest
+// payloads when full. It backs the localStorage store and tests.
+type memoryStore struct {
+	sync.Mutex
+	payloads [][]byte
+	bytes    int
+	limit    int
+}
+
+func (s *memoryStore) save(payloads [][]byte) error {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, payload := range payloads {
+		s.payloads = append(s.payloads, payload)
+		s.bytes += len(payload)
+	}
+
+	for s.limit > 0 && s.bytes > s.limit && len(s.payloads) > 0 {
+		s.bytes -= len(s.payloads[0])
+		s.payloads = s.payloads[1:]
+	}
+
+	return nil
+}
+
+func (s *memoryStore) peek(n int) ([][]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if n > len(s.payloads) {
+		n = len(s.payloads)
+	}
+
+	return append([][]byte(nil), s.payloads[:n]...), nil
+}
+
+func (s *memoryStore) drop(n int) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if n > len(s.payloads) {
+		n = len(s.payloads)
+	}
+
+	for _, payload := range s.payloads[:n] {
+		s.bytes -= len(payload)
+	}
+
+	s.payloads = s.payloads[n:]
+
+	return nil
+}
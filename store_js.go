@@ -0,0 +1,113 @@
+//go:build js && wasm
+// +build js,wasm
+
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"syscall/js"
+)
+
+// SetLocalStorageSpool keeps messages that failed to ship in the browser's
+// localStorage under key, bounded to limit bytes, and ships them once Loggly
+// is reachable again. It is the js/wasm counterpart of SetSpool. An empty key
+// disables it.
+func SetLocalStorageSpool(key string, limit int) error {
+	if key == "" {
+		loggerSingleton.Lock()
+		loggerSingleton.store = nil
+		loggerSingleton.Unlock()
+
+		return nil
+	}
+
+	storage := js.Global().Get("localStorage")
+
+	if storage.IsUndefined() || storage.IsNull() {
+		return errors.New("localStorage is not available")
+	}
+
+	store := &localStorageStore{storage: storage, key: key, memory: memoryStore{limit: limit}}
+
+	if err := store.load(); err != nil {
+		return err
+	}
+
+	loggerSingleton.Lock()
+	loggerSingleton.store = store
+	loggerSingleton.Unlock()
+
+	return nil
+}
+
+// localStorageStore mirrors a memoryStore to a localStorage item, as a JSON
+// array of payloads.
+type localStorageStore struct {
+	storage js.Value
+	key     string
+	memory  memoryStore
+}
+
+func (s *localStorageStore) load() error {
+	item := s.storage.Call("getItem", s.key)
+
+	if item.IsNull() {
+		return nil
+	}
+
+	var payloads []string
+
+	if err := json.Unmarshal([]byte(item.String()), &payloads); err != nil {
+		// An unreadable item is replaced rather than blocking logging.
+		return nil
+	}
+
+	for _, payload := range payloads {
+		s.memory.save([][]byte{[]byte(payload)})
+	}
+
+	return nil
+}
+
+func (s *localStorageStore) persist() (err error) {
+	s.memory.Lock()
+	payloads := make([]string, len(s.memory.payloads))
+	for i, payload := range s.memory.payloads {
+		payloads[i] = string(payload)
+	}
+	s.memory.Unlock()
+
+	b, err := json.Marshal(payloads)
+
+	if err != nil {
+		return err
+	}
+
+	// setItem throws once the origin's quota is exhausted.
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("localStorage quota exceeded")
+		}
+	}()
+
+	s.storage.Call("setItem", s.key, string(b))
+
+	return nil
+}
+
+func (s *localStorageStore) save(payloads [][]byte) error {
+	s.memory.save(payloads)
+
+	return s.persist()
+}
+
+func (s *localStorageStore) peek(n int) ([][]byte, error) {
+	return s.memory.peek(n)
+}
+
+func (s *localStorageStore) drop(n int) error {
+	s.memory.drop(n)
+
+	return s.persist()
+}
@@ -0,0 +1,61 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMemoryStore(t *testing.T) {
+	s := &memoryStore{limit: 10}
+	s.save([][]byte{[]byte("aaaa"), []byte("bbbb"), []byte("cccc")})
+
+	// The oldest payload is dropped to stay within the limit.
+	payloads, _ := s.peek(5)
+
+	if len(payloads) != 2 || string(payloads[0]) != "bbbb" || s.bytes != 8 {
+		t.Fatalf("unexpected payloads %q", payloads)
+	}
+
+	s.drop(1)
+
+	if payloads, _ := s.peek(5); len(payloads) != 1 || string(payloads[0]) != "cccc" || s.bytes != 4 {
+		t.Errorf("unexpected payloads %q", payloads)
+	}
+}
+
+func TestPayloadStoreReplay(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	store := &memoryStore{}
+	l.store = store
+
+	healthy := l.url
+	l.url = "http://127.0.0.1:1"
+
+	ack := make(chan error, 1)
+	l.log(record{output: "This is stored.", level: LogLevelInfo, ack: ack})
+	l.flush()
+
+	if err := <-ack; !errors.Is(err, ErrSpooled) {
+		t.Fatalf("expected ErrSpooled, got %v", err)
+	}
+
+	if payloads, _ := store.peek(10); len(payloads) != 1 {
+		t.Fatalf("expected 1 stored payload, got %d", len(payloads))
+	}
+
+	l.url = healthy
+	l.buildAndShipMessage("This is shipped.", LogLevelInfo, false, nil)
+	l.flush()
+
+	receive(t, bodies)
+
+	if body := receive(t, bodies); !strings.Contains(body, "This is stored.") {
+		t.Errorf("expected the stored message to be replayed, got %q", body)
+	}
+
+	if payloads, _ := store.peek(10); len(payloads) != 0 {
+		t.Errorf("expected the store to be empty, got %d payloads", len(payloads))
+	}
+}
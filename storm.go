@@ -0,0 +1,109 @@
+package log
+
+import (
+	"time"
+)
+
+// ErrorStormConfig configures detection of bursts of Error level messages.
+type ErrorStormConfig struct {
+	// Threshold is the number of Error or Fatal messages within Window that
+	// constitutes a storm.
+	Threshold int
+	Window    time.Duration
+
+	// Callback, when set, is fired once when a storm starts with the number
+	// of errors seen in the window.
+	Callback func(count int)
+
+	// Emit ships a single Warn level "error storm" message when a storm
+	// starts.
+	Emit bool
+}
+
+type errorStorm struct {
+	config  ErrorStormConfig
+	counter *windowCounter
+	active  bool
+}
+
+// SetErrorStorm enables error storm detection so paging systems can be driven
+// by a single escalation rather than raw error volume.
+func SetErrorStorm(config ErrorStormConfig) {
+	loggerSingleton.Lock()
+	loggerSingleton.errorStorm = &errorStorm{config: config, counter: newWindowCounter(config.Window)}
+	loggerSingleton.Unlock()
+}
+
+// observeError records an Error level message and reports a storm once the
+// threshold is crossed. The storm ends once the rate drops back below it.
+func (l *logger) observeError() {
+	now := l.now()
+
+	l.Lock()
+
+	storm := l.errorStorm
+	if storm == nil {
+		l.Unlock()
+		return
+	}
+
+	count := storm.counter.add(now)
+
+	if count < storm.config.Threshold {
+		storm.active = false
+		l.Unlock()
+		return
+	}
+
+	if storm.active {
+		l.Unlock()
+		return
+	}
+
+	storm.active = true
+	config := storm.config
+
+	l.Unlock()
+
+	if config.Callback != nil {
+		config.Callback(count)
+	}
+
+	if config.Emit {
+		l.buildAndShipMessage("error storm detected", LogLevelWarn, false, map[string]interface{}{
+			"errors":         count,
+			"window_seconds": config.Window.Seconds(),
+		})
+	}
+}
+
+// windowCounter counts events within a sliding time window.
+type windowCounter struct {
+	window time.Duration
+	times  []time.Time
+}
+
+func newWindowCounter(window time.Duration) *windowCounter {
+	return &windowCounter{window: window}
+}
+
+// add records an event at now and returns the number of events in the window.
+func (c *windowCounter) add(now time.Time) int {
+	c.times = append(c.times, now)
+
+	return c.count(now)
+}
+
+// count drops events older than the window and returns how many remain.
+func (c *windowCounter) count(now time.Time) int {
+	cutoff := now.Add(-c.window)
+
+	i := 0
+	for i < len(c.times) && !c.times[i].After(cutoff) {
+		i++
+	}
+
+	c.times = c.times[i:]
+
+	return len(c.times)
+}
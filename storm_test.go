@@ -0,0 +1,63 @@
+package log
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWindowCounter(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newWindowCounter(10 * time.Second)
+
+	c.add(start)
+	c.add(start.Add(5 * time.Second))
+
+	if n := c.add(start.Add(12 * time.Second)); n != 2 {
+		t.Errorf("expected 2 events in the window, got %d", n)
+	}
+}
+
+func TestErrorStorm(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.clock = clock
+
+	var storms []int
+	l.errorStorm = &errorStorm{
+		config: ErrorStormConfig{
+			Threshold: 3,
+			Window:    time.Minute,
+			Callback:  func(count int) { storms = append(storms, count) },
+			Emit:      true,
+		},
+		counter: newWindowCounter(time.Minute),
+	}
+
+	for i := 0; i < 5; i++ {
+		l.buildAndShipMessage("This is an error.", LogLevelError, false, nil)
+		clock.Advance(time.Second)
+	}
+
+	if len(storms) != 1 || storms[0] != 3 {
+		t.Fatalf("expected a single storm report at 3 errors, got %v", storms)
+	}
+
+	l.flush()
+
+	if body := receive(t, bodies); strings.Count(body, "error storm detected") != 1 {
+		t.Errorf("expected one synthesized storm message, got %q", body)
+	}
+
+	// Once the window has passed the next burst is a new storm.
+	clock.Advance(2 * time.Minute)
+
+	for i := 0; i < 3; i++ {
+		l.buildAndShipMessage("This is an error.", LogLevelError, false, nil)
+	}
+
+	if len(storms) != 2 {
+		t.Errorf("expected a second storm report, got %v", storms)
+	}
+}
@@ -0,0 +1,44 @@
+package log
+
+import (
+	"time"
+)
+
+// panicShipTimeout bounds how long an Error waits to be shipped before the
+// strict mode panic.
+const panicShipTimeout = 5 * time.Second
+
+// SetPanicOnError enables strict mode, where every Error level log panics
+// once it has been printed and shipped. It is meant for tests and
+// development to catch swallowed errors early. Wrap the data of individual
+// calls with NoPanic to opt them out.
+func SetPanicOnError(enabled bool) {
+	loggerSingleton.Lock()
+	loggerSingleton.panicOnError = enabled
+	loggerSingleton.Unlock()
+}
+
+type noPanic struct {
+	data interface{}
+}
+
+// NoPanic wraps the data of an Error call so it doesn't panic in strict mode,
+// e.g. Errord("expected failure", NoPanic(d)).
+func NoPanic(d interface{}) interface{} {
+	return noPanic{data: d}
+}
+
+func unwrapNoPanic(d interface{}) (interface{}, bool) {
+	if wrapped, ok := d.(noPanic); ok {
+		return wrapped.data, true
+	}
+
+	return d, false
+}
+
+func (l *logger) isPanicOnError() bool {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.panicOnError
+}
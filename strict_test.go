@@ -0,0 +1,45 @@
+package log
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPanicOnError(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+	l.synchronous = false
+	l.panicOnError = true
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected strict mode to panic")
+			}
+		}()
+
+		l.buildAndShipMessage("This is a swallowed error.", LogLevelError, false, nil)
+	}()
+
+	// The error is shipped before the panic.
+	select {
+	case body := <-bodies:
+		if !strings.Contains(body, "This is a swallowed error.") {
+			t.Errorf("unexpected body %q", body)
+		}
+	default:
+		t.Error("expected the error to be shipped before panicking")
+	}
+}
+
+func TestNoPanicOverride(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+	l.panicOnError = true
+
+	l.buildAndShipMessage("This is an expected error.", LogLevelError, false, NoPanic(map[string]string{"case": "override"}))
+	l.buildAndShipMessage("This is a warning.", LogLevelWarn, false, nil)
+	l.flush()
+
+	if body := receive(t, bodies); !strings.Contains(body, `"metadata":{"case":"override"}`) {
+		t.Errorf("expected NoPanic to be unwrapped, got %q", body)
+	}
+}
@@ -0,0 +1,78 @@
+package log
+
+// subscriberBuffer is how many events a subscription holds for a reader that
+// falls behind.
+const subscriberBuffer = 256
+
+type subscriber struct {
+	level Level
+	ch    chan Event
+}
+
+// Subscribe returns a channel receiving every event at or above level as it
+// is logged, for applications rendering a live tail of their own logs. The
+// channel is buffered, events are dropped rather than holding up logging when
+// the reader falls behind. Unsubscribe stops the subscription, and Shutdown
+// stops them all, closing their channels.
+func Subscribe(level Level) <-chan Event {
+	return loggerSingleton.subscribe(level)
+}
+
+// Unsubscribe stops a subscription returned by Subscribe and closes its
+// channel.
+func Unsubscribe(ch <-chan Event) {
+	loggerSingleton.unsubscribe(ch)
+}
+
+func (l *logger) subscribe(level Level) <-chan Event {
+	ch := make(chan Event, subscriberBuffer)
+
+	l.Lock()
+	l.subscribers = append(l.subscribers, subscriber{level: level, ch: ch})
+	l.Unlock()
+
+	return ch
+}
+
+func (l *logger) unsubscribe(ch <-chan Event) {
+	l.Lock()
+	defer l.Unlock()
+
+	for i, s := range l.subscribers {
+		if s.ch == ch {
+			close(s.ch)
+			l.subscribers = append(l.subscribers[:i:i], l.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// publish hands e to every subscriber whose level it meets, dropping it for
+// those whose channel is full.
+func (l *logger) publish(e Event) {
+	l.Lock()
+	defer l.Unlock()
+
+	for _, s := range l.subscribers {
+		if !e.Level.AtLeast(s.level) {
+			continue
+		}
+
+		select {
+		case s.ch <- e:
+		default:
+		}
+	}
+}
+
+// closeSubscribers ends every subscription.
+func (l *logger) closeSubscribers() {
+	l.Lock()
+	defer l.Unlock()
+
+	for _, s := range l.subscribers {
+		close(s.ch)
+	}
+
+	l.subscribers = nil
+}
@@ -0,0 +1,51 @@
+package log
+
+import (
+	"testing"
+)
+
+func TestSubscribe(t *testing.T) {
+	l, _ := newTestLogger(t, false)
+
+	warnings := l.subscribe(LogLevelWarn)
+	everything := l.subscribe(LogLevelTrace)
+
+	l.log(record{output: "This is informational.", level: LogLevelInfo})
+	l.log(record{output: "This is a warning.", level: LogLevelWarn})
+
+	if e := <-warnings; e.Message != "This is a warning." || e.Level != LogLevelWarn {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	if len(everything) != 2 {
+		t.Errorf("expected 2 events, got %d", len(everything))
+	}
+
+	l.unsubscribe(warnings)
+	l.log(record{output: "This is another warning.", level: LogLevelWarn})
+
+	if _, ok := <-warnings; ok {
+		t.Error("expected the channel to be closed")
+	}
+
+	l.shutdown("test")
+
+	for range everything {
+	}
+}
+
+func TestSubscribeDropsWhenFull(t *testing.T) {
+	l, _ := newTestLogger(t, false)
+	l.console = []ConsoleWriter{}
+	l.shippingDisabled = true
+
+	events := l.subscribe(LogLevelTrace)
+
+	for i := 0; i < subscriberBuffer+10; i++ {
+		l.log(record{output: "This fills the channel.", level: LogLevelInfo})
+	}
+
+	if len(events) != subscriberBuffer {
+		t.Errorf("expected %d buffered events, got %d", subscriberBuffer, len(events))
+	}
+}
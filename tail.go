@@ -0,0 +1,135 @@
+package log
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// tailKeepalive is how often an idle stream sends a comment, keeping proxies
+// from closing it.
+const tailKeepalive = 15 * time.Second
+
+// TailHandler returns an http.Handler streaming this instance's events as
+// Server-Sent Events, so operators can tail it without waiting on Loggly's
+// search. Each event is sent as a "data:" line of JSON, starting with the
+// events held by SetEventBuffer and followed by live ones.
+//
+// Requests must carry token, as a bearer token or in the token query
+// parameter for EventSource clients that can't set headers, and may filter
+// with the level query parameter, e.g. ?level=warn. The handler refuses
+// every request if token is empty. It streams from the package level logger
+// of the moment, so it may be mounted before SetupLogger.
+func TailHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		loggerSingleton.tailHandler(token).ServeHTTP(w, r)
+	})
+}
+
+func (l *logger) tailHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !tailAuthorized(r, token) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		level := LogLevelTrace
+
+		if name := r.URL.Query().Get("level"); name != "" {
+			var err error
+
+			if level, err = ParseLevel(name); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		flusher, ok := w.(http.Flusher)
+
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		// Subscribe before sending the recent events so none fall between.
+		events := l.subscribe(level)
+		defer l.unsubscribe(events)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+
+		l.Lock()
+		recent := l.recent
+		l.Unlock()
+
+		if recent != nil {
+			for _, e := range recent.snapshot() {
+				if e.Level.AtLeast(level) && writeTailEvent(w, e) != nil {
+					return
+				}
+			}
+		}
+
+		flusher.Flush()
+
+		keepalive := time.NewTicker(tailKeepalive)
+		defer keepalive.Stop()
+
+		for {
+			select {
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+
+				if writeTailEvent(w, e) != nil {
+					return
+				}
+			case <-keepalive.C:
+				if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+
+			flusher.Flush()
+		}
+	})
+}
+
+// tailAuthorized reports whether r carries token.
+func tailAuthorized(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	given := r.URL.Query().Get("token")
+
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		given = strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+func writeTailEvent(w http.ResponseWriter, e Event) error {
+	b, err := json.Marshal(e)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "data: %s\n\n", b)
+
+	return err
+}
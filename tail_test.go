@@ -0,0 +1,120 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTailHandler(t *testing.T) {
+	l, _ := newTestLogger(t, false)
+	l.recent = newEventRing(10)
+
+	l.log(record{output: "This is recent.", level: LogLevelWarn})
+	l.log(record{output: "This is below the tail's level.", level: LogLevelInfo})
+
+	server := httptest.NewServer(l.tailHandler("secret"))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"?level=warn", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+
+	next := func() string {
+		for lines.Scan() {
+			if line := lines.Text(); strings.HasPrefix(line, "data: ") {
+				return line
+			}
+		}
+
+		t.Fatal("stream ended")
+		return ""
+	}
+
+	if line := next(); !strings.Contains(line, "This is recent.") {
+		t.Errorf("unexpected event %q", line)
+	}
+
+	l.log(record{output: "This is live.", level: LogLevelError})
+
+	if line := next(); !strings.Contains(line, "This is live.") {
+		t.Errorf("unexpected event %q", line)
+	}
+}
+
+func TestTailHandlerFollowsSetup(t *testing.T) {
+	previous := loggerSingleton
+	t.Cleanup(func() { loggerSingleton = previous })
+
+	server := httptest.NewServer(TailHandler("secret"))
+	defer server.Close()
+
+	l, _ := newTestLogger(t, false)
+	l.recent = newEventRing(10)
+	loggerSingleton = l
+
+	l.log(record{output: "This is logged after setup.", level: LogLevelInfo})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"?token=secret", nil)
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	lines := bufio.NewScanner(resp.Body)
+
+	for lines.Scan() {
+		if line := lines.Text(); strings.HasPrefix(line, "data: ") {
+			if !strings.Contains(line, "This is logged after setup.") {
+				t.Errorf("unexpected event %q", line)
+			}
+
+			return
+		}
+	}
+
+	t.Fatal("stream ended")
+}
+
+func TestTailHandlerForbidden(t *testing.T) {
+	l, _ := newTestLogger(t, false)
+
+	for _, target := range []string{"/", "/?token=wrong"} {
+		w := httptest.NewRecorder()
+		l.tailHandler("secret").ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected 403 for %s, got %d", target, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	l.tailHandler("").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?token=", nil))
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected an empty token to refuse requests, got %d", w.Code)
+	}
+}
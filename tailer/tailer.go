@@ -0,0 +1,292 @@
+// Package tailer follows the log files of programs that can't be changed to
+// log directly and ships their lines to Loggly as they are written, making a
+// lightweight agent such as the loggly-agent command:
+//
+//	t, err := tailer.New(loggly.New(token, loggly.WithBulk(0, 0)), tailer.Config{
+//		Paths:  []string{"/var/log/nginx/access.log"},
+//		Parser: loggly.ParseCommonLog,
+//	})
+//
+//	err = t.Run(ctx)
+package tailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	loggly "github.com/morlockaerospace/loggly"
+)
+
+// maxLine bounds the length of a line, longer ones are shipped in pieces.
+const maxLine = 1024 * 1024
+
+// flushTimeout bounds shipping the last lines when Run returns.
+const flushTimeout = 10 * time.Second
+
+// Config configures a Tailer.
+type Config struct {
+	// Paths are the files to follow. They need not exist yet, and are
+	// reopened when rotated by renaming or truncated.
+	Paths []string
+
+	// Parser parses each line into an event, loggly.ParsePlainLines if nil.
+	// Lines it fails on are shipped as they are, with the error in the
+	// "parse_error" field.
+	Parser loggly.LineParser
+
+	// FromStart ships the lines files hold when Run starts, which are skipped
+	// otherwise. Files created or rotated in later are always read from the
+	// start.
+	FromStart bool
+
+	// PollInterval is how often files are checked besides the file system
+	// notifications, catching changes on file systems without them, 1 second
+	// if zero.
+	PollInterval time.Duration
+
+	// Fields are added to every event, along with "file" holding the path of
+	// the file the line was read from.
+	Fields map[string]interface{}
+}
+
+// Tailer ships the lines appended to a set of files.
+type Tailer struct {
+	logger *loggly.Logger
+	config Config
+	files  []*file
+}
+
+// file is the state of a followed path.
+type file struct {
+	path string
+
+	// f is the open file, nil while path doesn't exist.
+	f    *os.File
+	info os.FileInfo
+
+	// partial holds the end of the file after its last newline.
+	partial []byte
+}
+
+// New creates a tailer shipping through logger, or the default Logger if it
+// is nil.
+func New(logger *loggly.Logger, config Config) (*Tailer, error) {
+	if len(config.Paths) == 0 {
+		return nil, errors.New("no files to tail")
+	}
+
+	if logger == nil {
+		logger = loggly.Default()
+	}
+
+	if config.Parser == nil {
+		config.Parser = loggly.ParsePlainLines
+	}
+
+	if config.PollInterval == 0 {
+		config.PollInterval = time.Second
+	}
+
+	t := &Tailer{logger: logger, config: config}
+
+	for _, path := range config.Paths {
+		// Notifications name files by their absolute path.
+		path, err := filepath.Abs(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		t.files = append(t.files, &file{path: path})
+	}
+
+	return t, nil
+}
+
+// Run follows the files until ctx is done, then ships the lines read.
+func (t *Tailer) Run(ctx context.Context) error {
+	changes, stop := t.watch()
+	defer stop()
+
+	for _, f := range t.files {
+		t.open(f, t.config.FromStart)
+		t.read(f)
+	}
+
+	defer t.closeFiles()
+
+	ticker := time.NewTicker(t.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			defer cancel()
+
+			return t.logger.Flush(flushCtx)
+		case name := <-changes:
+			for _, f := range t.files {
+				if f.path == filepath.Clean(name) {
+					t.check(f)
+				}
+			}
+		case <-ticker.C:
+			for _, f := range t.files {
+				t.check(f)
+			}
+		}
+	}
+}
+
+// check reopens the file if it was rotated or truncated, and ships the lines
+// appended to it.
+func (t *Tailer) check(f *file) {
+	info, err := os.Stat(f.path)
+
+	// Lines may still be written to a removed file until it is replaced.
+	if err != nil {
+		if f.f != nil {
+			t.read(f)
+		}
+
+		return
+	}
+
+	switch {
+	case f.f == nil:
+		t.open(f, true)
+	case !os.SameFile(info, f.info):
+		t.read(f)
+		t.flushPartial(f)
+		f.f.Close()
+		t.open(f, true)
+	default:
+		offset, err := f.f.Seek(0, io.SeekCurrent)
+
+		if err == nil && info.Size() < offset {
+			f.f.Seek(0, io.SeekStart)
+			f.partial = nil
+		}
+	}
+
+	t.read(f)
+}
+
+// open opens the file at its start, or its end unless fromStart is set.
+func (t *Tailer) open(f *file, fromStart bool) {
+	handle, err := os.Open(f.path)
+
+	if err != nil {
+		f.f = nil
+		return
+	}
+
+	info, err := handle.Stat()
+
+	if err == nil && !fromStart {
+		_, err = handle.Seek(0, io.SeekEnd)
+	}
+
+	if err != nil {
+		handle.Close()
+		f.f = nil
+		return
+	}
+
+	f.f, f.info, f.partial = handle, info, nil
+}
+
+// read ships the complete lines from the file's offset to its end.
+func (t *Tailer) read(f *file) {
+	if f.f == nil {
+		return
+	}
+
+	buf := make([]byte, 64*1024)
+
+	for {
+		n, err := f.f.Read(buf)
+
+		f.partial = append(f.partial, buf[:n]...)
+
+		for {
+			i := bytes.IndexByte(f.partial, '\n')
+
+			if i < 0 {
+				break
+			}
+
+			t.ship(f, string(f.partial[:i]))
+			f.partial = f.partial[i+1:]
+		}
+
+		if len(f.partial) >= maxLine {
+			t.flushPartial(f)
+		}
+
+		if n == 0 || err != nil {
+			break
+		}
+	}
+
+	f.partial = append([]byte(nil), f.partial...)
+}
+
+// flushPartial ships the end of the file after its last newline, left when
+// a file is rotated before the line is finished.
+func (t *Tailer) flushPartial(f *file) {
+	if len(f.partial) > 0 {
+		t.ship(f, string(f.partial))
+		f.partial = nil
+	}
+}
+
+func (t *Tailer) ship(f *file, line string) {
+	line = strings.TrimRight(line, "\r")
+
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+
+	event, err := t.config.Parser(line)
+
+	if err == loggly.ErrSkipLine {
+		return
+	}
+
+	fields := make(map[string]interface{}, len(t.config.Fields)+len(event.Fields)+2)
+
+	for key, value := range t.config.Fields {
+		fields[key] = value
+	}
+
+	if err != nil {
+		event = loggly.Event{Level: loggly.LogLevelInfo, Message: line}
+		fields["parse_error"] = err.Error()
+	}
+
+	for key, value := range event.Fields {
+		fields[key] = value
+	}
+
+	fields["file"] = f.path
+	event.Fields = fields
+
+	t.logger.Ingest(event)
+}
+
+func (t *Tailer) closeFiles() {
+	for _, f := range t.files {
+		if f.f != nil {
+			f.f.Close()
+			f.f = nil
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package tailer
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	loggly "github.com/morlockaerospace/loggly"
+)
+
+func appendFile(t *testing.T, path string, text string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Close()
+}
+
+func TestTailer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailer")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	receive := func(expected string) {
+		t.Helper()
+
+		select {
+		case body := <-bodies:
+			if !strings.Contains(body, `"message":"`+expected+`"`) || !strings.Contains(body, `"host":"web-1"`) || !strings.Contains(body, `app.log"`) {
+				t.Errorf("expected %q with the fields, got %q", expected, body)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected %q shipped", expected)
+		}
+	}
+
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "written before the tailer started\n")
+
+	lg := loggly.New("", loggly.WithEndpoint(server.URL), loggly.WithSynchronous())
+	defer lg.Close()
+
+	tailer, err := New(lg, Config{Paths: []string{path}, PollInterval: 10 * time.Millisecond, Fields: map[string]interface{}{"host": "web-1"}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() {
+		done <- tailer.Run(ctx)
+	}()
+
+	// Lines appended in pieces ship once complete.
+	time.Sleep(50 * time.Millisecond)
+	appendFile(t, path, "first ")
+	time.Sleep(50 * time.Millisecond)
+	appendFile(t, path, "line\n")
+	receive("first line")
+
+	// Lines written just before a rotation ship from the renamed file, then
+	// the new file is read from its start.
+	appendFile(t, path, "before rotation\n")
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+
+	appendFile(t, path, "after rotation\n")
+	receive("before rotation")
+	receive("after rotation")
+
+	// A truncated file is read again from its start.
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	appendFile(t, path, "trunc\n")
+	receive("trunc")
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected event %q", body)
+	default:
+	}
+}
+
+func TestNew(t *testing.T) {
+	tailer, err := New(nil, Config{Paths: []string{"app.log"}, Parser: loggly.ParseJSONLines})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !filepath.IsAbs(tailer.files[0].path) {
+		t.Errorf("expected an absolute path, got %q", tailer.files[0].path)
+	}
+
+	if _, err := New(nil, Config{}); err == nil {
+		t.Error("expected an error without paths")
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !js
+// +build !js
+
+package tailer
+
+import (
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watch reports the paths changed in the directories of the files until stop
+// is called. Without file system notifications it returns a nil channel,
+// leaving polling to pick up changes alone.
+func (t *Tailer) watch() (changes <-chan string, stop func()) {
+	watcher, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return nil, func() {}
+	}
+
+	watched := map[string]bool{}
+
+	for _, f := range t.files {
+		// Watching the directory sees files created and renamed.
+		if dir := filepath.Dir(f.path); !watched[dir] {
+			watched[dir] = watcher.Add(dir) == nil
+		}
+	}
+
+	names := make(chan string)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				select {
+				case names <- event.Name:
+				case <-done:
+					return
+				}
+			case _, ok := <-watcher.Errors:
+				// Polling catches up on notifications lost to overflows.
+				if !ok {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return names, func() {
+		close(done)
+		watcher.Close()
+	}
+}
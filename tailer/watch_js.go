@@ -0,0 +1,10 @@
+//go:build js
+// +build js
+
+package tailer
+
+// watch returns a nil channel, js/wasm has no file system notifications so
+// polling picks up changes alone.
+func (t *Tailer) watch() (changes <-chan string, stop func()) {
+	return nil, func() {}
+}
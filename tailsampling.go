@@ -0,0 +1,196 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultTailMaxEvents bounds how many events a request holds.
+const defaultTailMaxEvents = 1000
+
+// TailSampling configures tail based retention. Events logged through a
+// RequestLog are held until the request ends, then all shipped if it failed
+// or was slow, and otherwise only for a sample of requests. Failures keep
+// their full context while the bulk of uneventful requests cost nothing.
+type TailSampling struct {
+	// Rate is the fraction of successful, fast requests whose events ship.
+	// The decision is keyed by the request ID.
+	Rate float64
+
+	// Latency, if set, keeps every request taking at least this long.
+	Latency time.Duration
+
+	// MaxEvents bounds the events held per request, 1000 if zero. A request
+	// logging more ships them all rather than holding more.
+	MaxEvents int
+}
+
+// SetTailSampling enables tail based retention for requests logged through
+// a RequestLog, including those served by Middleware. Pass nil to ship
+// their events as they are logged again.
+func SetTailSampling(config *TailSampling) {
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	if config == nil {
+		loggerSingleton.tail = nil
+		return
+	}
+
+	tail := *config
+	if tail.MaxEvents <= 0 {
+		tail.MaxEvents = defaultTailMaxEvents
+	}
+
+	loggerSingleton.tail = &tail
+}
+
+// RequestLog logs the events of a single request, stamping each with its
+// request ID and holding them under tail sampling until End. An Error or
+// Fatal event ships what was held and everything after it straight away.
+type RequestLog struct {
+	*Entry
+
+	id    string
+	start time.Time
+
+	mu      sync.Mutex
+	records []record
+	passing bool
+	once    sync.Once
+}
+
+// StartRequest starts the log of the request with id, a new ID if empty.
+func StartRequest(id string) *RequestLog {
+	return loggerSingleton.startRequest(id)
+}
+
+func (l *logger) startRequest(id string) *RequestLog {
+	if id == "" {
+		id = newSessionID()
+	}
+
+	r := &RequestLog{id: id, start: l.now()}
+	r.Entry = &Entry{logger: l, fields: map[string]interface{}{"request_id": id}, request: r}
+
+	return r
+}
+
+// ID returns the request's ID.
+func (r *RequestLog) ID() string {
+	return r.id
+}
+
+type requestLogKey struct{}
+
+// WithRequestLog returns a context carrying r, which Ctx entries log through.
+func WithRequestLog(ctx context.Context, r *RequestLog) context.Context {
+	return context.WithValue(ctx, requestLogKey{}, r)
+}
+
+// RequestLogFrom returns the RequestLog carried by ctx, or nil.
+func RequestLogFrom(ctx context.Context) *RequestLog {
+	if ctx == nil {
+		return nil
+	}
+
+	r, _ := ctx.Value(requestLogKey{}).(*RequestLog)
+
+	return r
+}
+
+// End decides, once, whether the held events ship: they do if err is not
+// nil, the request was slow or it is sampled. Events logged afterwards ship
+// as they are logged.
+func (r *RequestLog) End(err error) {
+	r.once.Do(func() {
+		l := r.Entry.logger
+
+		l.Lock()
+		tail := l.tail
+		l.Unlock()
+
+		r.mu.Lock()
+		records := r.records
+		r.records = nil
+		r.passing = true
+		r.mu.Unlock()
+
+		if len(records) == 0 {
+			return
+		}
+
+		keep := tail == nil || err != nil || (tail.Latency > 0 && l.now().Sub(r.start) >= tail.Latency) || sampleFraction(r.id) < tail.Rate
+
+		if keep {
+			l.replayHeld(records)
+			return
+		}
+
+		for _, held := range records {
+			l.stats.recordFiltered()
+
+			if held.ack != nil {
+				held.ack <- ErrFiltered
+				close(held.ack)
+			}
+		}
+	})
+}
+
+// hold keeps a record for the request's decision, printing it now, and
+// reports whether it did.
+func (r *RequestLog) hold(l *logger, rec record) bool {
+	l.Lock()
+	tail := l.tail
+	level := l.Level
+	now := l.clock.Now()
+	l.Unlock()
+
+	// Events below the level go straight to the flight recorder.
+	if tail == nil || rec.exit || !rec.level.AtLeast(level) {
+		return false
+	}
+
+	r.mu.Lock()
+
+	if r.passing {
+		r.mu.Unlock()
+		return false
+	}
+
+	if rec.level.AtLeast(LogLevelError) || len(r.records) >= tail.MaxEvents {
+		records := r.records
+		r.records = nil
+		r.passing = true
+		r.mu.Unlock()
+
+		l.replayHeld(records)
+
+		return false
+	}
+
+	if rec.time.IsZero() {
+		rec.time = now
+	}
+
+	held := rec
+	held.printed = true
+	r.records = append(r.records, held)
+	r.mu.Unlock()
+
+	if !rec.printed {
+		d, _ := unwrapNoPanic(rec.data)
+		l.printConsole(Event{Time: rec.time, Level: rec.level, Message: rec.output, Metadata: d, Fields: rec.fields})
+	}
+
+	return true
+}
+
+// replayHeld logs records held by a request.
+func (l *logger) replayHeld(records []record) {
+	for _, r := range records {
+		l.log(r)
+	}
+}
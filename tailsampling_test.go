@@ -0,0 +1,91 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTailSampling(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.clock = clock
+	l.tail = &TailSampling{Latency: time.Second, MaxEvents: defaultTailMaxEvents}
+
+	ok := l.startRequest("ok")
+	ok.Infoln("handling ok")
+	ok.End(nil)
+
+	failed := l.startRequest("failed")
+	failed.Infoln("handling failed")
+	failed.End(errors.New("upstream unavailable"))
+
+	slow := l.startRequest("slow")
+	slow.Infoln("handling slow")
+	clock.Advance(2 * time.Second)
+	slow.End(nil)
+
+	erroring := l.startRequest("erroring")
+	erroring.Infoln("handling erroring")
+	erroring.Errorln("lookup failed")
+
+	var shipped []string
+	for i := 0; i < 4; i++ {
+		shipped = append(shipped, receive(t, bodies))
+	}
+
+	expected := []string{"handling failed", "handling slow", "handling erroring", "lookup failed"}
+
+	for i, body := range shipped {
+		if !strings.Contains(body, expected[i]) || strings.Contains(body, "handling ok") {
+			t.Errorf("expected %q, got %q", expected[i], body)
+		}
+	}
+
+	if !strings.Contains(shipped[0], `"request_id":"failed"`) {
+		t.Errorf("expected the request ID, got %q", shipped[0])
+	}
+
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected body %q", body)
+	default:
+	}
+}
+
+func TestTailSamplingMiddleware(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+	l.tail = &TailSampling{MaxEvents: defaultTailMaxEvents}
+
+	handler := l.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Ctx(r.Context()).Infoln("handling " + r.URL.Path)
+
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+
+	for _, path := range []string{"/ok", "/fail"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Request-Id", "req"+strings.Replace(path, "/", "-", 1))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if body := receive(t, bodies); !strings.Contains(body, "handling /fail") || !strings.Contains(body, `"request_id":"req-fail"`) {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected body %q", body)
+	default:
+	}
+
+	if RequestLogFrom(context.Background()) != nil {
+		t.Error("expected no request log without one in the context")
+	}
+}
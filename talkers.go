@@ -0,0 +1,192 @@
+package log
+
+import (
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TopTalkersConfig configures the top talkers report.
+type TopTalkersConfig struct {
+	// N is how many templates the report lists, 10 when zero.
+	N int
+
+	// Period is how often the report is computed, an hour when zero.
+	Period time.Duration
+
+	// Emit ships an Info level "loggly.top_talkers" event with the report at
+	// the end of each period.
+	Emit bool
+}
+
+// Talker is a message template and how much of the period's volume it
+// produced.
+type Talker struct {
+	Template string
+	Level    Level
+	Events   uint64
+	Bytes    uint64
+
+	// Share is the template's fraction of the period's events, a noise
+	// score for prioritising cleanup.
+	Share float64
+}
+
+// maxTemplates bounds how many distinct templates a period tracks, the rest
+// are counted under otherTemplate.
+const maxTemplates = 10000
+
+const otherTemplate = "(other)"
+
+var (
+	quotedPattern = regexp.MustCompile(`"[^"]*"|'[^']*'`)
+	numberPattern = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]{8}(-[0-9a-fA-F]{4}){3}-[0-9a-fA-F]{12}\b|\d+(\.\d+)?`)
+)
+
+// messageTemplate reduces a message to its template by masking quoted
+// strings, numbers, hex values and UUIDs, so differently parameterised
+// occurrences of a log statement are counted together.
+func messageTemplate(output string) string {
+	output = quotedPattern.ReplaceAllString(output, `"*"`)
+
+	return numberPattern.ReplaceAllString(output, "#")
+}
+
+type talkerKey struct {
+	template string
+	level    Level
+}
+
+type topTalkers struct {
+	sync.Mutex
+	config TopTalkersConfig
+	start  time.Time
+	counts map[talkerKey]*Talker
+	total  uint64
+	last   []Talker
+}
+
+// SetTopTalkers enables counting of events by message template and the
+// periodic top talkers report.
+func SetTopTalkers(config TopTalkersConfig) {
+	if config.N <= 0 {
+		config.N = 10
+	}
+
+	if config.Period <= 0 {
+		config.Period = time.Hour
+	}
+
+	loggerSingleton.Lock()
+	loggerSingleton.talkers = &topTalkers{config: config, start: loggerSingleton.clock.Now(), counts: map[talkerKey]*Talker{}}
+	loggerSingleton.Unlock()
+}
+
+// TopTalkers returns the most recent completed report or, before the first
+// period ends, the current period's top templates so far.
+func TopTalkers() []Talker {
+	return loggerSingleton.topTalkers()
+}
+
+func (l *logger) topTalkers() []Talker {
+	l.Lock()
+	talkers := l.talkers
+	l.Unlock()
+
+	if talkers == nil {
+		return nil
+	}
+
+	talkers.Lock()
+	defer talkers.Unlock()
+
+	if talkers.last != nil {
+		return append([]Talker(nil), talkers.last...)
+	}
+
+	return talkers.top()
+}
+
+// countTalker counts an event against its template, shipping the report when
+// the period has ended.
+func (l *logger) countTalker(output string, level Level) {
+	l.Lock()
+	talkers := l.talkers
+	l.Unlock()
+
+	if talkers == nil {
+		return
+	}
+
+	now := l.now()
+	key := talkerKey{template: messageTemplate(output), level: level}
+
+	talkers.Lock()
+
+	var report []Talker
+
+	if now.Sub(talkers.start) >= talkers.config.Period {
+		report = talkers.top()
+		talkers.last = report
+		talkers.start = now
+		talkers.counts = map[talkerKey]*Talker{}
+		talkers.total = 0
+	}
+
+	talker, ok := talkers.counts[key]
+
+	if !ok {
+		if len(talkers.counts) >= maxTemplates {
+			key = talkerKey{template: otherTemplate, level: level}
+			talker, ok = talkers.counts[key]
+		}
+
+		if !ok {
+			talker = &Talker{Template: key.template, Level: level}
+			talkers.counts[key] = talker
+		}
+	}
+
+	talker.Events++
+	talker.Bytes += uint64(len(output))
+	talkers.total++
+
+	emit := talkers.config.Emit
+	period := talkers.config.Period
+
+	talkers.Unlock()
+
+	if emit && len(report) > 0 {
+		l.buildAndShipMessage("loggly.top_talkers", LogLevelInfo, false, map[string]interface{}{
+			"period_seconds": period.Seconds(),
+			"talkers":        report,
+		})
+	}
+}
+
+// top returns the N templates with the most events. It is called with the
+// lock held.
+func (t *topTalkers) top() []Talker {
+	talkers := make([]Talker, 0, len(t.counts))
+
+	for _, talker := range t.counts {
+		entry := *talker
+		entry.Share = float64(entry.Events) / float64(t.total)
+		talkers = append(talkers, entry)
+	}
+
+	sort.Slice(talkers, func(i, j int) bool {
+		if talkers[i].Events != talkers[j].Events {
+			return talkers[i].Events > talkers[j].Events
+		}
+
+		return talkers[i].Template < talkers[j].Template
+	})
+
+	if len(talkers) > t.config.N {
+		talkers = talkers[:t.config.N]
+	}
+
+	return talkers
+}
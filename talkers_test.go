@@ -0,0 +1,72 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMessageTemplate(t *testing.T) {
+	cases := map[string]string{
+		"request 42 took 1.5ms":                             "request # took #ms",
+		`user "alice" logged in`:                            `user "*" logged in`,
+		"frame 0x1f3a dropped":                              "frame # dropped",
+		"job 3f2504e0-4f89-11d3-9a0c-0305e82c3301 finished": "job # finished",
+		"no parameters here":                                "no parameters here",
+	}
+
+	for output, want := range cases {
+		if got := messageTemplate(output); got != want {
+			t.Errorf("messageTemplate(%q) = %q, want %q", output, got, want)
+		}
+	}
+}
+
+func TestTopTalkers(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.clock = clock
+	l.talkers = &topTalkers{config: TopTalkersConfig{N: 1, Period: time.Minute, Emit: true}, start: clock.Now(), counts: map[talkerKey]*Talker{}}
+
+	for i := 0; i < 3; i++ {
+		l.buildAndShipMessage("request "+string(rune('0'+i))+" served", LogLevelInfo, false, nil)
+		receive(t, bodies)
+	}
+
+	l.buildAndShipMessage("cache miss", LogLevelDebug, false, nil)
+	receive(t, bodies)
+
+	if top := l.topTalkers(); len(top) != 1 || top[0].Template != "request # served" || top[0].Events != 3 || top[0].Share != 0.75 {
+		t.Fatalf("unexpected top talkers %+v", top)
+	}
+
+	clock.Advance(time.Minute)
+	l.buildAndShipMessage("cache miss", LogLevelDebug, false, nil)
+
+	// The report ships ahead of the event that ended the period.
+	if report := receive(t, bodies); !strings.Contains(report, `"message":"loggly.top_talkers"`) || !strings.Contains(report, `"Template":"request # served"`) {
+		t.Errorf("unexpected report %q", report)
+	}
+
+	receive(t, bodies)
+
+	// The API keeps returning the completed report during the next period.
+	server := httptest.NewServer(l.adminHandler())
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/top-talkers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var top []Talker
+	json.NewDecoder(resp.Body).Decode(&top)
+
+	if len(top) != 1 || top[0].Events != 3 {
+		t.Errorf("unexpected top talkers %+v", top)
+	}
+}
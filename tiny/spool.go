@@ -0,0 +1,125 @@
+package tiny
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Flash is the storage a spool lives on, such as a region of a board's
+// flash exposed by its machine package.
+type Flash interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// slotSize is a spooled event's slot: its length followed by the event.
+const slotSize = 2 + MaxEvent
+
+// header holds the spool's read and write counters, in the first slot.
+const headerSize = slotSize
+
+// ErrSpoolFull is returned when the spool has no free slot, the event is
+// dropped.
+var ErrSpoolFull = errors.New("tiny: spool is full")
+
+// Spool is a ring of fixed size event slots on flash, surviving reboots.
+type Spool struct {
+	flash Flash
+	slots uint32
+	head  uint32
+	tail  uint32
+	slot  [slotSize]byte
+}
+
+// OpenSpool opens the spool in the first size bytes of flash, recovering its
+// counters from a previous boot. Flash that has never held a spool must be
+// zeroed.
+func OpenSpool(flash Flash, size int64) (*Spool, error) {
+	slots := (size - headerSize) / slotSize
+
+	if slots < 1 {
+		return nil, errors.New("tiny: spool region is too small")
+	}
+
+	s := &Spool{flash: flash, slots: uint32(slots)}
+
+	if _, err := flash.ReadAt(s.slot[:8], 0); err != nil {
+		return nil, err
+	}
+
+	s.head = binary.LittleEndian.Uint32(s.slot[0:4])
+	s.tail = binary.LittleEndian.Uint32(s.slot[4:8])
+
+	if s.tail-s.head > s.slots {
+		return nil, errors.New("tiny: spool header is corrupt")
+	}
+
+	return s, nil
+}
+
+// Len returns the number of spooled events.
+func (s *Spool) Len() int {
+	return int(s.tail - s.head)
+}
+
+// Append spools an event.
+func (s *Spool) Append(event []byte) error {
+	if s.tail-s.head >= s.slots {
+		return ErrSpoolFull
+	}
+
+	binary.LittleEndian.PutUint16(s.slot[0:2], uint16(len(event)))
+	n := copy(s.slot[2:], event)
+
+	if _, err := s.flash.WriteAt(s.slot[:2+n], s.offset(s.tail)); err != nil {
+		return err
+	}
+
+	s.tail++
+
+	return s.writeHeader()
+}
+
+// Replay writes spooled events to w, oldest first, removing each once
+// written.
+func (s *Spool) Replay(w io.Writer) error {
+	for s.head != s.tail {
+		if _, err := s.flash.ReadAt(s.slot[:], s.offset(s.head)); err != nil {
+			return err
+		}
+
+		n := int(binary.LittleEndian.Uint16(s.slot[0:2]))
+
+		if n > MaxEvent {
+			n = MaxEvent
+		}
+
+		if _, err := w.Write(s.slot[2 : 2+n]); err != nil {
+			return err
+		}
+
+		s.head++
+
+		if err := s.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Spool) offset(counter uint32) int64 {
+	return headerSize + int64(counter%s.slots)*slotSize
+}
+
+func (s *Spool) writeHeader() error {
+	var header [8]byte
+
+	binary.LittleEndian.PutUint32(header[0:4], s.head)
+	binary.LittleEndian.PutUint32(header[4:8], s.tail)
+
+	_, err := s.flash.WriteAt(header[:], 0)
+
+	return err
+}
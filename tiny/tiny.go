@@ -0,0 +1,232 @@
+// Package tiny is a reduced logger for microcontrollers built with TinyGo. It
+// encodes events without reflection into static buffers and writes them, one
+// JSON event per line in the format shipped to Loggly, to a link such as a
+// UART, leaving the hop to Loggly to a relay on the host. Events the link
+// refuses are spooled to flash until Flush succeeds.
+package tiny
+
+import (
+	"io"
+	"strconv"
+	"time"
+)
+
+// MaxEvent is the largest encoded event in bytes, longer events are
+// truncated at a field boundary.
+const MaxEvent = 256
+
+// Level mirrors the levels of the full logger.
+type Level int8
+
+// Levels, with the values of the full logger.
+const (
+	LevelTrace Level = -1
+	LevelDebug Level = 0
+	LevelInfo  Level = 1
+	LevelWarn  Level = 2
+	LevelError Level = 3
+	LevelFatal Level = 4
+)
+
+func (l Level) name() (string, int) {
+	switch {
+	case l >= LevelFatal:
+		return "FATAL", 2
+	case l >= LevelError:
+		return "ERROR", 3
+	case l >= LevelWarn:
+		return "WARN", 4
+	case l >= LevelInfo:
+		return "INFO", 6
+	case l >= LevelDebug:
+		return "DEBUG", 7
+	default:
+		return "TRACE", 7
+	}
+}
+
+type kind uint8
+
+const (
+	kindString kind = iota
+	kindInt
+	kindBool
+)
+
+// Field is a metadata field. Only strings, integers and booleans are
+// supported, which keeps encoding free of reflection.
+type Field struct {
+	key  string
+	kind kind
+	str  string
+	num  int64
+}
+
+// String creates a string field.
+func String(key, value string) Field {
+	return Field{key: key, kind: kindString, str: value}
+}
+
+// Int creates an integer field.
+func Int(key string, value int64) Field {
+	return Field{key: key, kind: kindInt, num: value}
+}
+
+// Bool creates a boolean field.
+func Bool(key string, value bool) Field {
+	f := Field{key: key, kind: kindBool}
+
+	if value {
+		f.num = 1
+	}
+
+	return f
+}
+
+// Logger writes events to a link. It is not safe for concurrent use, guard
+// it if several goroutines log.
+type Logger struct {
+	// Level is the lowest level written.
+	Level Level
+
+	// Now returns the current time. Boards without a real time clock can
+	// return the time since boot, events then carry it in uptime_ms too.
+	Now func() time.Time
+
+	link  io.Writer
+	spool *Spool
+	start time.Time
+	buf   [MaxEvent]byte
+}
+
+// New creates a logger writing to link, spooling to spool when the link
+// fails. spool may be nil.
+func New(link io.Writer, spool *Spool) *Logger {
+	l := &Logger{Level: LevelInfo, Now: time.Now, link: link, spool: spool}
+	l.start = l.Now()
+
+	return l
+}
+
+// Log writes an event at level.
+func (l *Logger) Log(level Level, message string, fields ...Field) error {
+	if level < l.Level {
+		return nil
+	}
+
+	event := l.encode(level, message, fields)
+
+	if _, err := l.link.Write(event); err != nil {
+		if l.spool == nil {
+			return err
+		}
+
+		return l.spool.Append(event)
+	}
+
+	return nil
+}
+
+// Info writes an Info level event.
+func (l *Logger) Info(message string, fields ...Field) error {
+	return l.Log(LevelInfo, message, fields...)
+}
+
+// Warn writes a Warn level event.
+func (l *Logger) Warn(message string, fields ...Field) error {
+	return l.Log(LevelWarn, message, fields...)
+}
+
+// Error writes an Error level event.
+func (l *Logger) Error(message string, fields ...Field) error {
+	return l.Log(LevelError, message, fields...)
+}
+
+// Flush writes spooled events to the link, oldest first, until the spool is
+// empty or the link fails.
+func (l *Logger) Flush() error {
+	if l.spool == nil {
+		return nil
+	}
+
+	return l.spool.Replay(l.link)
+}
+
+// encode renders an event into the logger's buffer. The result is only valid
+// until the next call.
+func (l *Logger) encode(level Level, message string, fields []Field) []byte {
+	now := l.Now()
+	name, severity := level.name()
+
+	b := l.buf[:0]
+	b = append(b, `{"timestamp":"`...)
+	b = now.UTC().AppendFormat(b, time.RFC3339)
+	b = append(b, `","level":"`...)
+	b = append(b, name...)
+	b = append(b, `","severity":`...)
+	b = strconv.AppendInt(b, int64(severity), 10)
+	b = append(b, `,"message":`...)
+	b = appendString(b, message, MaxEvent/2)
+	b = append(b, `,"uptime_ms":`...)
+	b = strconv.AppendInt(b, int64(now.Sub(l.start)/time.Millisecond), 10)
+	b = append(b, `,"metadata":{`...)
+
+	// Leave room for the closing braces and newline.
+	limit := MaxEvent - 3
+
+	for i, f := range fields {
+		mark := len(b)
+
+		if i > 0 {
+			b = append(b, ',')
+		}
+
+		b = appendString(b, f.key, limit-len(b))
+		b = append(b, ':')
+
+		switch f.kind {
+		case kindString:
+			b = appendString(b, f.str, limit-len(b))
+		case kindInt:
+			b = strconv.AppendInt(b, f.num, 10)
+		case kindBool:
+			b = strconv.AppendBool(b, f.num == 1)
+		}
+
+		// The buffer is static, drop fields that don't fit.
+		if len(b) > limit {
+			b = b[:mark]
+			break
+		}
+	}
+
+	return append(b, '}', '}', '\n')
+}
+
+// appendString appends s as a JSON string, truncating it to roughly max
+// bytes.
+func appendString(b []byte, s string, max int) []byte {
+	b = append(b, '"')
+
+	for i := 0; i < len(s) && max > 8; i++ {
+		c := s[i]
+
+		switch {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+			max -= 2
+		case c == '\n':
+			b = append(b, '\\', 'n')
+			max -= 2
+		case c < 0x20:
+			b = append(b, `\u00`...)
+			b = append(b, "0123456789abcdef"[c>>4], "0123456789abcdef"[c&0xf])
+			max -= 6
+		default:
+			b = append(b, c)
+			max--
+		}
+	}
+
+	return append(b, '"')
+}
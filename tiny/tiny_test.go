@@ -0,0 +1,117 @@
+package tiny
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memoryFlash []byte
+
+func (f memoryFlash) ReadAt(p []byte, off int64) (int, error) {
+	return copy(p, f[off:]), nil
+}
+
+func (f memoryFlash) WriteAt(p []byte, off int64) (int, error) {
+	return copy(f[off:], p), nil
+}
+
+type link struct {
+	bytes.Buffer
+	down bool
+}
+
+func (l *link) Write(p []byte) (int, error) {
+	if l.down {
+		return 0, errors.New("link down")
+	}
+
+	return l.Buffer.Write(p)
+}
+
+func TestLog(t *testing.T) {
+	var out link
+
+	boot := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := boot
+
+	l := New(&out, nil)
+	l.Now = func() time.Time { return now }
+	l.start = boot
+
+	now = now.Add(1500 * time.Millisecond)
+	l.Info("gps \"fix\"", String("mode", "3d"), Int("satellites", 9), Bool("rtk", false))
+	l.Log(LevelDebug, "This is filtered.")
+
+	want := `{"timestamp":"2020-01-01T00:00:01Z","level":"INFO","severity":6,"message":"gps \"fix\"","uptime_ms":1500,"metadata":{"mode":"3d","satellites":9,"rtk":false}}` + "\n"
+
+	if out.String() != want {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestLogTruncates(t *testing.T) {
+	var out link
+
+	l := New(&out, nil)
+	l.Info(strings.Repeat("m", 500), String("first", strings.Repeat("v", 100)), String("dropped", strings.Repeat("v", 100)))
+
+	line := out.Bytes()
+
+	if len(line) > MaxEvent {
+		t.Errorf("expected at most %d bytes, got %d", MaxEvent, len(line))
+	}
+
+	var event map[string]interface{}
+
+	if err := json.Unmarshal(line, &event); err != nil {
+		t.Fatalf("expected valid JSON, got %q: %v", line, err)
+	}
+
+	if metadata := event["metadata"].(map[string]interface{}); metadata["dropped"] != nil {
+		t.Errorf("expected the field that doesn't fit to be dropped, got %v", metadata)
+	}
+}
+
+func TestSpool(t *testing.T) {
+	flash := make(memoryFlash, headerSize+2*slotSize)
+
+	spool, err := OpenSpool(flash, int64(len(flash)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &link{down: true}
+	l := New(out, spool)
+
+	for _, message := range []string{"one", "two", "three"} {
+		err = l.Error(message)
+	}
+
+	if err != ErrSpoolFull || spool.Len() != 2 {
+		t.Fatalf("expected a full spool of 2, got %d: %v", spool.Len(), err)
+	}
+
+	// The spool survives a reboot.
+	if spool, err = OpenSpool(flash, int64(len(flash))); err != nil || spool.Len() != 2 {
+		t.Fatalf("unexpected reopened spool of %d: %v", spool.Len(), err)
+	}
+
+	l = New(out, spool)
+	out.down = false
+
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || !strings.Contains(lines[0], `"message":"one"`) || !strings.Contains(lines[1], `"message":"two"`) {
+		t.Errorf("unexpected replay %q", out.String())
+	}
+
+	if spool.Len() != 0 {
+		t.Errorf("expected an empty spool, got %d", spool.Len())
+	}
+}
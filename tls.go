@@ -0,0 +1,145 @@
+package log
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// SetClientCertificate presents the certificate and key in the given PEM files
+// to endpoints that require client authentication, such as an internal relay.
+// The files are checked before each new connection and reloaded when rotated,
+// so renewals need no restart. Pass empty paths to stop presenting one.
+func SetClientCertificate(certFile, keyFile string) error {
+	var reloader *certReloader
+
+	if certFile != "" || keyFile != "" {
+		var err error
+
+		if reloader, err = newCertReloader(certFile, keyFile); err != nil {
+			return err
+		}
+	}
+
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	loggerSingleton.transport.clientCert = reloader
+	loggerSingleton.rebuildClient()
+
+	return nil
+}
+
+// SetRootCAs verifies the endpoint against the certificate authorities in the
+// given PEM file instead of the system pool. Pass an empty path to use the
+// system pool again.
+func SetRootCAs(caFile string) error {
+	var pool *x509.CertPool
+
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+
+		if err != nil {
+			return err
+		}
+
+		pool = x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", caFile)
+		}
+	}
+
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	loggerSingleton.transport.rootCAs = pool
+	loggerSingleton.rebuildClient()
+
+	return nil
+}
+
+// certReloader serves a client certificate, reloading it from disk when the
+// files change.
+type certReloader struct {
+	sync.Mutex
+	certFile string
+	keyFile  string
+	cert     *tls.Certificate
+	modTime  time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("both a certificate and a key file are required")
+	}
+
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// reload loads the certificate if either file changed since the last load.
+// It must be called with the lock held, or before the reloader is shared.
+func (r *certReloader) reload() error {
+	modTime, err := latestModTime(r.certFile, r.keyFile)
+
+	if err != nil {
+		return err
+	}
+
+	if r.cert != nil && !modTime.After(r.modTime) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+
+	if err != nil {
+		return err
+	}
+
+	r.cert = &cert
+	r.modTime = modTime
+
+	return nil
+}
+
+// getClientCertificate implements tls.Config.GetClientCertificate. A failed
+// reload, say halfway through a rotation, keeps the previous certificate.
+func (r *certReloader) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if err := r.reload(); err != nil && r.cert == nil {
+		return nil, err
+	}
+
+	return r.cert, nil
+}
+
+func latestModTime(paths ...string) (time.Time, error) {
+	var latest time.Time
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
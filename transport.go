@@ -0,0 +1,291 @@
+package log
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RequestHook mutates an outgoing request just before it is sent, to sign it
+// or add credentials. Returning an error aborts the send.
+type RequestHook func(req *http.Request) error
+
+// SetRequestHook registers a hook run on every request shipped to Loggly.
+// Pass nil to remove it.
+func SetRequestHook(hook RequestHook) {
+	loggerSingleton.Lock()
+	loggerSingleton.requestHook = hook
+	loggerSingleton.Unlock()
+}
+
+// transportOptions configures the HTTP client used for shipping.
+type transportOptions struct {
+	localAddr  net.IP
+	proxy      *url.URL
+	clientCert *certReloader
+	rootCAs    *x509.CertPool
+	dns        *dnsCache
+	pool       PoolConfig
+
+	// custom replaces the built transport, see SetTransport.
+	custom http.RoundTripper
+}
+
+// PoolConfig tunes the shipping client's connection pool. Zero values keep
+// the net/http defaults.
+type PoolConfig struct {
+	// MaxIdleConns limits idle connections across all hosts.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost limits idle connections kept per host, which high
+	// volume senders should raise to avoid reconnecting.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost limits connections per host, including active ones.
+	MaxConnsPerHost int
+
+	// IdleConnTimeout closes connections idle for longer than this.
+	IdleConnTimeout time.Duration
+
+	// ForceHTTP1 disables HTTP/2, for relays that misbehave on it.
+	ForceHTTP1 bool
+}
+
+// SetTransport ships through a custom http.RoundTripper instead of the one
+// built from the transport settings, which then no longer apply. Pass nil to
+// go back to the built transport.
+func SetTransport(transport http.RoundTripper) {
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	loggerSingleton.transport.custom = transport
+	loggerSingleton.rebuildClient()
+}
+
+// SetHTTPClient ships through client, with its own timeout, transport and
+// proxy settings, instead of the client built from the transport settings,
+// which then no longer apply. Pass nil to go back to the built client.
+func SetHTTPClient(client *http.Client) {
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	loggerSingleton.customClient = client
+	loggerSingleton.rebuildClient()
+}
+
+// defaultRequestTimeout bounds each request to Loggly unless set otherwise.
+const defaultRequestTimeout = 30 * time.Second
+
+// SetRequestTimeout bounds each request to Loggly, including reading the
+// response, 30 seconds by default. Retries each get the full timeout. Zero
+// removes the bound, leaving only the client's own timeout.
+func SetRequestTimeout(timeout time.Duration) {
+	loggerSingleton.Lock()
+	loggerSingleton.requestTimeout = timeout
+	loggerSingleton.Unlock()
+}
+
+// SetConnectionPool tunes the shipping client's connection pool.
+func SetConnectionPool(pool PoolConfig) {
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	loggerSingleton.transport.pool = pool
+	loggerSingleton.rebuildClient()
+}
+
+// SetLocalAddr binds outgoing connections to a local address, either an IP
+// address or the name of a network interface, so log traffic leaves through
+// a specific network on multi-homed hosts. Pass an empty string to let the
+// system choose again.
+func SetLocalAddr(addr string) error {
+	ip, err := resolveLocalAddr(addr)
+
+	if err != nil {
+		return err
+	}
+
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	loggerSingleton.transport.localAddr = ip
+	loggerSingleton.rebuildClient()
+
+	return nil
+}
+
+// SetProxy ships through a proxy, given as an "http://", "https://" or
+// "socks5://" URL, with optional credentials in the user info. Pass an empty
+// string to fall back to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+// variables, which also accept socks5 URLs.
+func SetProxy(proxy string) error {
+	var u *url.URL
+
+	if proxy != "" {
+		var err error
+
+		if u, err = parseProxy(proxy); err != nil {
+			return err
+		}
+	}
+
+	loggerSingleton.Lock()
+	defer loggerSingleton.Unlock()
+
+	loggerSingleton.transport.proxy = u
+	loggerSingleton.rebuildClient()
+
+	return nil
+}
+
+func parseProxy(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %v", proxy, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, expected http, https or socks5", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy %q has no host", proxy)
+	}
+
+	return u, nil
+}
+
+func resolveLocalAddr(addr string) (net.IP, error) {
+	if addr == "" {
+		return nil, nil
+	}
+
+	if ip := net.ParseIP(addr); ip != nil {
+		return ip, nil
+	}
+
+	iface, err := net.InterfaceByName(addr)
+
+	if err != nil {
+		return nil, fmt.Errorf("local address %q is neither an IP address nor an interface: %v", addr, err)
+	}
+
+	addrs, err := iface.Addrs()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var fallback net.IP
+
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+
+		if !ok {
+			continue
+		}
+
+		// Prefer IPv4, the management networks we bind to rarely have IPv6.
+		if ip4 := ipNet.IP.To4(); ip4 != nil {
+			return ip4, nil
+		}
+
+		if fallback == nil {
+			fallback = ipNet.IP
+		}
+	}
+
+	if fallback == nil {
+		return nil, fmt.Errorf("interface %q has no addresses", addr)
+	}
+
+	return fallback, nil
+}
+
+// rebuildClient replaces the shipping client with one built from the
+// transport options, unless a custom client is set. It must be called with
+// the lock held.
+func (l *logger) rebuildClient() {
+	if l.customClient != nil {
+		l.client = l.customClient
+		return
+	}
+
+	l.client = &http.Client{Transport: l.transport.roundTripper()}
+}
+
+func (o transportOptions) roundTripper() http.RoundTripper {
+	if o.custom != nil {
+		return o.custom
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	o.configureDial(transport)
+
+	if o.proxy != nil {
+		transport.Proxy = http.ProxyURL(o.proxy)
+	}
+
+	if o.pool.MaxIdleConns > 0 {
+		transport.MaxIdleConns = o.pool.MaxIdleConns
+	}
+
+	if o.pool.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = o.pool.MaxIdleConnsPerHost
+	}
+
+	if o.pool.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = o.pool.MaxConnsPerHost
+	}
+
+	if o.pool.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = o.pool.IdleConnTimeout
+	}
+
+	if o.clientCert != nil || o.rootCAs != nil {
+		config := &tls.Config{RootCAs: o.rootCAs}
+
+		if o.clientCert != nil {
+			config.GetClientCertificate = o.clientCert.getClientCertificate
+		}
+
+		transport.TLSClientConfig = config
+	}
+
+	if o.pool.ForceHTTP1 {
+		// A non-nil, empty TLSNextProto turns off HTTP/2 negotiation.
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return transport
+}
+
+func (o transportOptions) dialer() *net.Dialer {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	if o.localAddr != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: o.localAddr}
+	}
+
+	return dialer
+}
+
+func (o transportOptions) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if o.dns != nil {
+		return o.dns.dial(ctx, o.dialer(), network, addr)
+	}
+
+	return o.dialer().DialContext(ctx, network, addr)
+}
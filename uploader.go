@@ -0,0 +1,284 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+)
+
+// SetSpoolOnly makes the logger write every batch to the spool set with
+// SetSpool instead of shipping it, leaving delivery to an Uploader, such as
+// the loggly-uploader command, watching the same directory. The application's
+// lifetime is then decoupled from delivery.
+func SetSpoolOnly(enabled bool) {
+	loggerSingleton.Lock()
+	loggerSingleton.spoolOnly = enabled
+	loggerSingleton.Unlock()
+}
+
+func (l *logger) isSpoolOnly() bool {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.spoolOnly
+}
+
+// spoolOnlyBatch writes messages to the spool in spool only mode.
+func (l *logger) spoolOnlyBatch(messages []*logMessage) error {
+	var err error
+
+	if !l.spoolMessages(messages) {
+		err = errors.New("could not write messages to the spool")
+	}
+
+	for _, m := range messages {
+		m.resolve(err)
+	}
+
+	return err
+}
+
+// UploaderConfig configures an Uploader.
+type UploaderConfig struct {
+	// Dir is the spool directory to upload from.
+	Dir string
+
+	// Token and Tags select the Loggly input, as for SetupLogger.
+	Token string
+	Tags  []string
+
+	// URL overrides the bulk endpoint built from Token and Tags.
+	URL string
+
+	// Routes sends events of a retention class to the input for the route's
+	// token and tags, as for SetRetentionRoute, which the application's
+	// routes should match.
+	Routes map[string]UploaderRoute
+
+	// PollInterval is how often the spool is checked for new messages, 1
+	// second if zero.
+	PollInterval time.Duration
+
+	// BytesPerSecond limits upload bandwidth, unlimited if zero.
+	BytesPerSecond int64
+
+	// RetryMin and RetryMax bound the exponential backoff between failed
+	// uploads, 1 second and 1 minute if zero.
+	RetryMin time.Duration
+	RetryMax time.Duration
+}
+
+// UploaderRoute is the input a retention class uploads to. An empty Token
+// keeps the uploader's.
+type UploaderRoute struct {
+	Token string
+	Tags  []string
+}
+
+// Uploader ships a spool directory's messages to Loggly. Several uploaders
+// may watch the same directory, only one uploads at a time.
+type Uploader struct {
+	config UploaderConfig
+	logger *logger
+	spool  *spool
+}
+
+// NewUploader creates an uploader for config.Dir.
+func NewUploader(config UploaderConfig) (*Uploader, error) {
+	if config.Dir == "" {
+		return nil, errors.New("a spool directory is required")
+	}
+
+	if config.PollInterval == 0 {
+		config.PollInterval = time.Second
+	}
+
+	if config.RetryMin == 0 {
+		config.RetryMin = time.Second
+	}
+
+	if config.RetryMax == 0 {
+		config.RetryMax = time.Minute
+	}
+
+	if config.URL == "" {
+		if err := validateToken(config.Token); err != nil {
+			return nil, err
+		}
+	}
+
+	routes := map[string]route{}
+
+	for class, r := range config.Routes {
+		token := r.Token
+		if token == "" {
+			token = config.Token
+		}
+
+		if err := validateToken(token); err != nil {
+			return nil, err
+		}
+
+		routes[class] = route{url: inputURL(true, token, r.Tags), tags: r.Tags}
+	}
+
+	s, err := openSpool(config.Dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := newLogger(config.Token, LogLevelInfo, config.Tags, true, false)
+
+	// An explicit endpoint carries its own credentials.
+	if config.URL != "" {
+		l.url = config.URL
+		l.tokenErr = nil
+	}
+
+	if len(config.Routes) > 0 {
+		l.routes = routes
+	}
+
+	return &Uploader{config: config, logger: l, spool: s}, nil
+}
+
+// Run uploads until ctx is done, then closes the spool. It returns early with
+// the error if the endpoint rejects the token, leaving the spool as it is.
+func (u *Uploader) Run(ctx context.Context) error {
+	defer u.spool.close()
+
+	for {
+		batch, err := u.spool.next(spoolReplayBatch)
+
+		if err != nil {
+			return err
+		}
+
+		if batch == nil {
+			if !sleepContext(ctx, u.config.PollInterval) {
+				return nil
+			}
+
+			continue
+		}
+
+		// Payloads are grouped by the input they were routed to, as in
+		// replaySpool.
+		var urls []string
+		groups := map[string][][]byte{}
+
+		for _, payload := range batch.payloads {
+			url := u.logger.payloadURL(payload)
+
+			if _, ok := groups[url]; !ok {
+				urls = append(urls, url)
+			}
+
+			groups[url] = append(groups[url], payload)
+		}
+
+		for _, url := range urls {
+			if ok, err := u.upload(ctx, batch, url, groups[url]); err != nil || !ok {
+				return err
+			}
+		}
+
+		if err := u.spool.commit(batch); err != nil {
+			return err
+		}
+	}
+}
+
+// upload posts payloads of batch to url, retrying transient failures with
+// backoff, and throttles to the bandwidth limit. Batches rejected as too
+// large, or for any other reason no retry will fix, are halved to find the
+// payloads at fault, which are quarantined so the rest of the spool keeps
+// uploading. It reports false if ctx was done first, and returns
+// authentication failures, which stop the uploader with the batch left in
+// the spool.
+func (u *Uploader) upload(ctx context.Context, batch *spoolBatch, url string, payloads [][]byte) (bool, error) {
+	backoff := u.config.RetryMin
+
+	for {
+		body := bytes.Join(payloads, []byte("\n"))
+		start := time.Now()
+		err := u.logger.post(ctx, url, body)
+
+		if err == nil {
+			return sleepContext(ctx, u.throttle(len(body), time.Since(start))), nil
+		}
+
+		if ctx.Err() != nil {
+			return false, nil
+		}
+
+		if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrCircuitOpen) {
+			return false, err
+		}
+
+		wait, retry := retryDelay(err)
+
+		if !retry {
+			if len(payloads) < 2 {
+				return true, u.spool.reject(batch, payloads)
+			}
+
+			half := len(payloads) / 2
+
+			for _, part := range [][][]byte{payloads[:half], payloads[half:]} {
+				if ok, err := u.upload(ctx, batch, url, part); err != nil || !ok {
+					return ok, err
+				}
+			}
+
+			return true, nil
+		}
+
+		if wait < backoff {
+			wait = backoff
+		}
+
+		if !sleepContext(ctx, wait) {
+			return false, nil
+		}
+
+		if backoff *= 2; backoff > u.config.RetryMax {
+			backoff = u.config.RetryMax
+		}
+	}
+}
+
+// throttle returns how long to wait after sending n bytes in elapsed to stay
+// within the bandwidth limit.
+func (u *Uploader) throttle(n int, elapsed time.Duration) time.Duration {
+	if u.config.BytesPerSecond <= 0 {
+		return 0
+	}
+
+	budget := time.Duration(float64(n) / float64(u.config.BytesPerSecond) * float64(time.Second))
+
+	if budget <= elapsed {
+		return 0
+	}
+
+	return budget - elapsed
+}
+
+// sleepContext sleeps for d, reporting false if ctx was done first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
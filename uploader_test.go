@@ -0,0 +1,272 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUploader(t *testing.T) {
+	dir := tempSpoolDir(t)
+
+	// The application only writes to the spool.
+	app := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	app.synchronous = true
+	app.spoolOnly = true
+
+	var err error
+	if app.spool, err = openSpool(dir); err != nil {
+		t.Fatal(err)
+	}
+	app.spool.segmentAge = 10 * time.Millisecond
+
+	ack := make(chan error, 1)
+	app.log(record{output: "This is uploaded later.", level: LogLevelInfo, ack: ack})
+
+	if err := <-ack; err != nil {
+		t.Fatalf("expected the message to be spooled, got %s", err)
+	}
+
+	var requests int32
+	bodies := make(chan string, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first upload to exercise the retry.
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(b)
+	}))
+	defer server.Close()
+
+	uploader, err := NewUploader(UploaderConfig{
+		Dir:          dir,
+		URL:          server.URL,
+		PollInterval: time.Millisecond,
+		RetryMin:     time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() { done <- uploader.Run(ctx) }()
+
+	select {
+	case body := <-bodies:
+		if !strings.Contains(body, "This is uploaded later.") {
+			t.Errorf("unexpected body %q", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the spooled message to be uploaded")
+	}
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	app.spool.close()
+}
+
+func TestUploaderThrottle(t *testing.T) {
+	u := &Uploader{config: UploaderConfig{BytesPerSecond: 1000}}
+
+	if wait := u.throttle(500, 100*time.Millisecond); wait != 400*time.Millisecond {
+		t.Errorf("expected to wait 400ms, got %s", wait)
+	}
+
+	if wait := u.throttle(500, time.Second); wait != 0 {
+		t.Errorf("expected no wait for a slow upload, got %s", wait)
+	}
+
+	if wait := (&Uploader{}).throttle(500, 0); wait != 0 {
+		t.Errorf("expected no limit, got %s", wait)
+	}
+}
+
+func TestUploaderQuarantinesRejects(t *testing.T) {
+	dir := tempSpoolDir(t)
+
+	app := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	app.synchronous = true
+	app.spoolOnly = true
+
+	var err error
+	if app.spool, err = openSpool(dir); err != nil {
+		t.Fatal(err)
+	}
+	app.spool.segmentAge = 10 * time.Millisecond
+
+	for _, output := range []string{"This is fine.", "This is poisoned.", "This is fine too."} {
+		app.log(record{output: output, level: LogLevelInfo})
+	}
+
+	bodies := make(chan string, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+
+		if strings.Contains(string(b), "poisoned") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		bodies <- string(b)
+	}))
+	defer server.Close()
+
+	uploader, err := NewUploader(UploaderConfig{Dir: dir, URL: server.URL, PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() { done <- uploader.Run(ctx) }()
+
+	var uploaded string
+
+	for !strings.Contains(uploaded, "fine.") || !strings.Contains(uploaded, "fine too.") {
+		select {
+		case body := <-bodies:
+			uploaded += body
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected the other messages uploaded, got %q", uploaded)
+		}
+	}
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	app.spool.close()
+
+	rejected, _ := filepath.Glob(filepath.Join(dir, spoolQuarantine, "*.rejected"))
+
+	if len(rejected) != 1 {
+		t.Fatalf("expected the rejected message quarantined, got %v", rejected)
+	}
+
+	if b, _ := ioutil.ReadFile(rejected[0]); !strings.Contains(string(b), "poisoned") || strings.Contains(string(b), "fine") {
+		t.Errorf("unexpected quarantined payloads %q", b)
+	}
+}
+
+func TestUploaderStopsOnAuthFailure(t *testing.T) {
+	dir := tempSpoolDir(t)
+
+	app := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	app.synchronous = true
+	app.spoolOnly = true
+
+	var err error
+	if app.spool, err = openSpool(dir); err != nil {
+		t.Fatal(err)
+	}
+	app.spool.segmentAge = 10 * time.Millisecond
+	app.log(record{output: "This is kept.", level: LogLevelInfo})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	uploader, err := NewUploader(UploaderConfig{Dir: dir, URL: server.URL, PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := uploader.Run(context.Background()); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+
+	app.spool.close()
+
+	if rejected, _ := filepath.Glob(filepath.Join(dir, spoolQuarantine, "*")); len(rejected) != 0 {
+		t.Errorf("expected nothing quarantined, got %v", rejected)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestUploaderRoutes(t *testing.T) {
+	dir := tempSpoolDir(t)
+
+	app := newLogger("yourlogglytoken", 0, []string{"test"}, false, false)
+	app.synchronous = true
+	app.spoolOnly = true
+
+	var err error
+	if app.spool, err = openSpool(dir); err != nil {
+		t.Fatal(err)
+	}
+	app.spool.segmentAge = 10 * time.Millisecond
+
+	(&Entry{logger: app}).WithRetention(RetentionAudit).Infoln("This is an audit event.")
+	(&Entry{logger: app}).Infoln("This is a regular event.")
+
+	uploader, err := NewUploader(UploaderConfig{
+		Dir:          dir,
+		Token:        "yourlogglytoken",
+		Routes:       map[string]UploaderRoute{RetentionAudit: {Token: "audittoken", Tags: []string{"audit"}}},
+		PollInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := make(chan string, 10)
+	uploader.logger.client = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		b, _ := ioutil.ReadAll(r.Body)
+		requests <- r.URL.Path + " " + string(b)
+
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("")), Header: http.Header{}}, nil
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() { done <- uploader.Run(ctx) }()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case request := <-requests:
+			audit := strings.Contains(request, "audit event")
+
+			if audit != strings.HasPrefix(request, "/bulk/audittoken/tag/audit/ ") || strings.Contains(request, "audit event") == strings.Contains(request, "regular event") {
+				t.Errorf("unexpected request %q", request)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the spooled messages to be uploaded")
+		}
+	}
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	app.spool.close()
+}
@@ -0,0 +1,38 @@
+package log
+
+import (
+	"time"
+)
+
+// uptimeField is the top level field carrying the logger's monotonic uptime.
+const uptimeField = "uptime_ms"
+
+// SetUptimeField adds an "uptime_ms" field to every event: the milliseconds
+// since the logger started, read from the monotonic clock. Events stay
+// orderable by it when the wall clock jumps, e.g. on GPS lock or an NTP step.
+func SetUptimeField(enabled bool) {
+	loggerSingleton.Lock()
+	loggerSingleton.uptimeField = enabled
+	loggerSingleton.Unlock()
+}
+
+// withUptime returns fields with the uptime field if it is enabled.
+func (l *logger) withUptime(fields map[string]interface{}) map[string]interface{} {
+	l.Lock()
+	enabled := l.uptimeField
+	l.Unlock()
+
+	if !enabled {
+		return fields
+	}
+
+	stamped := make(map[string]interface{}, len(fields)+1)
+
+	for key, value := range fields {
+		stamped[key] = value
+	}
+
+	stamped[uptimeField] = int64(l.uptime() / time.Millisecond)
+
+	return stamped
+}
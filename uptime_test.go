@@ -0,0 +1,23 @@
+package log
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUptimeField(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	clock := NewManualClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l.clock = clock
+	l.started = clock.Now()
+	l.uptimeField = true
+
+	clock.Advance(1500 * time.Millisecond)
+	l.buildAndShipMessage("This is an info statement.", LogLevelInfo, false, nil)
+
+	if body := receive(t, bodies); !strings.Contains(body, `"uptime_ms":1500`) {
+		t.Errorf("unexpected body %q", body)
+	}
+}
@@ -0,0 +1,147 @@
+package log
+
+import (
+	"time"
+)
+
+// Volume is the shipped volume of a tag or component.
+type Volume struct {
+	Events uint64
+	Bytes  uint64
+}
+
+// VolumeConfig configures volume reporting.
+type VolumeConfig struct {
+	// DailySummary ships a "loggly.volume" Info event with the previous UTC
+	// day's volumes once a day rolls over.
+	DailySummary bool
+
+	// CostPerGB, if set, adds an estimated ingest cost to the summary.
+	CostPerGB float64
+}
+
+// SetVolumeReporting configures volume reporting. Volumes are always tracked
+// and available from Stats, this controls the daily summary event.
+func SetVolumeReporting(config VolumeConfig) {
+	loggerSingleton.Lock()
+	loggerSingleton.volumeConfig = config
+	loggerSingleton.Unlock()
+}
+
+// volume accumulates a UTC day's shipped volume. It is guarded by the stats
+// lock.
+type volume struct {
+	day        time.Time
+	total      Volume
+	components map[string]*Volume
+	tags       map[string]*Volume
+}
+
+func newVolume(day time.Time) *volume {
+	return &volume{day: day, components: map[string]*Volume{}, tags: map[string]*Volume{}}
+}
+
+func (v *volume) add(m *logMessage, tags []string) {
+	size := uint64(m.size)
+
+	v.total.Events++
+	v.total.Bytes += size
+
+	if m.component != "" {
+		addVolume(v.components, m.component, size)
+	}
+
+	for _, tag := range tags {
+		addVolume(v.tags, tag, size)
+	}
+}
+
+func addVolume(volumes map[string]*Volume, key string, size uint64) {
+	entry, ok := volumes[key]
+
+	if !ok {
+		entry = &Volume{}
+		volumes[key] = entry
+	}
+
+	entry.Events++
+	entry.Bytes += size
+}
+
+func copyVolumes(volumes map[string]*Volume) map[string]Volume {
+	copied := make(map[string]Volume, len(volumes))
+
+	for key, v := range volumes {
+		copied[key] = *v
+	}
+
+	return copied
+}
+
+// recordVolume accounts shipped messages to the day of now, returning the
+// previous day's volume when the day rolled over.
+func (s *stats) recordVolume(now time.Time, messages []*logMessage, defaultTags []string) *volume {
+	day := now.UTC().Truncate(24 * time.Hour)
+
+	s.Lock()
+	defer s.Unlock()
+
+	var previous *volume
+
+	if s.volume == nil || !s.volume.day.Equal(day) {
+		if s.volume != nil && s.volume.total.Events > 0 {
+			previous = s.volume
+		}
+
+		s.volume = newVolume(day)
+	}
+
+	for _, m := range messages {
+		tags := defaultTags
+		if m.url != "" {
+			tags = m.tags
+		}
+
+		s.volume.add(m, tags)
+	}
+
+	return previous
+}
+
+// summary describes the day's volume for the daily summary event.
+func (v *volume) summary(costPerGB float64) map[string]interface{} {
+	summary := map[string]interface{}{
+		"day":        v.day.Format("2006-01-02"),
+		"events":     v.total.Events,
+		"bytes":      v.total.Bytes,
+		"components": copyVolumes(v.components),
+		"tags":       copyVolumes(v.tags),
+	}
+
+	if costPerGB > 0 {
+		summary["estimated_cost"] = float64(v.total.Bytes) / (1 << 30) * costPerGB
+	}
+
+	return summary
+}
+
+// recordVolume accounts a shipped batch and ships the daily summary when the
+// day rolls over.
+func (l *logger) recordVolume(messages []*logMessage) {
+	if len(messages) == 0 {
+		return
+	}
+
+	now := l.now()
+
+	l.Lock()
+	tags := l.tags
+	config := l.volumeConfig
+	l.Unlock()
+
+	previous := l.stats.recordVolume(now, messages, tags)
+
+	if previous != nil && config.DailySummary {
+		l.buildAndShipMessage("loggly.volume", LogLevelInfo, false, previous.summary(config.CostPerGB))
+	}
+}
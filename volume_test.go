@@ -0,0 +1,66 @@
+package log
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVolumeStats(t *testing.T) {
+	l, bodies := newTestLogger(t, true)
+
+	clock := NewManualClock(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+	l.clock = clock
+
+	l.buildAndShipMessage("radio chatter", LogLevelInfo, false, map[string]interface{}{"component": "radio"})
+	l.buildAndShipMessage("radio chatter", LogLevelInfo, false, map[string]interface{}{"component": "radio"})
+	l.buildAndShipMessage("gps fix", LogLevelInfo, false, map[string]interface{}{"component": "gps"})
+	l.flush()
+
+	body := receive(t, bodies)
+	snapshot := l.stats.snapshot()
+
+	if !snapshot.VolumeDay.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected volume day %v", snapshot.VolumeDay)
+	}
+
+	if snapshot.Volume.Events != 3 || snapshot.Volume.Bytes != uint64(len(body)) {
+		t.Errorf("unexpected volume %+v for a %d byte body", snapshot.Volume, len(body))
+	}
+
+	if radio := snapshot.ComponentVolume["radio"]; radio.Events != 2 {
+		t.Errorf("unexpected radio volume %+v", radio)
+	}
+
+	if tag := snapshot.TagVolume["test"]; tag != snapshot.Volume {
+		t.Errorf("expected the test tag to carry all volume, got %+v", tag)
+	}
+}
+
+func TestVolumeDailySummary(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	clock := NewManualClock(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+	l.clock = clock
+	l.volumeConfig = VolumeConfig{DailySummary: true, CostPerGB: 1}
+
+	l.buildAndShipMessage("gps fix", LogLevelInfo, false, map[string]interface{}{"component": "gps"})
+	receive(t, bodies)
+
+	clock.Advance(24 * time.Hour)
+	l.buildAndShipMessage("gps fix", LogLevelInfo, false, map[string]interface{}{"component": "gps"})
+	receive(t, bodies)
+
+	summary := receive(t, bodies)
+
+	for _, want := range []string{`"message":"loggly.volume"`, `"day":"2020-01-01"`, `"events":1`, `"gps":{"Bytes":`, `"estimated_cost":`} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("expected %s in summary %q", want, summary)
+		}
+	}
+
+	// The new day starts with the event that rolled it over and the summary.
+	if snapshot := l.stats.snapshot(); snapshot.Volume.Events != 2 {
+		t.Errorf("unexpected volume %+v", snapshot.Volume)
+	}
+}
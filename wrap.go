@@ -0,0 +1,87 @@
+package log
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// fieldError is an error annotated by WrapError.
+type fieldError struct {
+	msg    string
+	err    error
+	fields map[string]interface{}
+
+	// reported is set once ReportError has logged the error.
+	reported int32
+}
+
+func (e *fieldError) Error() string {
+	if e.err == nil {
+		return e.msg
+	}
+
+	return e.msg + ": " + e.err.Error()
+}
+
+func (e *fieldError) Unwrap() error {
+	return e.err
+}
+
+// WrapError annotates err with msg and fields, given as alternating keys and
+// values, for ReportError to log at the top level handler. Layers wrap the
+// error on the way up instead of each logging it. It returns nil if err is
+// nil.
+func WrapError(err error, msg string, keyvals ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+
+	return &fieldError{msg: msg, err: err, fields: keyvalFields(keyvals)}
+}
+
+// ReportError logs err at Error level with the fields accumulated by every
+// WrapError in its chain, outer layers taking precedence. An error is only
+// logged the first time it is reported, it returns whether it was logged.
+func ReportError(err error) bool {
+	return loggerSingleton.reportError(err)
+}
+
+func (l *logger) reportError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var layers []*fieldError
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if layer, ok := e.(*fieldError); ok {
+			layers = append(layers, layer)
+		}
+	}
+
+	for _, layer := range layers {
+		if atomic.LoadInt32(&layer.reported) == 1 {
+			return false
+		}
+	}
+
+	if len(layers) > 0 && !atomic.CompareAndSwapInt32(&layers[0].reported, 0, 1) {
+		return false
+	}
+
+	fields := map[string]interface{}{}
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		// Marking inner layers keeps an error that is wrapped again after
+		// being reported from being logged twice.
+		atomic.StoreInt32(&layers[i].reported, 1)
+
+		for key, value := range layers[i].fields {
+			fields[key] = value
+		}
+	}
+
+	(&Entry{logger: l, fields: fields}).Errorln(err.Error())
+
+	return true
+}
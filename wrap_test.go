@@ -0,0 +1,59 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWrapError(t *testing.T) {
+	if WrapError(nil, "load config") != nil {
+		t.Fatal("expected wrapping nil to return nil")
+	}
+
+	cause := errors.New("no such file")
+	err := WrapError(WrapError(cause, "open config", "path", "/etc/app.yaml", "attempt", 1), "load config", "attempt", 2)
+
+	if err.Error() != "load config: open config: no such file" {
+		t.Errorf("unexpected error text %q", err.Error())
+	}
+
+	if !errors.Is(err, cause) {
+		t.Error("expected the wrapped error to unwrap to its cause")
+	}
+}
+
+func TestReportError(t *testing.T) {
+	l, bodies := newTestLogger(t, false)
+
+	inner := WrapError(errors.New("no such file"), "open config", "path", "/etc/app.yaml", "attempt", 1)
+	err := fmt.Errorf("startup: %w", WrapError(inner, "load config", "attempt", 2))
+
+	if !l.reportError(err) {
+		t.Fatal("expected the first report to log")
+	}
+
+	body := receive(t, bodies)
+
+	for _, want := range []string{`"level":"ERROR"`, `"message":"startup: load config: open config: no such file"`, `"path":"/etc/app.yaml"`, `"attempt":2`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in %q", want, body)
+		}
+	}
+
+	// Reporting again, or reporting a layer of it, logs nothing.
+	if l.reportError(err) || l.reportError(inner) || l.reportError(WrapError(inner, "retry")) {
+		t.Error("expected a reported error not to be logged again")
+	}
+
+	if l.reportError(nil) {
+		t.Error("expected a nil error not to be logged")
+	}
+
+	select {
+	case body := <-bodies:
+		t.Errorf("unexpected body %q", body)
+	default:
+	}
+}